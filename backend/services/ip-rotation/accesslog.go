@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// statusRecorder는 핸들러가 보낸 상태 코드와 본문 크기를 기록하는 http.ResponseWriter 래퍼입니다.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// WriteHeader는 상태 코드를 기록하고 전달합니다.
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write는 본문 크기를 기록하고 전달합니다. WriteHeader 없이 쓰면 200으로 간주합니다.
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Flush는 내부 writer가 http.Flusher를 지원하면 버퍼된 응답을 바로 보냅니다. 접근 로그로 감싸도 스트리밍 응답
+// (JSON Lines 내보내기 등)이 끝까지 버퍼링되지 않게 합니다. WriteHeader 없이 호출하면 200으로 간주합니다.
+func (s *statusRecorder) Flush() {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap은 감싼 writer를 반환하여 http.ResponseController가 내부 writer의 기능(쓰기 데드라인, Hijack 등)을 찾게 합니다.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// accessLogMiddleware는 모든 요청의 메서드, 경로, 상태 코드, 응답 크기, 소요 시간, 원격 주소를 기록합니다.
+// 프록시 선택/기록 로그와 별개로 클라이언트 연동 문제를 진단하기 위한 접근 로그입니다.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		log.Printf("[IP-ROTATION] access method=%s path=%s status=%d bytes=%d duration=%s remote=%s",
+			r.Method, r.URL.Path, rec.status, rec.bytes, time.Since(start), r.RemoteAddr)
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusRecorderFlushAndUnwrap(t *testing.T) {
+	inner := httptest.NewRecorder()
+	rec := &statusRecorder{ResponseWriter: inner}
+
+	var w http.ResponseWriter = rec
+	if _, ok := w.(http.Flusher); !ok {
+		t.Fatal("statusRecorder does not implement http.Flusher")
+	}
+	// ResponseController finds the inner writer's capabilities through Unwrap
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Fatalf("ResponseController.Flush: %v", err)
+	}
+	if !inner.Flushed {
+		t.Error("flush was not passed to the inner writer")
+	}
+	if rec.status != http.StatusOK {
+		t.Errorf("status after flush = %d, want 200", rec.status)
+	}
+	if rec.Unwrap() != inner {
+		t.Error("Unwrap did not return the inner writer")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// ActiveHours는 프록시를 선택할 수 있는 반복 시간대입니다(예: 업무 시간에만 라이선스된 프록시).
+// 유지보수 시간대와 달리 Enabled를 바꾸지 않고 선택 후보에서만 제외하므로, 운영자가 지정한 활성/비활성 상태와
+// 시간에 따른 선택 가능 여부가 섞이지 않습니다. 형식과 의미는 MaintenanceWindow와 같습니다(End가 Start보다 이르면 자정을 넘는 시간대).
+type ActiveHours struct {
+	Start    string   `json:"start"`              // "HH:MM", inclusive
+	End      string   `json:"end"`                // "HH:MM", exclusive
+	Days     []string `json:"days,omitempty"`     // mon..sun; empty = every day
+	Timezone string   `json:"timezone,omitempty"` // IANA name, e.g. "Asia/Seoul"; empty = UTC
+}
+
+// validate는 시간대 정의가 올바른지 검사하고, 문제가 있으면 설명을 반환합니다.
+func (h ActiveHours) validate() string {
+	return MaintenanceWindow(h).validate()
+}
+
+// outsideActiveHours는 프록시에 ActiveHours가 있고 now가 그 밖인지 반환합니다. ActiveHours가 없으면 항상 false입니다.
+func (p *ProxyIP) outsideActiveHours(now time.Time) bool {
+	return p.ActiveHours != nil && !MaintenanceWindow(*p.ActiveHours).active(now)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// 프록시 익명성 수준(AnonymityLevel) 값입니다. 빈 값은 아직 점검되지 않았음을 뜻합니다.
+const (
+	AnonymityTransparent = "transparent" // forwards our origin IP to the target
+	AnonymityAnonymous   = "anonymous"   // reveals that a proxy is used, but not our IP
+	AnonymityElite       = "elite"       // indistinguishable from a direct client
+)
+
+// ErrNoEliteProxy는 EliteOnly 설정에서 elite로 확인된 프록시가 하나도 없을 때 반환됩니다.
+var ErrNoEliteProxy = errors.New("no enabled proxies verified as elite")
+
+// forwardingHeaders는 클라이언트(원본) IP를 대상 서버에 전달하는 헤더입니다.
+var forwardingHeaders = []string{
+	"X-Forwarded-For",
+	"X-Real-Ip",
+	"Forwarded",
+	"Client-Ip",
+	"X-Client-Ip",
+	"True-Client-Ip",
+	"X-Originating-Ip",
+}
+
+// proxyRevealingHeaders는 IP는 드러내지 않지만 프록시 사용 사실을 드러내는 헤더입니다.
+var proxyRevealingHeaders = []string{
+	"Via",
+	"Proxy-Connection",
+	"X-Proxy-Id",
+	"X-Bluecoat-Via",
+}
+
+// parseEchoedHeaders는 헤더 에코 서비스의 JSON 응답에서 요청 헤더를 추출합니다.
+// {"headers": {...}} 형식(httpbin 등)과 최상위 객체 형식을 모두 받으며, 값은 문자열 또는 문자열 배열일 수 있습니다.
+func parseEchoedHeaders(body []byte) (http.Header, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if raw, ok := doc["headers"]; ok {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	header := make(http.Header, len(doc))
+	for name, raw := range doc {
+		var single string
+		if err := json.Unmarshal(raw, &single); err == nil {
+			header.Add(name, single)
+			continue
+		}
+		var multi []string
+		if err := json.Unmarshal(raw, &multi); err == nil {
+			for _, v := range multi {
+				header.Add(name, v)
+			}
+		}
+	}
+	return header, nil
+}
+
+// classifyAnonymity는 대상 서버가 받은 헤더로 익명성 수준을 판정합니다.
+// 원본 IP 전달 헤더에 originIP가 보이면 transparent입니다. originIP를 모르면 전달 헤더가 있는 것만으로
+// transparent로 간주합니다(보수적 판정). 프록시 흔적 헤더만 있으면 anonymous, 아무것도 없으면 elite입니다.
+func classifyAnonymity(header http.Header, originIP string) string {
+	revealing := false
+	for _, name := range forwardingHeaders {
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		if originIP == "" {
+			return AnonymityTransparent
+		}
+		for _, v := range values {
+			if strings.Contains(v, originIP) {
+				return AnonymityTransparent
+			}
+		}
+		revealing = true
+	}
+	for _, name := range proxyRevealingHeaders {
+		if header.Get(name) != "" {
+			revealing = true
+		}
+	}
+	if revealing {
+		return AnonymityAnonymous
+	}
+	return AnonymityElite
+}
+
+// checkAnonymity는 프록시를 통해 헤더 에코 서비스(checkURL)를 조회하여 프록시의 익명성 수준을 판정합니다.
+// 프록시가 헤더를 볼 수 있도록 checkURL은 평문 http여야 합니다.
+func (p *IPPool) checkAnonymity(ctx context.Context, proxy *ProxyIP, checkURL, originIP string) (string, error) {
+	proxyURL, proxyHeader, err := p.proxyEndpoint(proxy)
+	if err != nil {
+		return "", err
+	}
+	body, err := fetchThroughProxy(ctx, p.upstreamDial(), proxyURL, proxyHeader, checkURL)
+	if err != nil {
+		return "", err
+	}
+	header, err := parseEchoedHeaders(body)
+	if err != nil {
+		return "", err
+	}
+	return classifyAnonymity(header, originIP), nil
+}
+
+// discoverOriginIP는 exitIPURL을 풀의 프록시 없이 조회하여 이 서비스의 원본(외부) IP를 반환합니다.
+// UpstreamProxy를 쓰는 경우 dial이 그 프록시를 거치므로 upstream의 외부 IP가 원본 IP가 됩니다.
+// 조회에 실패하거나 본문이 IP가 아니면 빈 문자열을 반환합니다.
+func discoverOriginIP(ctx context.Context, dial dialFunc, exitIPURL string) string {
+	// A nil proxy URL makes the transport connect to the target through dial
+	body, err := fetchThroughProxy(ctx, dial, nil, nil, exitIPURL)
+	if err != nil {
+		return ""
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
+
+// filterElite는 elite로 확인된 프록시만 반환합니다. 호출자는 p.mu 잠금을 보유해야 합니다.
+func filterElite(proxies []*ProxyIP) []*ProxyIP {
+	elite := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy.AnonymityLevel == AnonymityElite {
+			elite = append(elite, proxy)
+		}
+	}
+	return elite
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net"
+	"strings"
+)
+
+// maxASNAntiAffinity는 asnAntiAffinity로 피할 수 있는 최근 선택 수의 상한입니다.
+const maxASNAntiAffinity = 64
+
+// parseASN은 "AS15169 Google LLC", "AS15169", "as15169" 같은 값에서 "AS15169"를 추출합니다. 형식이 다르면 빈 문자열입니다.
+func parseASN(value string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+	asn := strings.ToUpper(fields[0])
+	digits, ok := strings.CutPrefix(asn, "AS")
+	if !ok || digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return ""
+	}
+	return asn
+}
+
+// subnetOf는 출구 IP가 속한 대역을 CIDR로 반환합니다(IPv4는 /24, IPv6는 /48). IP가 아니면 빈 문자열입니다.
+func subnetOf(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// networkKey는 anti-affinity에서 프록시가 속한 네트워크를 나타내는 값입니다. ASN을 우선하고, 없으면 서브넷,
+// 둘 다 모르면 빈 문자열(비교 대상 아님)입니다.
+func networkKey(proxy *ProxyIP) string {
+	if proxy.ASN != "" {
+		return proxy.ASN
+	}
+	return proxy.Subnet
+}
+
+// noteNetworkLocked는 선택된 프록시의 네트워크를 최근 선택 목록에 기록합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) noteNetworkLocked(proxy *ProxyIP) {
+	if p.config.ASNAntiAffinity <= 0 {
+		return
+	}
+	p.recentNetworks = append(p.recentNetworks, networkKey(proxy))
+	if extra := len(p.recentNetworks) - p.config.ASNAntiAffinity; extra > 0 {
+		p.recentNetworks = append(p.recentNetworks[:0], p.recentNetworks[extra:]...)
+	}
+}
+
+// preferOtherNetworks는 최근 ASNAntiAffinity번의 선택과 다른 네트워크(ASN/서브넷)에 있는 프록시만 남깁니다.
+// 네트워크를 모르는 프록시는 항상 남으며, 모두 걸러지면 입력 목록을 그대로 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) preferOtherNetworks(proxies []*ProxyIP) []*ProxyIP {
+	if p.config.ASNAntiAffinity <= 0 || len(p.recentNetworks) == 0 {
+		return proxies
+	}
+	recent := make(map[string]bool, len(p.recentNetworks))
+	for _, key := range p.recentNetworks {
+		if key != "" {
+			recent[key] = true
+		}
+	}
+	other := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !recent[networkKey(proxy)] {
+			other = append(other, proxy)
+		}
+	}
+	if len(other) == 0 {
+		return proxies
+	}
+	return other
+}
+
+// asnCountsLocked는 soft-removed가 아닌 프록시 수를 ASN별로 셉니다. ASN을 모르는 프록시는 "unknown"으로 묶습니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) asnCountsLocked() map[string]int {
+	counts := make(map[string]int)
+	for _, proxy := range p.proxies {
+		if proxy.Removed {
+			continue
+		}
+		asn := proxy.ASN
+		if asn == "" {
+			asn = "unknown"
+		}
+		counts[asn]++
+	}
+	return counts
+}
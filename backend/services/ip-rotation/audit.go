@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 감사 로그 조회(GET /admin/audit)의 기본/최대 반환 건수입니다.
+const (
+	defaultAuditQueryLimit = 100
+	maxAuditQueryLimit     = 1000
+)
+
+// auditRedacted는 감사 기록에서 비밀 값(비밀번호, 프록시 헤더 값)을 대신하는 문자열입니다.
+const auditRedacted = "[redacted]"
+
+// AuditRecord는 관리 API 변경 한 건의 감사 기록입니다. JSONL 파일의 한 줄로 저장됩니다.
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor"`  // X-Username / X-User-Id set by the API gateway after JWT verification
+	Action    string    `json:"action"` // e.g. proxy.add, proxy.update, config.update
+	ProxyID   string    `json:"proxyId,omitempty"`
+	Before    any       `json:"before,omitempty"`
+	After     any       `json:"after,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+}
+
+// auditLog는 감사 기록을 추가 전용 JSONL 파일에 기록합니다.
+type auditLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// auditLogger는 AUDIT_LOG_PATH가 설정된 경우 main에서 생성됩니다. nil이면 감사 기록을 남기지 않습니다.
+var auditLogger *auditLog
+
+// newAuditLog는 path에 기록하는 감사 로그를 생성하고, 디렉터리가 없으면 만듭니다.
+func newAuditLog(path string) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return &auditLog{path: path}, nil
+}
+
+// Append는 감사 기록 한 건을 파일 끝에 추가합니다. 기록은 한 번의 write로 쓰여 줄이 섞이지 않습니다.
+func (a *auditLog) Append(rec AuditRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query는 since 이후(포함) 기록을 오래된 순서로 최대 limit건 반환합니다. 손상된 줄은 건너뜁니다.
+func (a *auditLog) Query(since time.Time, limit int) ([]AuditRecord, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	records := make([]AuditRecord, 0)
+	f, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() && len(records) < limit {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Timestamp.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// auditActor는 요청을 보낸 관리자를 식별합니다. API 게이트웨이가 JWT 검증 후 붙이는 헤더를 사용하며,
+// 없으면 "unknown"을 반환합니다.
+func auditActor(r *http.Request) string {
+	if v := r.Header.Get("X-Username"); v != "" {
+		return v
+	}
+	if v := r.Header.Get("X-User-Id"); v != "" {
+		return v
+	}
+	return "unknown"
+}
+
+// audit은 관리 API 변경을 감사 로그에 기록합니다. 감사 로그가 꺼져 있으면 아무것도 하지 않으며,
+// 기록 실패는 이미 적용된 변경을 되돌리지 않고 로그만 남깁니다.
+func audit(r *http.Request, action, proxyID string, before, after any) {
+	if auditLogger == nil {
+		return
+	}
+	rec := AuditRecord{
+		Timestamp: time.Now().UTC(),
+		Actor:     auditActor(r),
+		Action:    action,
+		ProxyID:   proxyID,
+		Before:    before,
+		After:     after,
+		Remote:    r.RemoteAddr,
+	}
+	if err := auditLogger.Append(rec); err != nil {
+		log.Printf("[IP-ROTATION] Audit log write failed: action=%s proxy=%s err=%v", action, proxyID, err)
+	}
+}
+
+// auditProxyLocked는 감사 기록용 프록시 스냅샷을 만들고 비밀번호와 헤더 값을 가립니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func auditProxyLocked(proxy *ProxyIP) map[string]any {
+	data, err := json.Marshal(proxy)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]any
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	redactSecrets(snapshot)
+	return snapshot
+}
+
+// redactSecrets는 JSON으로 풀어 놓은 프록시에서 비밀번호와 헤더 값을 auditRedacted로 바꿉니다.
+func redactSecrets(proxy map[string]any) {
+	if _, ok := proxy["password"]; ok {
+		proxy["password"] = auditRedacted
+	}
+	if headers, ok := proxy["headers"].(map[string]any); ok {
+		for name := range headers {
+			headers[name] = auditRedacted
+		}
+	}
+}
+
+// auditProxy는 id의 현재 상태를 감사 기록용으로 스냅샷합니다. 프록시가 없으면 nil을 반환합니다.
+func (p *IPPool) auditProxy(id string) map[string]any {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	proxy, ok := p.proxies[id]
+	if !ok {
+		return nil
+	}
+	return auditProxyLocked(proxy)
+}
+
+// handleAudit은 감사 기록을 조회합니다(관리자용). since(RFC 3339)와 limit으로 범위를 제한합니다.
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	if auditLogger == nil {
+		writeErr(w, http.StatusNotFound, errors.New("audit log is disabled (set AUDIT_LOG_PATH)"))
+		return
+	}
+
+	query := r.URL.Query()
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, errors.New("since must be an RFC 3339 timestamp"))
+			return
+		}
+		since = t
+	}
+	limit := defaultAuditQueryLimit
+	if v := query.Get("limit"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &limit); err != nil || limit <= 0 {
+			writeErr(w, http.StatusBadRequest, errors.New("limit must be a positive integer"))
+			return
+		}
+	}
+	if limit > maxAuditQueryLimit {
+		limit = maxAuditQueryLimit
+	}
+
+	records, err := auditLogger.Query(since, limit)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"records": records,
+		"count":   len(records),
+	})
+}
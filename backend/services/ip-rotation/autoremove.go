@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// deadProxySweepInterval은 장기간 죽어 있는 프록시를 찾는 정리 루틴의 실행 주기입니다.
+const deadProxySweepInterval = 10 * time.Minute
+
+// markUnhealthyStreak는 프록시의 비정상 연속 구간 시작 시각을 기록합니다. 이미 진행 중이면 유지합니다.
+func markUnhealthyStreak(proxy *ProxyIP) {
+	if proxy.UnhealthySince.Load().IsZero() {
+		proxy.UnhealthySince.Store(time.Now())
+	}
+}
+
+// StartDeadProxySweeper는 AutoRemoveAfterHours 이상 계속 비정상(unhealthy 또는 실패로 비활성화)인 프록시를
+// 주기적으로 soft-remove하는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartDeadProxySweeper() {
+	p.mu.Lock()
+	if p.sweepRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.sweepRunning = true
+	hours := p.config.AutoRemoveAfterHours
+	stop := p.stopSweep
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Dead proxy sweeper started (autoRemoveAfter=%dh)", hours)
+		ticker := time.NewTicker(deadProxySweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.sweepDeadProxies()
+			case <-stop:
+				log.Printf("[IP-ROTATION] Dead proxy sweeper stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopDeadProxySweeper는 죽은 프록시 정리 루틴을 중지합니다.
+func (p *IPPool) StopDeadProxySweeper() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sweepRunning {
+		close(p.stopSweep)
+		p.sweepRunning = false
+		p.stopSweep = make(chan struct{})
+	}
+}
+
+// sweepDeadProxies는 비정상 구간이 AutoRemoveAfterHours를 넘은 프록시를 soft-remove하고, 제거한 수를 반환합니다.
+// 관리자가 비활성화/격리한 프록시는 대상이 아닙니다. soft-remove이므로 같은 주소로 다시 추가하면 복원됩니다.
+func (p *IPPool) sweepDeadProxies() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.config.AutoRemoveAfterHours <= 0 {
+		return 0
+	}
+	limit := time.Duration(p.config.AutoRemoveAfterHours) * time.Hour
+	now := time.Now()
+	removed := 0
+	for _, id := range p.order {
+		proxy := p.proxies[id]
+		since := proxy.UnhealthySince.Load()
+		if proxy.Removed || since.IsZero() || now.Sub(since) < limit {
+			continue
+		}
+		// Admin-disabled, quarantined or quota-capped proxies are not dead
+		dead := proxy.HealthStatus == "unhealthy"
+		if !proxy.Enabled {
+			dead = proxy.DisabledReason == DisabledReasonMaxFailures || proxy.DisabledReason == DisabledReasonLowSuccessRate
+		}
+		if !dead {
+			continue
+		}
+
+		proxy.Removed = true
+		proxy.RemovedAt = now
+		if proxy.Enabled {
+			proxy.Enabled = false
+			proxy.DisabledAt = now
+			proxy.DisabledReason = DisabledReasonRemoved
+		}
+		p.recordEvent(id, EventDisabled, "auto removed: dead too long", 0)
+		log.Printf("[IP-ROTATION] Proxy auto-removed: id=%s addr=%s unhealthy_since=%s",
+			id, proxy.Address, since.Format(time.RFC3339))
+		removed++
+	}
+	if removed > 0 {
+		p.invalidateWeights()
+		p.autoSave()
+	}
+	return removed
+}
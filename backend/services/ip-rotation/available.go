@@ -0,0 +1,90 @@
+package main
+
+import (
+	"maps"
+	"time"
+)
+
+// AvailableProxy는 클라이언트가 직접 로테이션할 때 쓰는 사용 가능한 프록시 한 개의 정보입니다.
+// 관리자 목록과 달리 통계와 내부 상태는 포함하지 않습니다.
+type AvailableProxy struct {
+	ID           string            `json:"proxyId"`
+	ProxyURL     string            `json:"proxyUrl"` // ready to use; credentials percent-encoded
+	Protocol     string            `json:"protocol"`
+	Country      string            `json:"country,omitempty"`
+	Provider     string            `json:"provider,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Priority     int               `json:"priority"`
+	HealthStatus string            `json:"healthStatus"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	TimeoutMs    int64             `json:"timeoutMs,omitempty"`
+	TLSProfile   string            `json:"tlsProfile,omitempty"`
+}
+
+// GetAvailableProxies는 지금 선택될 수 있는(활성, unhealthy 아님, 분당 요청 한도 미만, 할당량 남음, eliteOnly 충족) 프록시 중
+// filter에 맞는 것을 등록 순서대로 반환합니다. 아직 점검되지 않은(unknown) 프록시는 선택과 같이 포함됩니다.
+// 사용 통계는 갱신하지 않습니다. 일시 중지 중에는 /proxy/next와 같이 ErrServicePaused를 반환합니다.
+func (p *IPPool) GetAvailableProxies(filter ProxyFilter) ([]AvailableProxy, error) {
+	if p.Paused() {
+		return nil, ErrServicePaused
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	available := make([]AvailableProxy, 0)
+	for _, id := range p.order {
+		proxy := p.proxies[id]
+		if !p.usableLocked(proxy) || !filter.Matches(proxy) {
+			continue
+		}
+		proxyURL, err := proxy.GetProxyURL()
+		if err != nil {
+			continue
+		}
+		available = append(available, AvailableProxy{
+			ID:           proxy.ID,
+			ProxyURL:     proxyURL.String(),
+			Protocol:     proxy.Protocol,
+			Country:      proxy.Country,
+			Provider:     proxy.Provider,
+			Tags:         append([]string(nil), proxy.Tags...),
+			Priority:     proxy.Priority,
+			HealthStatus: proxy.HealthStatus,
+			Headers:      maps.Clone(proxy.Headers),
+			TimeoutMs:    proxy.TimeoutMs,
+			TLSProfile:   proxy.TLSProfile,
+		})
+	}
+	return available, nil
+}
+
+// UsableProxyCount는 지금 선택될 수 있는 프록시 수를 반환합니다. /ready 판단에 씁니다.
+func (p *IPPool) UsableProxyCount() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	count := 0
+	for _, proxy := range p.proxies {
+		if p.usableLocked(proxy) {
+			count++
+		}
+	}
+	return count
+}
+
+// usableLocked는 프록시가 지금 선택될 수 있는지, 즉 eligibleLocked 조건을 만족하고 분당 요청 한도에도 걸리지 않았는지 반환합니다.
+// candidatesLocked와 같은 한도 검사를 하므로 /ready와 /proxy/available이 ErrRateLimited와 어긋나지 않습니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) usableLocked(proxy *ProxyIP) bool {
+	return p.eligibleLocked(proxy) && !proxy.overRateLimit(time.Now())
+}
+
+// eligibleLocked는 프록시가 활성, unhealthy 아님, 기한 남음, 활성 시간대 안, 할당량 남음, eliteOnly 충족 조건을 모두 만족하는지
+// 반환합니다. 1분 안에 풀리는 분당 요청 한도는 보지 않습니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) eligibleLocked(proxy *ProxyIP) bool {
+	now := time.Now()
+	if !proxy.Enabled || proxy.Removed || proxy.Draining || proxy.HealthStatus == "unhealthy" || proxy.expired(now) || proxy.outsideActiveHours(now) {
+		return false
+	}
+	return !p.quotaExhausted(proxy) && (!p.config.EliteOnly || proxy.AnonymityLevel == AnonymityElite)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPausedPoolHandsOutNothing(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 2)
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+	pool.Pause()
+
+	for _, tt := range []struct {
+		target  string
+		handler http.HandlerFunc
+	}{
+		{"/proxy/available", handleAvailableProxies},
+		{"/ready", handleReady},
+	} {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s status = %d while paused, want 503 (body %s)", tt.target, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestRateLimitedProxiesAreNotAvailable(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+	if _, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "http://10.0.0.1:8080", MaxRequestsPerMinute: 1}); err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+	if _, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if _, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second select err = %v, want ErrRateLimited", err)
+	}
+
+	available, err := pool.GetAvailableProxies(ProxyFilter{})
+	if err != nil || len(available) != 0 {
+		t.Errorf("available = %d proxies (err %v), want none at the per-minute limit", len(available), err)
+	}
+	rec := httptest.NewRecorder()
+	handleReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/ready status = %d, want 503 while every proxy is rate limited", rec.Code)
+	}
+}
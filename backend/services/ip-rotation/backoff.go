@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// filterBackedOff는 최근 FailureBackoffSeconds 이내에 실패가 기록된 프록시를 후보에서 제외합니다.
+// 모든 후보가 백오프 중이면 풀이 비지 않도록 입력 목록을 그대로 반환합니다. 호출자는 p.mu 잠금을 보유해야 합니다.
+func (p *IPPool) filterBackedOff(proxies []*ProxyIP) []*ProxyIP {
+	window := time.Duration(p.config.FailureBackoffSeconds) * time.Second
+	if window <= 0 {
+		return proxies
+	}
+	now := time.Now()
+	eligible := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if last := proxy.LastFailure.Load(); last.IsZero() || now.Sub(last) >= window {
+			eligible = append(eligible, proxy)
+		}
+	}
+	if len(eligible) == 0 {
+		return proxies
+	}
+	return eligible
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// DisabledReasonBlocked는 대상 사이트에서 차단(403, CAPTCHA 벽 등)된 것으로 보고되어 비활성화된 프록시의 사유입니다.
+// 일반 쿨다운 대신 BlockCooldownMinutes가 지난 뒤에 재활성화됩니다.
+const DisabledReasonBlocked = "blocked"
+
+// RecordBlock은 프록시가 차단되었음을 기록하고, MaxFailures와 관계없이 즉시 비활성화합니다.
+// 일시적 오류(RecordFailure)와 구분되도록 FailCount가 아닌 BlockCount를 증가시킵니다.
+func (p *IPPool) RecordBlock(proxyID string, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[proxyID]
+	if !ok {
+		return
+	}
+	blocks := proxy.BlockCount.Add(1)
+	releaseActive(proxy)
+	p.recordEvent(proxyID, EventBlocked, reason, 0)
+	log.Printf("[IP-ROTATION] Block reported: id=%s blocks=%d reason=%s", proxyID, blocks, reason)
+
+	switch {
+	case proxy.Enabled, proxy.DisabledReason == "", proxy.DisabledReason == DisabledReasonMaxFailures,
+		proxy.DisabledReason == DisabledReasonLowSuccessRate, proxy.DisabledReason == DisabledReasonBlocked:
+	default:
+		// Keep admin, quarantine, quota, drain, maintenance, expiry and removal decisions; the block is still counted
+		p.autoSave()
+		return
+	}
+
+	// A repeated report restarts the block cooldown
+	if proxy.Enabled {
+		p.metrics.autoDisables.Add(1)
+	}
+	proxy.Enabled = false
+	proxy.DisabledAt = time.Now()
+	proxy.DisabledReason = DisabledReasonBlocked
+	markUnhealthyStreak(proxy)
+	p.invalidateWeights()
+	p.recordEvent(proxyID, EventDisabled, "blocked", 0)
+	log.Printf("[IP-ROTATION] Proxy disabled as blocked: id=%s (will re-enable after %d minutes)",
+		proxyID, p.config.BlockCooldownMinutes)
+	p.autoSave()
+}
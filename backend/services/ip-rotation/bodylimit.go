@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// 요청 본문 크기 기본 한도입니다. MAX_BODY_BYTES, MAX_BULK_BODY_BYTES로 바꿀 수 있습니다.
+const (
+	defaultMaxBodyBytes     = 1 << 20  // single proxies, patches, config, record results
+	defaultMaxBulkBodyBytes = 32 << 20 // whole-pool payloads: JSON-array import and diff
+)
+
+// bulkBodyPaths는 풀 전체를 본문으로 받는 경로로, 더 큰 한도(MAX_BULK_BODY_BYTES)를 적용합니다.
+// JSON Lines 가져오기는 예외입니다(isJSONLImport).
+var bulkBodyPaths = map[string]bool{
+	"/admin/proxy-pool/import": true,
+	"/admin/proxy-pool/diff":   true,
+}
+
+// maxBodyMiddleware는 모든 요청 본문을 http.MaxBytesReader로 감싸 한도를 넘는 본문을 읽지 못하게 합니다.
+// 한도를 넘으면 본문을 읽는 핸들러가 *http.MaxBytesError를 받고, writeErr가 413으로 응답합니다.
+func maxBodyMiddleware(next http.Handler, limit, bulkLimit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isJSONLImport(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		n := limit
+		if bulkBodyPaths[r.URL.Path] {
+			n = bulkLimit
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isJSONLImport는 요청이 JSON Lines 형식의 풀 가져오기인지 반환합니다. 이 형식은 한 줄씩 읽어 바로 반영하고
+// 줄마다 maxImportLineBytes로 제한되므로, 메모리가 본문 크기에 비례하지 않아 전체 본문 한도를 적용하지 않습니다.
+// 수십만 개 프록시의 내보내기 파일도 그대로 다시 가져올 수 있습니다.
+func isJSONLImport(r *http.Request) bool {
+	return r.URL.Path == "/admin/proxy-pool/import" &&
+		(r.URL.Query().Get("format") == "jsonl" || strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-ndjson"))
+}
+
+// bodyTooLarge는 err가 요청 본문 한도 초과로 난 오류인지 반환합니다.
+func bodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// bodyLimits는 MAX_BODY_BYTES, MAX_BULK_BODY_BYTES를 읽습니다. 잘못되었거나 양수가 아닌 값은
+// 다른 설정과 같이 env에 기록되고 기본값을 사용합니다.
+func bodyLimits(env *envConfig) (limit, bulkLimit int64) {
+	return envBytes(env, "MAX_BODY_BYTES", defaultMaxBodyBytes), envBytes(env, "MAX_BULK_BODY_BYTES", defaultMaxBulkBodyBytes)
+}
+
+// envBytes는 name을 바이트 단위 양의 정수로 읽습니다. 설정되지 않았거나 잘못된 값이면 def를 반환합니다.
+func envBytes(env *envConfig, name string, def int64) int64 {
+	n := env.Int64(name, def)
+	if n <= 0 {
+		env.invalid(name, os.Getenv(name), def)
+		return def
+	}
+	return n
+}
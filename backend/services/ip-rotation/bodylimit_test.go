@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyMiddlewareExemptsJSONLImport(t *testing.T) {
+	const limit, bulkLimit = 16, 64
+	body := strings.Repeat("x", bulkLimit*2)
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		tooLarge    bool
+	}{
+		{"jsonl import by format", "/admin/proxy-pool/import?format=jsonl", "", false},
+		{"jsonl import by content type", "/admin/proxy-pool/import", "application/x-ndjson", false},
+		{"json array import", "/admin/proxy-pool/import", "application/json", true},
+		{"diff", "/admin/proxy-pool/diff", "application/json", true},
+		{"jsonl elsewhere", "/admin/proxy-pool?format=jsonl", "application/x-ndjson", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var readErr error
+			handler := maxBodyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, readErr = io.ReadAll(r.Body)
+			}), limit, bulkLimit)
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if got := bodyTooLarge(readErr); got != tt.tooLarge {
+				t.Errorf("body too large = %v (err %v), want %v", got, readErr, tt.tooLarge)
+			}
+		})
+	}
+}
+
+func TestBodyLimitsReportInvalidValues(t *testing.T) {
+	t.Setenv("MAX_BODY_BYTES", "1MB")
+	t.Setenv("MAX_BULK_BODY_BYTES", "-1")
+	var env envConfig
+	limit, bulkLimit := bodyLimits(&env)
+	if limit != defaultMaxBodyBytes || bulkLimit != defaultMaxBulkBodyBytes {
+		t.Errorf("limits = %d, %d, want the defaults", limit, bulkLimit)
+	}
+	if len(env.problems) != 2 {
+		t.Errorf("problems = %q, want both settings reported", env.problems)
+	}
+
+	t.Setenv("MAX_BODY_BYTES", "2048")
+	t.Setenv("MAX_BULK_BODY_BYTES", "")
+	env = envConfig{}
+	limit, bulkLimit = bodyLimits(&env)
+	if limit != 2048 || bulkLimit != defaultMaxBulkBodyBytes || len(env.problems) != 0 {
+		t.Errorf("limits = %d, %d, problems = %q", limit, bulkLimit, env.problems)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 일괄 작업(bulk action) 종류입니다.
+const (
+	BulkActionEnable     = "enable"
+	BulkActionDisable    = "disable"
+	BulkActionQuarantine = "quarantine" // disabled and never re-enabled by the cooldown checker
+)
+
+// ProxyFilter는 여러 프록시를 한 번에 고르기 위한 조건입니다. 비어 있는 필드는 조건에서 제외됩니다.
+type ProxyFilter struct {
+	Country  string `json:"country,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// IsEmpty는 설정된 조건이 하나도 없는지 반환합니다.
+func (f ProxyFilter) IsEmpty() bool {
+	return f.Country == "" && f.Tag == "" && f.Protocol == "" && f.Provider == ""
+}
+
+// Matches는 프록시가 모든 조건을 만족하는지 반환합니다(대소문자 무시).
+func (f ProxyFilter) Matches(proxy *ProxyIP) bool {
+	if f.Country != "" && !strings.EqualFold(proxy.Country, f.Country) {
+		return false
+	}
+	if f.Protocol != "" && !strings.EqualFold(proxy.Protocol, f.Protocol) {
+		return false
+	}
+	if f.Provider != "" && !strings.EqualFold(proxy.Provider, f.Provider) {
+		return false
+	}
+	if f.Tag != "" && !proxy.HasTag(f.Tag) {
+		return false
+	}
+	return true
+}
+
+// HasTag는 프록시에 주어진 태그가 있는지 반환합니다(대소문자 무시).
+func (p *ProxyIP) HasTag(tag string) bool {
+	for _, t := range p.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// BulkAction은 필터에 맞는 모든 프록시에 enable/disable/quarantine을 한 번의 잠금으로 적용하고,
+// 상태가 바뀐 프록시 ID 목록을 반환합니다. 저장은 한 번만 예약됩니다.
+func (p *IPPool) BulkAction(filter ProxyFilter, action string) ([]string, error) {
+	if filter.IsEmpty() {
+		return nil, errors.New("at least one filter (country, tag, protocol) is required")
+	}
+	if action != BulkActionEnable && action != BulkActionDisable && action != BulkActionQuarantine {
+		return nil, fmt.Errorf("invalid action: %s, must be one of: enable, disable, quarantine", action)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	affected := make([]string, 0)
+	for id, proxy := range p.proxies {
+		if !filter.Matches(proxy) {
+			continue
+		}
+		switch action {
+		case BulkActionEnable:
+			if proxy.Enabled || proxy.Removed {
+				continue
+			}
+			proxy.Enabled = true
+			proxy.DisabledAt = time.Time{}
+			proxy.DisabledReason = ""
+			p.metrics.manualEnables.Add(1)
+			p.recordEvent(id, EventEnabled, "bulk action", 0)
+		case BulkActionDisable:
+			if !proxy.Enabled {
+				continue
+			}
+			proxy.Enabled = false
+			proxy.DisabledAt = now
+			proxy.DisabledReason = DisabledReasonAdmin
+			p.metrics.manualDisables.Add(1)
+			p.recordEvent(id, EventDisabled, "bulk action", 0)
+		case BulkActionQuarantine:
+			if !proxy.Enabled && proxy.DisabledReason == DisabledReasonQuarantine {
+				continue
+			}
+			proxy.Enabled = false
+			proxy.DisabledAt = now
+			proxy.DisabledReason = DisabledReasonQuarantine
+			p.metrics.manualDisables.Add(1)
+			p.recordEvent(id, EventDisabled, "bulk quarantine", 0)
+		}
+		affected = append(affected, id)
+	}
+	sort.Strings(affected)
+
+	log.Printf("[IP-ROTATION] Bulk action applied: action=%s filter=%+v affected=%d", action, filter, len(affected))
+
+	if len(affected) > 0 {
+		p.invalidateWeights()
+		p.autoSave()
+	}
+	return affected, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// captcha_aware 전략 관련 기본값입니다.
+const (
+	defaultCaptchaWindowMinutes = 60  // rolling window when captchaWindowMinutes is 0
+	maxCaptchaTimes             = 256 // per-proxy timestamps kept; older ones are dropped first
+)
+
+// captchaWindow는 프록시의 최근 CAPTCHA 발생 시각을 보관합니다. 기록 경로는 p.mu 읽기 잠금만 보유하므로
+// 자체 뮤텍스로 보호합니다.
+type captchaWindow struct {
+	mu    sync.Mutex
+	times []time.Time // oldest first
+}
+
+// add는 CAPTCHA 발생 시각을 추가하고, 보관 한도를 넘으면 가장 오래된 시각을 버립니다.
+func (w *captchaWindow) add(at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.times) >= maxCaptchaTimes {
+		w.times = w.times[1:]
+	}
+	w.times = append(w.times, at)
+}
+
+// countSince는 since 이후의 CAPTCHA 수를 반환하며, 그보다 오래된 시각은 정리합니다.
+func (w *captchaWindow) countSince(since time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := 0
+	for i < len(w.times) && w.times[i].Before(since) {
+		i++
+	}
+	w.times = w.times[i:]
+	return len(w.times)
+}
+
+// reset은 보관된 시각을 모두 지웁니다.
+func (w *captchaWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.times = nil
+}
+
+// captchaWindowDuration은 captcha_aware 전략이 보는 최근 구간 길이를 반환합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) captchaWindowDuration() time.Duration {
+	minutes := p.config.CaptchaWindowMinutes
+	if minutes <= 0 {
+		minutes = defaultCaptchaWindowMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// selectCaptchaAware는 최근 구간(captchaWindowMinutes) 안의 CAPTCHA가 가장 적은 프록시들 중 하나를 무작위로 선택합니다.
+// 누적 CaptchaCount와 달리 구간이 지나면 패널티가 완전히 사라집니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) selectCaptchaAware(proxies []*ProxyIP) *ProxyIP {
+	return p.selectRandom(p.fewestRecentCaptchas(proxies))
+}
+
+// fewestRecentCaptchas는 최근 구간 안의 CAPTCHA 수가 가장 적은 프록시들을 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) fewestRecentCaptchas(proxies []*ProxyIP) []*ProxyIP {
+	since := time.Now().Add(-p.captchaWindowDuration())
+	fewest := -1
+	var candidates []*ProxyIP
+	for _, proxy := range proxies {
+		recent := proxy.recentCaptchas.countSince(since)
+		switch {
+		case fewest < 0 || recent < fewest:
+			fewest = recent
+			candidates = append(candidates[:0], proxy)
+		case recent == fewest:
+			candidates = append(candidates, proxy)
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// dialFunc는 점검용 연결을 여는 함수입니다. http.Transport.DialContext와 같은 시그니처입니다.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newDialFunc는 upstream(UpstreamProxy)이 비어 있으면 직접 연결하고, 설정되어 있으면 해당 HTTP 프록시에
+// CONNECT 터널을 열어 연결하는 dialFunc를 반환합니다. upstream은 Validate를 통과한 값이어야 합니다.
+func newDialFunc(upstream string) dialFunc {
+	var direct net.Dialer
+	if upstream == "" {
+		return direct.DialContext
+	}
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return func(context.Context, string, string) (net.Conn, error) {
+			return nil, fmt.Errorf("invalid upstream proxy: %w", err)
+		}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if network != "tcp" && network != "tcp4" && network != "tcp6" {
+			return nil, fmt.Errorf("upstream proxy cannot carry %s", network)
+		}
+		conn, err := dialViaConnect(ctx, direct.DialContext, u, nil, addr)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %w", err)
+		}
+		return conn, nil
+	}
+}
+
+// UpstreamProxy는 풀의 프록시에 도달할 때 거치는 상위 HTTP 프록시 URL을 반환합니다. 설정되지 않았으면 빈 문자열입니다.
+func (p *IPPool) UpstreamProxy() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config.UpstreamProxy
+}
+
+// upstreamDial은 현재 설정의 UpstreamProxy를 반영한 dialFunc를 반환합니다.
+func (p *IPPool) upstreamDial() dialFunc {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return newDialFunc(p.config.UpstreamProxy)
+}
+
+// dialViaConnect는 dial로 HTTP(S) 프록시에 연결해 addr로의 CONNECT 터널을 열고, 터널이 된 연결을 반환합니다.
+// header는 CONNECT 요청에 그대로 실리며, 프록시 URL에 사용자 정보가 있으면 Proxy-Authorization(Basic)으로 보냅니다.
+// https 프록시는 TLS로 감싸 연결합니다. 핸드셰이크는 ctx 데드라인으로 제한됩니다.
+func dialViaConnect(ctx context.Context, dial dialFunc, proxy *url.URL, header http.Header, addr string) (net.Conn, error) {
+	proxyAddr := proxy.Host
+	if proxy.Port() == "" {
+		port := "80"
+		if proxy.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxy.Hostname(), port)
+	}
+	conn, err := dial(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("proxy dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy tls: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: header.Clone(),
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy connect: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy connect: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy connect: %s", resp.Status)
+	}
+
+	// The tunnel outlives the handshake; callers apply their own deadlines
+	conn.SetDeadline(time.Time{})
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn은 CONNECT 응답을 읽을 때 함께 버퍼링된 터널 데이터를 먼저 돌려주는 net.Conn입니다.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
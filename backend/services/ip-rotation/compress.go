@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize는 gzip 압축을 적용할 최소 응답 크기(바이트)입니다. 작은 응답은 압축 오버헤드가 더 큽니다.
+const gzipMinSize = 1024
+
+// gzipMiddleware는 클라이언트가 gzip을 허용하고 응답이 gzipMinSize 이상일 때 응답 본문을 gzip으로 압축합니다.
+func gzipMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next(gw, r)
+		gw.finish()
+	}
+}
+
+// acceptsGzip은 Accept-Encoding 헤더가 gzip을 허용하는지 반환합니다(q=0은 거부로 취급).
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter는 응답이 gzipMinSize에 도달할 때까지 버퍼링한 뒤,
+// 도달하면 gzip 스트림으로 전환하고 그렇지 않으면 원본 그대로 내보냅니다.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         bytes.Buffer
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+// WriteHeader는 상태 코드를 기록해 두고, 압축 여부가 결정될 때 실제로 전송합니다.
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	g.status = status
+	// Bodyless responses and handlers that already encode their output are passed through untouched
+	if status == http.StatusNoContent || status == http.StatusNotModified || g.Header().Get("Content-Encoding") != "" {
+		g.passthrough = true
+		g.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write는 본문을 버퍼링하거나, 임계값을 넘으면 gzip 스트림으로 씁니다.
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.passthrough {
+		return g.ResponseWriter.Write(b)
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+
+	g.buf.Write(b)
+	if g.buf.Len() < gzipMinSize {
+		return len(b), nil
+	}
+
+	h := g.Header()
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	g.ResponseWriter.WriteHeader(g.status)
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+	if _, err := g.gz.Write(g.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	g.buf.Reset()
+	return len(b), nil
+}
+
+// finish는 gzip 스트림을 닫거나, 임계값에 도달하지 않은 응답을 압축 없이 내보냅니다.
+func (g *gzipResponseWriter) finish() {
+	switch {
+	case g.passthrough:
+	case g.gz != nil:
+		g.gz.Close()
+	default:
+		g.ResponseWriter.WriteHeader(g.status)
+		g.ResponseWriter.Write(g.buf.Bytes())
+	}
+}
+
+// Flush는 스트리밍 응답을 위해 지금까지 쓴 데이터를 내보냅니다. 압축 여부가 정해지기 전(버퍼링 중)에는 아무것도 하지 않습니다.
+func (g *gzipResponseWriter) Flush() {
+	switch {
+	case g.gz != nil:
+		g.gz.Flush()
+	case !g.passthrough:
+		return
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
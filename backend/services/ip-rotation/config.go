@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ReloadConfigFile은 JSON 설정 파일을 읽어 현재 설정 위에 덮어쓴 뒤 검증하고 UpdateConfig로 적용합니다.
+// 파일에 없는 필드는 현재 값을 유지하고, 파일에 있는 필드는 맵과 슬라이스를 포함해 파일 값으로 통째로 바뀝니다.
+// 변경된 필드 목록을 반환합니다.
+func (p *IPPool) ReloadConfigFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	p.mu.RLock()
+	current := p.config
+	p.mu.RUnlock()
+
+	// Decode into fresh values: unmarshalling over a copy of the live config would merge into
+	// the maps and slice arrays it shares with p.config, outside the lock and before validation
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	// encoding/json matches keys case-insensitively, so presence does too
+	present := make(map[string]bool, len(raw))
+	for key := range raw {
+		present[strings.ToLower(key)] = true
+	}
+	var fromFile IPPoolConfig
+	if err := json.Unmarshal(data, &fromFile); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	next := current
+	nextVal := reflect.ValueOf(&next).Elem()
+	fileVal := reflect.ValueOf(fromFile)
+	for i := 0; i < nextVal.NumField(); i++ {
+		if present[strings.ToLower(configFieldName(nextVal.Type().Field(i)))] {
+			nextVal.Field(i).Set(fileVal.Field(i))
+		}
+	}
+
+	changes := configDiff(current, next)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	if err := p.UpdateConfig(next); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// configDiff는 두 설정을 비교하여 "jsonName: old -> new" 형식의 변경 목록을 반환합니다.
+func configDiff(oldCfg, newCfg IPPoolConfig) []string {
+	oldVal := reflect.ValueOf(oldCfg)
+	newVal := reflect.ValueOf(newCfg)
+	t := oldVal.Type()
+
+	var changes []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		a := oldVal.Field(i).Interface()
+		b := newVal.Field(i).Interface()
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", configFieldName(field), a, b))
+	}
+	return changes
+}
+
+// configFieldName은 설정 필드의 JSON 이름을 반환합니다. 태그가 없으면 필드 이름입니다.
+func configFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// reloadConfigFromEnvFile은 CONFIG_FILE 환경 변수가 설정된 경우 해당 파일로 설정을 다시 읽고 변경 사항을 로그로 남깁니다.
+func reloadConfigFromEnvFile() {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return
+	}
+	changes, err := globalIPPool.ReloadConfigFile(path)
+	if err != nil {
+		log.Printf("[IP-ROTATION] Config reload from %s failed: %v", path, err)
+		return
+	}
+	if len(changes) == 0 {
+		log.Printf("[IP-ROTATION] Config reload from %s: no changes", path)
+		return
+	}
+	for _, change := range changes {
+		log.Printf("[IP-ROTATION] Config reload from %s: %s", path, change)
+	}
+}
+
+// envConfig는 환경 변수에서 설정 값을 읽고, 파싱할 수 없는 값은 로그를 남긴 뒤 기본값을 사용합니다.
+// 발견된 문제는 problems에 모여 STRICT_CONFIG 검사에 쓰입니다.
+type envConfig struct {
+	problems []string
+}
+
+// invalid는 잘못된 환경 변수 값을 기록하고 경고 로그를 남깁니다.
+func (e *envConfig) invalid(name, value string, def any) {
+	problem := fmt.Sprintf("%s=%q is not a valid value", name, value)
+	e.problems = append(e.problems, problem)
+	log.Printf("[IP-ROTATION] Invalid %s=%q, using default %v", name, value, def)
+}
+
+// invalidEntry는 목록형 환경 변수에서 해석할 수 없어 건너뛴 항목을 기록하고 경고 로그를 남깁니다.
+func (e *envConfig) invalidEntry(name, entry, why string) {
+	e.problems = append(e.problems, fmt.Sprintf("%s entry %q is not valid: %s", name, entry, why))
+	log.Printf("[IP-ROTATION] Ignoring invalid %s entry %q: %s", name, entry, why)
+}
+
+// enforceStrict는 STRICT_CONFIG=true일 때 기록된 문제가 있으면 기본값으로 실행하는 대신 시작을 거부합니다.
+func (e *envConfig) enforceStrict() {
+	if e.Bool("STRICT_CONFIG", false) && len(e.problems) > 0 {
+		log.Fatalf("[IP-ROTATION] Refusing to start with STRICT_CONFIG=true: %s", strings.Join(e.problems, "; "))
+	}
+}
+
+// Int는 name을 정수로 읽습니다. 설정되지 않았거나 잘못된 값이면 def를 반환합니다.
+func (e *envConfig) Int(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		e.invalid(name, v, def)
+		return def
+	}
+	return n
+}
+
+// Int64는 name을 64비트 정수로 읽습니다. 설정되지 않았거나 잘못된 값이면 def를 반환합니다.
+func (e *envConfig) Int64(name string, def int64) int64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		e.invalid(name, v, def)
+		return def
+	}
+	return n
+}
+
+// Float는 name을 실수로 읽습니다. 설정되지 않았거나 잘못된 값이면 def를 반환합니다.
+func (e *envConfig) Float(name string, def float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		e.invalid(name, v, def)
+		return def
+	}
+	return f
+}
+
+// Bool은 name을 불리언(true/false, 1/0 등)으로 읽습니다. 설정되지 않았거나 잘못된 값이면 def를 반환합니다.
+func (e *envConfig) Bool(name string, def bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		e.invalid(name, v, def)
+		return def
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeConfigFile은 임시 디렉터리에 설정 파일을 쓰고 경로를 반환합니다.
+func writeConfigFile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestReloadConfigFileReplacesMapsAndSlices(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{
+		MaxFailures:    7,
+		CountryTargets: map[string]float64{"US": 10},
+		UpstreamPools:  []string{"http://a.example:8080", "http://b.example:8080"},
+	})
+
+	changes, err := pool.ReloadConfigFile(writeConfigFile(t,
+		`{"countryTargets": {"DE": 20}, "upstreamPools": ["http://c.example:8080"]}`))
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("changes = %q, want countryTargets and upstreamPools", changes)
+	}
+	pool.mu.RLock()
+	cfg := pool.config
+	pool.mu.RUnlock()
+	if want := map[string]float64{"DE": 20}; !reflect.DeepEqual(cfg.CountryTargets, want) {
+		t.Errorf("countryTargets = %v, want %v (replaced, not merged)", cfg.CountryTargets, want)
+	}
+	if want := []string{"http://c.example:8080"}; !reflect.DeepEqual(cfg.UpstreamPools, want) {
+		t.Errorf("upstreamPools = %v, want %v", cfg.UpstreamPools, want)
+	}
+	if cfg.MaxFailures != 7 {
+		t.Errorf("maxFailures = %d, want 7 kept from the current config", cfg.MaxFailures)
+	}
+}
+
+func TestReloadConfigFileInvalidLeavesLiveConfig(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{
+		CountryTargets:   map[string]float64{"US": 10},
+		LatencyBucketsMs: []float64{100, 500},
+	})
+
+	_, err := pool.ReloadConfigFile(writeConfigFile(t, `{"countryTargets": {"DE": 200}, "latencyBucketsMs": [50]}`))
+	if err == nil {
+		t.Fatal("reload accepted a countryTargets share above 100")
+	}
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if want := map[string]float64{"US": 10}; !reflect.DeepEqual(pool.config.CountryTargets, want) {
+		t.Errorf("countryTargets = %v after a rejected reload, want %v", pool.config.CountryTargets, want)
+	}
+	if want := []float64{100, 500}; !reflect.DeepEqual(pool.config.LatencyBucketsMs, want) {
+		t.Errorf("latencyBucketsMs = %v after a rejected reload, want %v", pool.config.LatencyBucketsMs, want)
+	}
+}
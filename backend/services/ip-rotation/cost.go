@@ -0,0 +1,57 @@
+package main
+
+import "math"
+
+// defaultCostQualityTolerance는 costQualityTolerance가 0일 때 쓰는 허용 성공률 차이(%p)입니다.
+const defaultCostQualityTolerance = 10.0
+
+// costQualityTolerance는 cost_aware 전략에서 싼 프록시가 최고 성공률보다 뒤처져도 되는 폭(%p)입니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) costQualityTolerance() float64 {
+	if p.config.CostQualityTolerance > 0 {
+		return p.config.CostQualityTolerance
+	}
+	return defaultCostQualityTolerance
+}
+
+// cheapestComparable은 성공률이 후보 중 최고값에서 costQualityTolerance 이내인 프록시들 중 요청당 비용이 가장 낮은 것들을 반환합니다.
+// 싼 프록시가 실패를 거듭해 성공률이 떨어지면 비교 대상에서 빠지므로, 그때에야 비싼 프록시가 선택됩니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) cheapestComparable(proxies []*ProxyIP) []*ProxyIP {
+	best := 0.0
+	for _, proxy := range proxies {
+		best = math.Max(best, calculateSuccessRate(proxy))
+	}
+	floor := best - p.costQualityTolerance()
+
+	var cheapest []*ProxyIP
+	minCost := math.Inf(1)
+	for _, proxy := range proxies {
+		if calculateSuccessRate(proxy) < floor {
+			continue
+		}
+		switch cost := proxy.CostPerRequest; {
+		case cost < minCost:
+			minCost = cost
+			cheapest = append(cheapest[:0], proxy)
+		case cost == minCost:
+			cheapest = append(cheapest, proxy)
+		}
+	}
+	return cheapest
+}
+
+// selectCostAware는 품질이 비슷한 후보 중 가장 싼 프록시들 가운데 하나를 무작위로 선택합니다.
+func (p *IPPool) selectCostAware(proxies []*ProxyIP) *ProxyIP {
+	return p.selectRandom(p.cheapestComparable(proxies))
+}
+
+// estimatedSpendLocked는 프록시별 사용 횟수와 요청당 비용으로 추정한 누적 지출입니다. soft-removed 프록시의 지출도 포함합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) estimatedSpendLocked() float64 {
+	spend := 0.0
+	for _, proxy := range p.proxies {
+		spend += proxy.CostPerRequest * float64(proxy.UsageCount.Load())
+	}
+	return spend
+}
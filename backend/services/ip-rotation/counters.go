@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Counter는 잠금 없이 갱신할 수 있는 int64 카운터입니다. JSON에서는 일반 숫자로 직렬화됩니다.
+// 기록 경로(/proxy/record 등)가 풀 쓰기 잠금 없이 통계를 갱신할 수 있도록 합니다.
+type Counter struct {
+	v atomic.Int64
+}
+
+// Load는 현재 값을 반환합니다.
+func (c *Counter) Load() int64 { return c.v.Load() }
+
+// Store는 값을 설정합니다.
+func (c *Counter) Store(n int64) { c.v.Store(n) }
+
+// Add는 delta를 더하고 새 값을 반환합니다.
+func (c *Counter) Add(delta int64) int64 { return c.v.Add(delta) }
+
+// CompareAndSwap은 현재 값이 old일 때만 new로 바꿉니다.
+func (c *Counter) CompareAndSwap(old, new int64) bool { return c.v.CompareAndSwap(old, new) }
+
+// MarshalJSON은 카운터를 숫자로 직렬화합니다.
+func (c *Counter) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.v.Load())
+}
+
+// UnmarshalJSON은 숫자에서 카운터 값을 복원합니다.
+func (c *Counter) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	c.v.Store(n)
+	return nil
+}
+
+// Gauge는 잠금 없이 갱신할 수 있는 float64 값입니다. JSON에서는 일반 숫자로 직렬화됩니다.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Load는 현재 값을 반환합니다.
+func (g *Gauge) Load() float64 { return math.Float64frombits(g.bits.Load()) }
+
+// Store는 값을 설정합니다.
+func (g *Gauge) Store(f float64) { g.bits.Store(math.Float64bits(f)) }
+
+// Swap은 값을 설정하고 이전 값을 반환합니다.
+func (g *Gauge) Swap(f float64) float64 {
+	return math.Float64frombits(g.bits.Swap(math.Float64bits(f)))
+}
+
+// MarshalJSON은 게이지를 숫자로 직렬화합니다.
+func (g *Gauge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.Load())
+}
+
+// UnmarshalJSON은 숫자에서 게이지 값을 복원합니다.
+func (g *Gauge) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	g.Store(f)
+	return nil
+}
+
+// Timestamp는 잠금 없이 갱신할 수 있는 시각 값입니다(UnixNano로 저장). JSON에서는 time.Time과 같은 형식으로 직렬화됩니다.
+type Timestamp struct {
+	nanos atomic.Int64
+}
+
+// Load는 저장된 시각을 반환합니다. 설정된 적이 없으면 zero time입니다.
+func (t *Timestamp) Load() time.Time {
+	n := t.nanos.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Store는 시각을 설정합니다. zero time은 미설정 상태로 저장됩니다.
+func (t *Timestamp) Store(v time.Time) {
+	if v.IsZero() {
+		t.nanos.Store(0)
+		return
+	}
+	t.nanos.Store(v.UnixNano())
+}
+
+// MarshalJSON은 시각을 RFC 3339 문자열로 직렬화합니다.
+func (t *Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Load())
+}
+
+// UnmarshalJSON은 RFC 3339 문자열에서 시각을 복원합니다.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var v time.Time
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Store(v)
+	return nil
+}
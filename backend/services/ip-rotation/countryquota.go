@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// countryUsageWindow는 국가별 사용 비율을 계산할 때 보는 최근 선택 수입니다.
+const countryUsageWindow = 1000
+
+// countryUsage는 최근 countryUsageWindow회 선택의 국가 분포를 추적하는 링 버퍼입니다.
+// p.mu 쓰기 잠금으로 보호됩니다.
+type countryUsage struct {
+	recent []string // upper-cased country per selection; "" when the proxy has no country
+	next   int
+	counts map[string]int
+}
+
+// record는 선택 한 건의 국가를 기록하고, 창을 벗어난 가장 오래된 기록을 뺍니다.
+func (u *countryUsage) record(country string) {
+	country = strings.ToUpper(country)
+	if u.counts == nil {
+		u.counts = make(map[string]int)
+	}
+	if len(u.recent) < countryUsageWindow {
+		u.recent = append(u.recent, country)
+	} else {
+		old := u.recent[u.next]
+		if u.counts[old]--; u.counts[old] == 0 {
+			delete(u.counts, old)
+		}
+		u.recent[u.next] = country
+		u.next = (u.next + 1) % countryUsageWindow
+	}
+	u.counts[country]++
+}
+
+// share는 최근 선택 중 country가 차지한 비율(%)을 반환합니다.
+func (u *countryUsage) share(country string) float64 {
+	if len(u.recent) == 0 {
+		return 0
+	}
+	return float64(u.counts[strings.ToUpper(country)]) / float64(len(u.recent)) * 100
+}
+
+// preferUnderservedCountry는 CountryTargets에 비해 최근 사용 비율이 가장 많이 모자란 국가의 프록시만 남깁니다.
+// 후보에 목표 미달 국가가 없으면 입력 목록을 그대로 반환합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) preferUnderservedCountry(proxies []*ProxyIP) []*ProxyIP {
+	if len(p.config.CountryTargets) == 0 {
+		return proxies
+	}
+	targets := make(map[string]float64, len(p.config.CountryTargets))
+	for country, target := range p.config.CountryTargets {
+		targets[strings.ToUpper(country)] = target
+	}
+
+	best, bestDeficit := "", 0.0
+	seen := make(map[string]bool)
+	for _, proxy := range proxies {
+		country := strings.ToUpper(proxy.Country)
+		target, ok := targets[country]
+		if !ok || seen[country] {
+			continue
+		}
+		seen[country] = true
+		// Ties go to the alphabetically first country so the choice is deterministic
+		deficit := target - p.countryUsage.share(country)
+		if deficit > bestDeficit || (deficit == bestDeficit && deficit > 0 && country < best) {
+			best, bestDeficit = country, deficit
+		}
+	}
+	if best == "" {
+		return proxies
+	}
+
+	preferred := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.EqualFold(proxy.Country, best) {
+			preferred = append(preferred, proxy)
+		}
+	}
+	return preferred
+}
+
+// countryUsageSummaryLocked는 GetStats에 노출할 최근 국가별 사용 비율과 목표를 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) countryUsageSummaryLocked() map[string]any {
+	shares := make(map[string]string, len(p.countryUsage.counts))
+	for country := range p.countryUsage.counts {
+		name := country
+		if name == "" {
+			name = "unknown"
+		}
+		shares[name] = fmt.Sprintf("%.2f%%", p.countryUsage.share(country))
+	}
+	targets := make(map[string]string, len(p.config.CountryTargets))
+	for country, target := range p.config.CountryTargets {
+		targets[strings.ToUpper(country)] = fmt.Sprintf("%.2f%%", target)
+	}
+	return map[string]any{
+		"window":  len(p.countryUsage.recent),
+		"shares":  shares,
+		"targets": targets,
+	}
+}
+
+// parseCountryTargets는 "KR:30,US:25" 형식의 국가별 목표 비율(%)을 파싱합니다. 형식이 잘못된 항목은 건너뜁니다.
+func parseCountryTargets(v string) map[string]float64 {
+	var targets map[string]float64
+	for _, entry := range strings.Split(v, ",") {
+		country, pct, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			continue
+		}
+		target, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		country = strings.TrimSpace(country)
+		if err != nil || country == "" {
+			continue
+		}
+		if targets == nil {
+			targets = make(map[string]float64)
+		}
+		targets[strings.ToUpper(country)] = target
+	}
+	return targets
+}
@@ -0,0 +1,20 @@
+package main
+
+import "log"
+
+// setCredentialsLocked는 프록시 인증 정보를 바꾸고, 실제로 바뀌었으면 CredentialVersion을 올린 뒤 true를 반환합니다.
+// 이전 인증 정보로 맺은 헬스체크 연결은 바로 버려 다음 점검부터 새 인증 정보를 씁니다. 비밀번호는 로그에 남기지 않습니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) setCredentialsLocked(proxy *ProxyIP, username, password, source string) bool {
+	if proxy.Username == username && proxy.Password == password {
+		return false
+	}
+	userChanged := proxy.Username != username
+	proxy.Username = username
+	proxy.Password = password
+	proxy.CredentialVersion++
+	p.healthTransports.drop(proxy.ID)
+	log.Printf("[IP-ROTATION] Proxy credentials changed: id=%s source=%s username_changed=%t credential_version=%d",
+		proxy.ID, source, userChanged, proxy.CredentialVersion)
+	return true
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// 결과 기록 중복 제거(replay protection) 기본값입니다.
+const (
+	defaultRecordDedupWindow = 60    // seconds
+	defaultRecordDedupSize   = 10000 // remembered request IDs
+)
+
+// dedupEntry는 최근에 본 요청 ID와 처음 본 시각입니다.
+type dedupEntry struct {
+	id     string
+	seenAt time.Time
+}
+
+// dedupCache는 최근 요청 ID를 기억하는 크기 제한 LRU입니다. 풀 잠금과 독립된 자체 잠금을 사용합니다.
+type dedupCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front = most recent
+}
+
+// newDedupCache는 비어 있는 중복 제거 캐시를 생성합니다.
+func newDedupCache() *dedupCache {
+	return &dedupCache{
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// seen은 id가 window 이내에 이미 기록되었는지 반환하고, 처음 보는 id면 기록합니다.
+// 캐시가 capacity를 넘으면 가장 오래된 항목부터 제거합니다.
+func (c *dedupCache) seen(id string, window time.Duration, capacity int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if elem, ok := c.entries[id]; ok {
+		if now.Sub(elem.Value.(*dedupEntry).seenAt) < window {
+			return true
+		}
+		// Expired: treat as a new request
+		c.order.Remove(elem)
+		delete(c.entries, id)
+	}
+
+	c.entries[id] = c.order.PushFront(&dedupEntry{id: id, seenAt: now})
+
+	// Evict expired entries and anything beyond capacity, oldest first
+	for c.order.Len() > 0 {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*dedupEntry)
+		if c.order.Len() <= capacity && now.Sub(entry.seenAt) < window {
+			break
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, entry.id)
+	}
+	return false
+}
+
+// IsDuplicateRecord는 클라이언트가 보낸 requestId가 중복 제거 윈도우 내에 이미 처리되었는지 확인합니다.
+// 처음 보는 ID는 기록되며 false를 반환합니다. 빈 ID는 항상 false입니다.
+func (p *IPPool) IsDuplicateRecord(requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+
+	p.mu.RLock()
+	window := p.config.RecordDedupWindow
+	size := p.config.RecordDedupSize
+	p.mu.RUnlock()
+	if window <= 0 {
+		window = defaultRecordDedupWindow
+	}
+	if size <= 0 {
+		size = defaultRecordDedupSize
+	}
+
+	return p.recordDedup.seen(requestID, time.Duration(window)*time.Second, size)
+}
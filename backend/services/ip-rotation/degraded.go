@@ -0,0 +1,28 @@
+package main
+
+import "time"
+
+// HealthStatusDegraded는 헬스체크에는 응답하지만 DegradedLatencyMs보다 느린 프록시의 상태입니다.
+// healthy와 unhealthy 사이 단계로, 선택 시 같은 티어에 다른 프록시가 없을 때만 쓰입니다.
+const HealthStatusDegraded = "degraded"
+
+// slowHealthCheck는 성공한 헬스체크의 응답 시간이 DegradedLatencyMs를 넘는지 반환합니다(0이면 분류하지 않음).
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) slowHealthCheck(latency time.Duration) bool {
+	threshold := time.Duration(p.config.DegradedLatencyMs) * time.Millisecond
+	return threshold > 0 && latency > threshold
+}
+
+// preferNonDegraded는 degraded가 아닌 프록시가 있으면 그것만 반환하고, 모두 degraded면 입력 목록을 그대로 반환합니다.
+func preferNonDegraded(proxies []*ProxyIP) []*ProxyIP {
+	preferred := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy.HealthStatus != HealthStatusDegraded {
+			preferred = append(preferred, proxy)
+		}
+	}
+	if len(preferred) == 0 {
+		return proxies
+	}
+	return preferred
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PoolDiff는 제안된 프록시 목록과 현재 풀의 차이입니다. ID가 없는 새 항목은 주소로 표시됩니다.
+type PoolDiff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Modified  []string `json:"modified"`
+	Unchanged int      `json:"unchanged"`
+	Applied   bool     `json:"applied"`
+}
+
+// proxySpec은 운영자가 지정하는 프록시 설정 필드만 모은 것으로, 통계/상태 필드는 비교에서 제외됩니다.
+// omitempty 덕분에 nil과 빈 슬라이스/맵은 같은 값으로 비교됩니다.
+type proxySpec struct {
+	Address              string              `json:"address"`
+	Protocol             string              `json:"protocol"`
+	Username             string              `json:"username,omitempty"`
+	Password             string              `json:"password,omitempty"`
+	Country              string              `json:"country,omitempty"`
+	City                 string              `json:"city,omitempty"`
+	Priority             int                 `json:"priority,omitempty"`
+	MaxUsageCount        int64               `json:"maxUsageCount,omitempty"`
+	MaxRequestsPerMinute int64               `json:"maxRequestsPerMinute,omitempty"`
+	SupportsUDP          bool                `json:"supportsUdp,omitempty"`
+	Latitude             *float64            `json:"latitude,omitempty"`
+	Longitude            *float64            `json:"longitude,omitempty"`
+	Tags                 []string            `json:"tags,omitempty"`
+	Headers              map[string]string   `json:"headers,omitempty"`
+	TimeoutMs            int64               `json:"timeoutMs,omitempty"`
+	CostPerRequest       float64             `json:"costPerRequest,omitempty"`
+	Provider             string              `json:"provider,omitempty"`
+	Notes                string              `json:"notes,omitempty"`
+	Metadata             map[string]string   `json:"metadata,omitempty"`
+	UserAgents           []string            `json:"userAgents,omitempty"`
+	TLSProfile           string              `json:"tlsProfile,omitempty"`
+	ExpiresAt            time.Time           `json:"expiresAt,omitempty"`
+	MaintenanceWindows   []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+	ActiveHours          *ActiveHours        `json:"activeHours,omitempty"`
+	HealthCheckEnabled   *bool               `json:"healthCheckEnabled,omitempty"`
+}
+
+// specOf는 프록시의 설정 필드를 추출합니다.
+func specOf(proxy *ProxyIP) proxySpec {
+	return proxySpec{
+		Address:              proxy.Address,
+		Protocol:             strings.ToLower(proxy.Protocol),
+		Username:             proxy.Username,
+		Password:             proxy.Password,
+		Country:              proxy.Country,
+		City:                 proxy.City,
+		Priority:             proxy.Priority,
+		MaxUsageCount:        proxy.MaxUsageCount,
+		MaxRequestsPerMinute: proxy.MaxRequestsPerMinute,
+		SupportsUDP:          proxy.SupportsUDP,
+		Latitude:             proxy.Latitude,
+		Longitude:            proxy.Longitude,
+		Tags:                 proxy.Tags,
+		Headers:              proxy.Headers,
+		TimeoutMs:            proxy.TimeoutMs,
+		CostPerRequest:       proxy.CostPerRequest,
+		Provider:             proxy.Provider,
+		Notes:                proxy.Notes,
+		Metadata:             proxy.Metadata,
+		UserAgents:           proxy.UserAgents,
+		TLSProfile:           proxy.TLSProfile,
+		ExpiresAt:            proxy.ExpiresAt,
+		MaintenanceWindows:   proxy.MaintenanceWindows,
+		ActiveHours:          proxy.ActiveHours,
+		HealthCheckEnabled:   proxy.HealthCheckEnabled,
+	}
+}
+
+// equal은 두 설정이 같은지 반환합니다.
+func (s proxySpec) equal(other proxySpec) bool {
+	a, errA := json.Marshal(s)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// DiffPool은 제안된 프록시 목록을 현재 풀(soft-removed 제외)과 비교합니다. 항목은 id가 있으면 id로,
+// 없으면 주소로 기존 프록시와 짝지어집니다. apply가 true면 같은 쓰기 잠금 안에서 차이를 그대로 반영합니다:
+// 추가된 프록시는 AddProxy와 같이 등록되고, 빠진 프록시는 삭제되며, 변경된 프록시는 통계를 유지한 채 설정만 바뀝니다.
+func (p *IPPool) DiffPool(proposed []*ProxyIP, apply bool) (*PoolDiff, error) {
+	return p.diffPool(proposed, apply, nil, nil)
+}
+
+// diffPool은 DiffPool의 본체입니다. scope가 주어지면 scope에 속한 프록시만 비교/삭제 대상이 됩니다.
+// 범위 밖 프록시와 주소가 같은 항목은 건너뛰어, 다른 곳에서 관리하는 프록시를 덮어쓰거나 중복 등록하지 않습니다.
+// merge가 주어지면 기존 프록시의 목표 설정은 merge(현재 설정, 제안된 설정)이 되어, 제안된 항목이 일부 필드만 정할 수 있습니다.
+func (p *IPPool) diffPool(proposed []*ProxyIP, apply bool, scope func(*ProxyIP) bool, merge func(live, proposed proxySpec) proxySpec) (*PoolDiff, error) {
+	verr := &ValidationError{}
+	seen := make(map[string]bool, len(proposed))
+	for i, proxy := range proposed {
+		if proxy == nil {
+			verr.Add(fmt.Sprintf("proxies[%d]", i), "proxy entry must not be null")
+			continue
+		}
+		if proxy.Protocol == "" {
+			proxy.Protocol = "http"
+		}
+		for field, msg := range validateProxy(proxy).Fields {
+			verr.Add(fmt.Sprintf("proxies[%d].%s", i, field), msg)
+		}
+		key := diffKey(proxy)
+		if seen[key] {
+			verr.Add(fmt.Sprintf("proxies[%d]", i), "duplicate proxy: "+key)
+		}
+		seen[key] = true
+	}
+	if verr.HasErrors() {
+		return nil, verr
+	}
+
+	if apply {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+	} else {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+	}
+
+	byAddress := make(map[string]*ProxyIP, len(p.proxies))
+	for _, proxy := range p.proxies {
+		if !proxy.Removed {
+			byAddress[proxy.Address] = proxy
+		}
+	}
+
+	diff := &PoolDiff{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	matched := make(map[string]bool, len(proposed))
+	var added []*ProxyIP
+	modified := make(map[string]proxySpec)
+	for _, proxy := range proposed {
+		var live *ProxyIP
+		if proxy.ID != "" {
+			if existing, ok := p.proxies[proxy.ID]; ok && !existing.Removed {
+				live = existing
+			}
+		} else {
+			live = byAddress[proxy.Address]
+		}
+		if live != nil && scope != nil && !scope(live) {
+			continue
+		}
+		if live == nil {
+			diff.Added = append(diff.Added, diffKey(proxy))
+			added = append(added, proxy)
+			continue
+		}
+		matched[live.ID] = true
+		target := specOf(proxy)
+		if merge != nil {
+			target = merge(specOf(live), target)
+		}
+		if specOf(live).equal(target) {
+			diff.Unchanged++
+			continue
+		}
+		diff.Modified = append(diff.Modified, live.ID)
+		modified[live.ID] = target
+	}
+	for id, proxy := range p.proxies {
+		if !proxy.Removed && !matched[id] && (scope == nil || scope(proxy)) {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+
+	if !apply {
+		return diff, nil
+	}
+
+	for _, id := range diff.Removed {
+		p.deleteProxyLocked(id)
+	}
+	now := time.Now()
+	for id, spec := range modified {
+		p.applySpecLocked(p.proxies[id], spec, now)
+	}
+	for _, proxy := range added {
+		if _, err := p.addProxyLocked(proxy); err != nil {
+			// Entries were validated up front, so this is not expected
+			return nil, err
+		}
+	}
+	p.invalidateWeights()
+	diff.Applied = true
+
+	log.Printf("[IP-ROTATION] Pool diff applied: added=%d removed=%d modified=%d unchanged=%d",
+		len(diff.Added), len(diff.Removed), len(diff.Modified), diff.Unchanged)
+
+	p.autoSave()
+	return diff, nil
+}
+
+// diffKey는 DiffPool에서 제안된 항목을 식별하는 값입니다(id, 없으면 주소).
+func diffKey(proxy *ProxyIP) string {
+	if proxy.ID != "" {
+		return proxy.ID
+	}
+	return proxy.Address
+}
+
+// applySpecLocked는 프록시의 설정 필드를 spec으로 바꾸고 파생 상태(할당량, 점검 상태, 유지보수/만료)를 갱신합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) applySpecLocked(proxy *ProxyIP, spec proxySpec, now time.Time) {
+	addressChanged := proxy.Address != spec.Address
+	udpChanged := proxy.SupportsUDP != spec.SupportsUDP
+
+	proxy.Address = spec.Address
+	if addressChanged {
+		p.addressChangedLocked(proxy)
+	}
+	proxy.Protocol = spec.Protocol
+	p.setCredentialsLocked(proxy, spec.Username, spec.Password, "pool diff")
+	proxy.Country = spec.Country
+	proxy.City = spec.City
+	proxy.Priority = spec.Priority
+	proxy.MaxUsageCount = spec.MaxUsageCount
+	proxy.MaxRequestsPerMinute = spec.MaxRequestsPerMinute
+	proxy.SupportsUDP = spec.SupportsUDP
+	proxy.Latitude = spec.Latitude
+	proxy.Longitude = spec.Longitude
+	proxy.Tags = spec.Tags
+	proxy.Headers = spec.Headers
+	proxy.TimeoutMs = spec.TimeoutMs
+	proxy.CostPerRequest = spec.CostPerRequest
+	proxy.Provider = spec.Provider
+	proxy.Notes = spec.Notes
+	proxy.Metadata = spec.Metadata
+	proxy.UserAgents = spec.UserAgents
+	proxy.TLSProfile = spec.TLSProfile
+	proxy.ExpiresAt = spec.ExpiresAt
+	proxy.MaintenanceWindows = spec.MaintenanceWindows
+	proxy.ActiveHours = spec.ActiveHours
+	p.setHealthCheckEnabledLocked(proxy, spec.HealthCheckEnabled)
+
+	if udpChanged {
+		proxy.UDPStatus = ""
+		if proxy.SupportsUDP {
+			proxy.UDPStatus = "unknown"
+		}
+	}
+	p.refreshQuota(proxy)
+	p.applyMaintenanceLocked(proxy, now)
+	p.applyExpiryLocked(proxy, now)
+	log.Printf("[IP-ROTATION] Proxy updated from pool diff: id=%s addr=%s", proxy.ID, proxy.Address)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplySpecAddressChangeClearsResolvedIPs(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "http://old.example:8080"})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	proxy.ResolvedIPs = []string{"1.2.3.4"}
+	proxy.ResolvedAt = time.Now()
+	pool.refreshIPVersion(proxy)
+	if proxy.IPVersion != 4 {
+		t.Fatalf("ipVersion = %d before the change, want 4 from the resolved IP", proxy.IPVersion)
+	}
+
+	spec := specOf(proxy)
+	spec.Address = "http://new.example:8080"
+	pool.applySpecLocked(proxy, spec, time.Now())
+	if len(proxy.ResolvedIPs) != 0 || !proxy.ResolvedAt.IsZero() {
+		t.Errorf("resolvedIps=%v resolvedAt=%v, want the old host's results cleared", proxy.ResolvedIPs, proxy.ResolvedAt)
+	}
+	if proxy.IPVersion != 0 {
+		t.Errorf("ipVersion = %d, want 0 until the new host is resolved", proxy.IPVersion)
+	}
+}
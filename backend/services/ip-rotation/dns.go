@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+)
+
+// defaultDNSRefreshInterval은 DNSRefreshInterval이 설정되지 않았을 때 사용하는 재조회 주기(초)입니다.
+const defaultDNSRefreshInterval = 300
+
+// StartDNSResolver는 프록시 호스트명을 주기적으로 IP로 미리 조회해 두는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartDNSResolver() {
+	p.mu.Lock()
+	if p.dnsRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.dnsRunning = true
+	interval := p.config.DNSRefreshInterval
+	if interval <= 0 {
+		interval = defaultDNSRefreshInterval
+	}
+	p.dnsTicker = time.NewTicker(time.Duration(interval) * time.Second)
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] DNS resolver started (refresh=%d seconds)", interval)
+		p.resolveProxyHosts()
+		for {
+			select {
+			case <-p.dnsTicker.C:
+				p.resolveProxyHosts()
+			case <-p.stopDNS:
+				p.dnsTicker.Stop()
+				log.Printf("[IP-ROTATION] DNS resolver stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopDNSResolver는 DNS 사전 조회 백그라운드 루틴을 중지합니다.
+func (p *IPPool) StopDNSResolver() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dnsRunning {
+		close(p.stopDNS)
+		p.dnsRunning = false
+		p.stopDNS = make(chan struct{})
+	}
+}
+
+// resolveProxyHosts는 모든 프록시의 호스트명을 다시 조회하여 ResolvedIPs를 갱신합니다.
+func (p *IPPool) resolveProxyHosts() {
+	p.mu.RLock()
+	ids := make([]string, 0, len(p.proxies))
+	for id := range p.proxies {
+		ids = append(ids, id)
+	}
+	p.mu.RUnlock()
+
+	for _, id := range ids {
+		p.resolveProxy(id)
+	}
+}
+
+// addressChangedLocked는 주소가 바뀐 프록시에서 이전 호스트의 출구 IP와 DNS 조회 결과를 지우고 주소 체계를 다시 정합니다.
+// 그러지 않으면 /proxy/next의 resolvedIps와 dialAddress가 새 주소 대신 이전 호스트의 IP를 씁니다.
+// DNS 사전 조회가 켜져 있으면 새 호스트를 바로 조회합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) addressChangedLocked(proxy *ProxyIP) {
+	proxy.ExitIP = "" // the new address may exit elsewhere
+	proxy.ResolvedIPs = nil
+	proxy.ResolvedAt = time.Time{}
+	p.refreshIPVersion(proxy)
+	if p.config.PreResolveDNS {
+		go p.resolveProxy(proxy.ID)
+	}
+}
+
+// resolveProxy는 단일 프록시의 호스트명을 조회합니다. DNS 조회는 잠금 없이 수행합니다.
+// 조회에 실패하면 이전에 조회된 IP 목록을 유지하고, 조회 중 주소가 바뀌었으면 결과를 버립니다.
+func (p *IPPool) resolveProxy(id string) {
+	p.mu.RLock()
+	proxy, ok := p.proxies[id]
+	if !ok {
+		p.mu.RUnlock()
+		return
+	}
+	proxyURL, err := proxy.GetProxyURL()
+	address := proxy.Address
+	timeout := p.config.HealthCheckTimeout
+	p.mu.RUnlock()
+	if err != nil || proxyURL.Hostname() == "" {
+		return
+	}
+	if timeout <= 0 {
+		timeout = 10
+	}
+
+	host := proxyURL.Hostname()
+	var ips []string
+	if net.ParseIP(host) != nil {
+		ips = []string{host}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+		ips, err = net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			log.Printf("[IP-ROTATION] DNS resolution failed for %s (%s): %v", id, host, err)
+			return
+		}
+	}
+
+	p.mu.Lock()
+	// A lookup for an address replaced meanwhile must not overwrite the new host's results
+	if proxy, ok := p.proxies[id]; ok && proxy.Address == address {
+		proxy.ResolvedIPs = ips
+		proxy.ResolvedAt = time.Now()
+		p.refreshIPVersion(proxy)
+	}
+	p.mu.Unlock()
+}
+
+// dialAddress는 헬스체크 등에서 연결할 host:port를 반환합니다.
+// DNS 사전 조회가 켜져 있고 유효한 조회 결과가 있으면 호스트명 대신 첫 번째 IP를 사용합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) dialAddress(proxy *ProxyIP, host string) string {
+	if !p.config.PreResolveDNS || len(proxy.ResolvedIPs) == 0 {
+		return host
+	}
+	refresh := p.config.DNSRefreshInterval
+	if refresh <= 0 {
+		refresh = defaultDNSRefreshInterval
+	}
+	// Treat results older than two refresh periods as expired
+	if time.Since(proxy.ResolvedAt) > 2*time.Duration(refresh)*time.Second {
+		return host
+	}
+	_, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+	return net.JoinHostPort(proxy.ResolvedIPs[0], port)
+}
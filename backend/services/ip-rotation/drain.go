@@ -0,0 +1,136 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// DisabledReasonDrained는 drain 완료 후 자동 비활성화된 프록시의 비활성화 사유입니다.
+// 쿨다운 체크로는 재활성화되지 않으며, undrain 또는 관리자 활성화가 필요합니다.
+const DisabledReasonDrained = "drained"
+
+// acquireActive는 선택된 프록시의 진행 중 요청 수를 증가시키고 보유 시간 측정을 시작합니다.
+func acquireActive(proxy *ProxyIP) {
+	proxy.ActiveRequests.Add(1)
+	proxy.HoldTime.start(time.Now())
+}
+
+// releaseActive는 결과가 기록된 프록시의 진행 중 요청 수를 감소시키고 새 값을 반환합니다. 보유 시간도 함께 기록합니다.
+// 선택 없이 기록된 결과로 음수가 되지 않도록 0에서 멈춥니다.
+func releaseActive(proxy *ProxyIP) int64 {
+	proxy.HoldTime.finish(time.Now())
+	return decrementActive(proxy)
+}
+
+// cancelActive는 사용하지 않고 반납한 선택의 임대를 닫습니다. releaseActive와 같지만 보유 시간은 기록하지 않습니다.
+func cancelActive(proxy *ProxyIP) int64 {
+	proxy.HoldTime.cancel()
+	return decrementActive(proxy)
+}
+
+// decrementActive는 진행 중 요청 수를 0 아래로 내려가지 않게 하나 줄이고 새 값을 반환합니다.
+func decrementActive(proxy *ProxyIP) int64 {
+	for {
+		old := proxy.ActiveRequests.Load()
+		if old <= 0 {
+			return 0
+		}
+		if proxy.ActiveRequests.CompareAndSwap(old, old-1) {
+			return old - 1
+		}
+	}
+}
+
+// expireAbandonedLeases는 maxLeaseAge 안에 결과가 기록되지 않은 임대를 버려진 것으로 보고 진행 중 요청 수에서 뺍니다.
+// 결과를 기록하지 않고 죽은 클라이언트 때문에 drain이 끝나지 않는 일을 막으며, 그렇게 임대가 모두 끝난
+// drain 중 프록시는 이때 drain을 완료합니다. 쿨다운 체커가 주기적으로 호출합니다.
+func (p *IPPool) expireAbandonedLeases() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, proxy := range p.proxies {
+		// Every in-flight request holds an open lease (up to maxOpenLeases), so anything beyond the live leases was abandoned
+		open := int64(proxy.HoldTime.expire(now))
+		if active := proxy.ActiveRequests.Load(); active > open {
+			proxy.ActiveRequests.Store(open)
+			log.Printf("[IP-ROTATION] Abandoned leases expired: id=%s expired=%d active=%d", proxy.ID, active-open, open)
+			p.finishDrainLocked(proxy)
+		}
+	}
+}
+
+// ReturnProxy는 선택만 하고 사용하지 않은 프록시의 임대를 돌려줍니다. 사용 통계는 그대로 두며,
+// 임대가 모두 끝난 drain 중 프록시는 이때 drain을 완료합니다.
+func (p *IPPool) ReturnProxy(proxy *ProxyIP) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cancelActive(proxy)
+	p.finishDrainLocked(proxy)
+}
+
+// DrainProxy는 프록시를 drain 상태로 전환합니다. drain 중인 프록시는 새로 선택되지 않지만
+// 통계에는 계속 포함되고 /proxy/record로 결과를 기록할 수 있습니다.
+func (p *IPPool) DrainProxy(id string) (*ProxyIP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[id]
+	if !ok || proxy.Removed {
+		return nil, ErrProxyNotFound
+	}
+	if !proxy.Draining {
+		proxy.Draining = true
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Proxy draining: id=%s active=%d", id, proxy.ActiveRequests.Load())
+		p.autoSave()
+	}
+	p.finishDrainLocked(proxy)
+	return proxy, nil
+}
+
+// UndrainProxy는 drain 상태를 해제하여 프록시를 다시 선택 대상에 포함합니다.
+// drain 완료로 비활성화되었던 프록시는 다시 활성화됩니다.
+func (p *IPPool) UndrainProxy(id string) (*ProxyIP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[id]
+	if !ok || proxy.Removed {
+		return nil, ErrProxyNotFound
+	}
+	proxy.Draining = false
+	p.invalidateWeights()
+	if !proxy.Enabled && proxy.DisabledReason == DisabledReasonDrained {
+		proxy.Enabled = true
+		proxy.DisabledAt = time.Time{}
+		proxy.DisabledReason = ""
+		p.recordEvent(id, EventEnabled, "undrained", 0)
+	}
+	log.Printf("[IP-ROTATION] Proxy undrained: id=%s", id)
+	p.autoSave()
+	return proxy, nil
+}
+
+// finishDrain은 drain 중인 프록시의 진행 중 요청이 모두 끝났는지 확인하고, 설정에 따라 비활성화합니다.
+func (p *IPPool) finishDrain(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if proxy, ok := p.proxies[id]; ok {
+		p.finishDrainLocked(proxy)
+	}
+}
+
+// finishDrainLocked는 finishDrain의 본체입니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) finishDrainLocked(proxy *ProxyIP) {
+	if !proxy.Draining || !proxy.Enabled || !p.config.DrainAutoDisable || proxy.ActiveRequests.Load() > 0 {
+		return
+	}
+	proxy.Enabled = false
+	proxy.DisabledAt = time.Now()
+	proxy.DisabledReason = DisabledReasonDrained
+	p.recordEvent(proxy.ID, EventDisabled, "drain complete", 0)
+	log.Printf("[IP-ROTATION] Proxy drained and disabled: id=%s addr=%s", proxy.ID, proxy.Address)
+	p.autoSave()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPatchResultReleasesLeaseAndFinishesDrain(t *testing.T) {
+	for _, body := range []string{`{"success":true,"latency_ms":50}`, `{"failure":true}`} {
+		t.Run(body, func(t *testing.T) {
+			pool := newTestPool(t, IPPoolConfig{DrainAutoDisable: true}, 1)
+			previous := globalIPPool
+			globalIPPool = pool
+			t.Cleanup(func() { globalIPPool = previous })
+
+			proxy, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin)
+			if err != nil {
+				t.Fatalf("select: %v", err)
+			}
+			if _, err := pool.DrainProxy(proxy.ID); err != nil {
+				t.Fatalf("drain: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, "/admin/proxy-pool/"+proxy.ID, strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			handleProxyPoolByID(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("PATCH status = %d, body = %s", rec.Code, rec.Body)
+			}
+
+			pool.mu.RLock()
+			defer pool.mu.RUnlock()
+			if got := proxy.ActiveRequests.Load(); got != 0 {
+				t.Errorf("active requests = %d, want the lease released", got)
+			}
+			if proxy.Enabled || proxy.DisabledReason != DisabledReasonDrained {
+				t.Errorf("enabled=%v reason=%q, want the drain completed", proxy.Enabled, proxy.DisabledReason)
+			}
+		})
+	}
+}
+
+func TestLiveRotateTestReturnsLeases(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{DrainAutoDisable: true}, 2)
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+	previousLimit := rotateTestLimit
+	rotateTestLimit = &rotateTestLimiter{}
+	t.Cleanup(func() { rotateTestLimit = previousLimit })
+
+	// A proxy still serving a client while draining; the test run must not add to its leases
+	inUse, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/proxy-rotate-test", strings.NewReader(`{"count":4,"dryRun":false}`))
+	rec := httptest.NewRecorder()
+	handleProxyRotateTest(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	if _, err := pool.DrainProxy(inUse.ID); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	pool.RecordSuccess(inUse.ID, 50)
+
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	for _, proxy := range pool.proxies {
+		if got := proxy.ActiveRequests.Load(); got != 0 {
+			t.Errorf("%s active requests = %d, want every test lease returned", proxy.ID, got)
+		}
+		if proxy.UsageCount.Load() == 0 {
+			t.Errorf("%s usage = 0, want the live run counted", proxy.ID)
+		}
+	}
+	if inUse.Enabled || inUse.DisabledReason != DisabledReasonDrained {
+		t.Errorf("enabled=%v reason=%q, want the drain completed once the real client recorded", inUse.Enabled, inUse.DisabledReason)
+	}
+	var hold holdSnapshot
+	data, _ := inUse.HoldTime.MarshalJSON()
+	json.Unmarshal(data, &hold)
+	if hold.Open != 0 || hold.Samples != 1 {
+		t.Errorf("hold open=%d samples=%d, want only the real client's lease recorded", hold.Open, hold.Samples)
+	}
+}
+
+func TestExpireAbandonedLeasesFinishesDrain(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{DrainAutoDisable: true}, 1)
+	proxy := pool.proxies["p0"]
+	for i := 0; i < 2; i++ {
+		if _, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin); err != nil {
+			t.Fatalf("select: %v", err)
+		}
+	}
+	if _, err := pool.DrainProxy(proxy.ID); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	// The first client crashed long ago without recording; the second is still working
+	proxy.HoldTime.mu.Lock()
+	proxy.HoldTime.open[0] = time.Now().Add(-2 * maxLeaseAge)
+	proxy.HoldTime.mu.Unlock()
+
+	pool.expireAbandonedLeases()
+	if got := proxy.ActiveRequests.Load(); got != 1 {
+		t.Fatalf("active requests = %d, want only the live lease left", got)
+	}
+	if !proxy.Enabled {
+		t.Fatal("drain completed while a live lease was still open")
+	}
+
+	pool.RecordSuccess(proxy.ID, 50)
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if proxy.Enabled || proxy.DisabledReason != DisabledReasonDrained {
+		t.Errorf("enabled=%v reason=%q, want the drain completed", proxy.Enabled, proxy.DisabledReason)
+	}
+}
+
+// stalledWriter는 본문을 쓰는 동안 release가 닫힐 때까지 멈추는 느린 클라이언트를 흉내 냅니다.
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *stalledWriter) Write(b []byte) (int, error) {
+	close(w.writing)
+	<-w.release
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestDrainResponseDoesNotHoldPoolLock(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 1)
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+
+	w := &stalledWriter{ResponseRecorder: httptest.NewRecorder(), writing: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handleProxyDrain(w, httptest.NewRequest(http.MethodPost, "/admin/proxy-pool/p0/drain", nil), "p0")
+	}()
+	<-w.writing
+
+	locked := make(chan struct{})
+	go func() {
+		pool.mu.Lock()
+		pool.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Error("pool write lock blocked while the drain response was being written")
+	}
+	close(w.release)
+	<-done
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"draining":true`) {
+		t.Errorf("status = %d, body = %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// 풀 연산이 반환하는 오류입니다. 핸들러는 errorStatus로 오류를 HTTP 상태 코드에 대응시키므로
+// 호출자는 문자열 대신 errors.Is로 구분해야 합니다.
+var (
+	ErrProxyNotFound   = errors.New("proxy not found")
+	ErrInvalidProtocol = errors.New("invalid protocol") // matched by a *ValidationError with a protocol field error
+	ErrInvalidStrategy = errors.New("invalid strategy")
+	ErrInvalidConfig   = errors.New("invalid config")
+	// ErrProxyExists is returned when AddProxy is given an ID that is already in the pool
+	ErrProxyExists = errors.New("proxy already exists")
+	// ErrUnsupportedSchema is returned when a state file was written by a newer build; loading it would drop
+	// fields this build doesn't know and the next save would overwrite them
+	ErrUnsupportedSchema = errors.New("state file schema is newer than this build supports")
+	// ErrStateEncryption is returned when PERSISTENCE_KEY and the state file's format disagree or decryption fails
+	ErrStateEncryption = errors.New("state file encryption mismatch")
+)
+
+// ValidationError는 필드별 검증 오류(field → message)를 담는 구조화된 오류입니다.
+// HTTP 핸들러는 이 타입을 감지해 422와 함께 필드별 상세 정보를 응답합니다.
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// Add는 필드 오류를 추가합니다. 같은 필드에 이미 오류가 있으면 첫 번째 메시지를 유지합니다.
+func (e *ValidationError) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, exists := e.Fields[field]; !exists {
+		e.Fields[field] = message
+	}
+}
+
+// HasErrors는 기록된 필드 오류가 있는지 반환합니다.
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Fields) > 0
+}
+
+// Is는 protocol 필드 오류가 있으면 ErrInvalidProtocol과 일치한다고 보고합니다.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidProtocol && e.Fields["protocol"] != ""
+}
+
+// Error는 필드 오류를 필드명 순으로 정렬해 한 줄 문자열로 반환합니다.
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field, e.Fields[field]))
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// isSelectionUnavailable은 선택할 수 있는 프록시가 없어서 난 오류인지 반환합니다. 잠시 후 다시 시도하거나
+// 다른 풀로 넘길 수 있는 오류입니다.
+func isSelectionUnavailable(err error) bool {
+	return errors.Is(err, ErrNoProxyAvailable) || errors.Is(err, ErrQuotaExhausted) ||
+		errors.Is(err, ErrNoEliteProxy) || errors.Is(err, ErrNoWebSocketProxy) || errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrNoIPVersionProxy)
+}
+
+// errorStatus는 풀 오류에 맞는 HTTP 상태 코드를 반환합니다. 알려진 오류가 아니면 fallback을 반환합니다.
+func errorStatus(err error, fallback int) int {
+	var verr *ValidationError
+	switch {
+	case errors.Is(err, ErrProxyNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &verr), errors.Is(err, ErrInvalidStrategy), errors.Is(err, ErrInvalidConfig):
+		return http.StatusUnprocessableEntity
+	case isSelectionUnavailable(err), errors.Is(err, ErrServicePaused):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrProxyExists), errors.Is(err, ErrUnsupportedSchema), errors.Is(err, ErrStateEncryption):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// DisabledReasonExpired는 ExpiresAt이 지나 자동 비활성화된 프록시의 사유입니다. 쿨다운으로 재활성화되지 않으며,
+// ExpiresAt을 연장하거나 지우면 다시 활성화됩니다.
+const DisabledReasonExpired = "expired"
+
+// expirySweepInterval은 만료된 프록시를 찾는 정리 루틴의 실행 주기입니다.
+const expirySweepInterval = time.Minute
+
+// expired는 now 기준으로 프록시의 사용 기한(ExpiresAt)이 지났는지 반환합니다. ExpiresAt이 없으면 만료되지 않습니다.
+func (p *ProxyIP) expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt)
+}
+
+// applyExpiryLocked는 기한이 지난 활성 프록시를 비활성화하고, 기한이 연장된 만료 프록시를 다시 활성화합니다.
+// 상태가 바뀌었으면 true를 반환합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) applyExpiryLocked(proxy *ProxyIP, now time.Time) bool {
+	if proxy.Removed {
+		return false
+	}
+	expired := proxy.expired(now)
+	switch {
+	case expired && proxy.Enabled:
+		proxy.Enabled = false
+		proxy.DisabledAt = now
+		proxy.DisabledReason = DisabledReasonExpired
+		p.recordEvent(proxy.ID, EventDisabled, "expired", 0)
+		log.Printf("[IP-ROTATION] Proxy expired and disabled: id=%s addr=%s expires_at=%s",
+			proxy.ID, proxy.Address, proxy.ExpiresAt.Format(time.RFC3339))
+	case !expired && !proxy.Enabled && proxy.DisabledReason == DisabledReasonExpired:
+		proxy.Enabled = true
+		proxy.DisabledAt = time.Time{}
+		proxy.DisabledReason = ""
+		p.recordEvent(proxy.ID, EventEnabled, "expiry extended", 0)
+		log.Printf("[IP-ROTATION] Proxy re-enabled after expiry was extended: id=%s", proxy.ID)
+	default:
+		return false
+	}
+	p.invalidateWeights()
+	return true
+}
+
+// sweepExpiredProxies는 모든 프록시에 사용 기한을 적용하고, 상태가 바뀐 프록시 수를 반환합니다.
+func (p *IPPool) sweepExpiredProxies() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	changed := 0
+	for _, proxy := range p.proxies {
+		if p.applyExpiryLocked(proxy, now) {
+			changed++
+		}
+	}
+	if changed > 0 {
+		p.autoSave()
+	}
+	return changed
+}
+
+// StartExpirySweeper는 기한이 지난 프록시를 주기적으로 비활성화하는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartExpirySweeper() {
+	p.mu.Lock()
+	if p.expiryRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.expiryRunning = true
+	stop := p.stopExpiry
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Proxy expiry sweeper started (interval=%s)", expirySweepInterval)
+		ticker := time.NewTicker(expirySweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.sweepExpiredProxies()
+			case <-stop:
+				log.Printf("[IP-ROTATION] Proxy expiry sweeper stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopExpirySweeper는 만료 프록시 정리 루틴을 중지합니다.
+func (p *IPPool) StopExpirySweeper() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.expiryRunning {
+		close(p.stopExpiry)
+		p.expiryRunning = false
+		p.stopExpiry = make(chan struct{})
+	}
+}
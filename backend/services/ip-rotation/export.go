@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// 대량 내보내기/가져오기 관련 한도입니다.
+const (
+	exportChunkSize     = 256     // proxies encoded per read-lock acquisition while streaming
+	maxImportLineBytes  = 1 << 20 // longest accepted JSON Lines record
+	maxImportErrorsKept = 100     // per-line errors included in an ImportReport
+)
+
+// ImportLineError는 가져오기에서 실패한 항목의 위치와 원인입니다. Line은 JSON Lines에서는 줄 번호,
+// JSON 배열에서는 1부터 센 항목 번호입니다.
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportReport는 ImportJSONL의 결과 요약입니다. Errors는 앞쪽 maxImportErrorsKept개만 담습니다.
+type ImportReport struct {
+	Imported int               `json:"imported"`
+	Failed   int               `json:"failed"`
+	Errors   []ImportLineError `json:"errors"`
+}
+
+// ExportJSONL은 풀의 모든 프록시를 로테이션 순서대로 한 줄에 하나씩 JSON으로 w에 씁니다.
+// exportChunkSize개마다 잠금을 풀고 flush를 호출하므로 풀 전체를 메모리에 올리지 않고, 쓰기 중에도 선택이 막히지 않습니다.
+// 내보내는 동안 삭제된 프록시는 건너뜁니다. 쓴 프록시 수를 반환합니다.
+func (p *IPPool) ExportJSONL(w io.Writer, flush func()) (int, error) {
+	p.mu.RLock()
+	ids := append([]string(nil), p.order...)
+	p.mu.RUnlock()
+
+	written := 0
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for start := 0; start < len(ids); start += exportChunkSize {
+		end := min(start+exportChunkSize, len(ids))
+
+		buf.Reset()
+		now := time.Now()
+		p.mu.RLock()
+		for _, id := range ids[start:end] {
+			proxy, ok := p.proxies[id]
+			if !ok {
+				continue
+			}
+			p.updateScore(proxy, now)
+			// Encode writes a trailing newline, which is the JSON Lines record separator
+			if err := enc.Encode(proxy); err != nil {
+				p.mu.RUnlock()
+				return written, err
+			}
+			written++
+		}
+		p.mu.RUnlock()
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return written, err
+		}
+		if flush != nil {
+			flush()
+		}
+	}
+	return written, nil
+}
+
+// ImportJSONL은 r에서 JSON Lines 형식의 프록시를 한 줄씩 읽어 AddProxy로 추가합니다. 빈 줄은 건너뜁니다.
+// 잘못된 줄은 보고서에 기록하고 계속 진행하며, 입력 자체를 읽지 못하면 그때까지의 보고서와 오류를 반환합니다.
+func (p *IPPool) ImportJSONL(r io.Reader) (ImportReport, error) {
+	report := ImportReport{Errors: []ImportLineError{}}
+	fail := func(line int, err error) {
+		report.Failed++
+		if len(report.Errors) < maxImportErrorsKept {
+			report.Errors = append(report.Errors, ImportLineError{Line: line, Error: err.Error()})
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var proxy ProxyIP
+		if err := json.Unmarshal(data, &proxy); err != nil {
+			fail(line, err)
+			continue
+		}
+		if _, err := p.AddProxy(&proxy); err != nil {
+			fail(line, err)
+			continue
+		}
+		report.Imported++
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("line %d exceeds %d bytes", line+1, maxImportLineBytes)
+		}
+		return report, err
+	}
+	return report, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// usageFairness는 프록시별 사용량 분포가 얼마나 고른지 요약합니다. gini는 0(완전히 균등)에서
+// 1에 가까울수록(한 프록시에 집중) 커집니다. 사용량이 모두 0이면 0입니다.
+func usageFairness(usages []int64) map[string]any {
+	summary := map[string]any{
+		"proxies": len(usages),
+		"gini":    0.0,
+		"min":     int64(0),
+		"max":     int64(0),
+		"median":  0.0,
+	}
+	n := len(usages)
+	if n == 0 {
+		return summary
+	}
+
+	sorted := slices.Clone(usages)
+	slices.Sort(sorted)
+	var sum, weighted float64
+	for i, u := range sorted {
+		sum += float64(u)
+		weighted += float64(i+1) * float64(u)
+	}
+	if sum > 0 {
+		gini := 2*weighted/(float64(n)*sum) - float64(n+1)/float64(n)
+		summary["gini"] = math.Round(gini*10000) / 10000
+	}
+
+	median := float64(sorted[n/2])
+	if n%2 == 0 {
+		median = float64(sorted[n/2-1]+sorted[n/2]) / 2
+	}
+	summary["min"] = sorted[0]
+	summary["max"] = sorted[n-1]
+	summary["median"] = median
+	return summary
+}
@@ -0,0 +1,57 @@
+package main
+
+import "log"
+
+// maxFallbacks는 /proxy/next 한 번에 함께 돌려줄 수 있는 예비 프록시의 최대 수입니다.
+const maxFallbacks = 5
+
+// FallbacksFor는 primary와 같은 옵션으로 최대 n개의 서로 다른 예비 프록시를 고릅니다. 같은 전략을 primary와
+// 이미 고른 프록시를 제외한 후보에 반복 적용하므로 라운드로빈은 순서상 다음 프록시를, weighted는 가중치에 따라 고릅니다.
+// 예비 프록시는 사용 횟수와 할당량을 소비하지 않고 라운드로빈 커서도 움직이지 않으며, 클라이언트가 실제로 쓴 뒤
+// ConfirmFallback으로 알려야 사용으로 기록됩니다. 후보가 모자라면 n개보다 적게 반환합니다.
+func (p *IPPool) FallbacksFor(primary *ProxyIP, opts SelectOptions, n int) []*ProxyIP {
+	if n <= 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	strategy := p.strategyForLocked(opts)
+	// Fallbacks are a peek; the live rotation continues after the primary
+	savedIndex := p.index
+	defer func() { p.index = savedIndex }()
+
+	opts.exclude = []*ProxyIP{primary}
+	fallbacks := make([]*ProxyIP, 0, n)
+	for len(fallbacks) < n {
+		next, err := p.pickProxyLocked(strategy, opts)
+		if err != nil || next == nil {
+			break
+		}
+		fallbacks = append(fallbacks, next)
+		opts.exclude = append(opts.exclude, next)
+	}
+	return fallbacks
+}
+
+// ConfirmFallback은 클라이언트가 /proxy/next의 예비 프록시를 실제로 사용했음을 기록합니다.
+// 선택 시 건너뛴 사용 횟수, 할당량, 분산 통계와 진행 중 요청(임대)을 이때 반영하므로, 이어지는 결과 기록이 이 임대를 닫습니다.
+// primaryID가 주어지면 예비 프록시로 대체되어 결과가 기록되지 않을 주 프록시의 임대를 닫습니다(drain 완료 포함).
+// 주 프록시의 결과를 따로 기록한 경우에는 그 기록이 임대를 닫으므로 primaryID를 비워야 합니다.
+func (p *IPPool) ConfirmFallback(id, primaryID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[id]
+	if !ok || proxy.Removed {
+		return ErrProxyNotFound
+	}
+	usage := p.markUsedLocked(proxy, p.config.Strategy)
+	acquireActive(proxy)
+	if primary, ok := p.proxies[primaryID]; ok && primary != proxy {
+		releaseActive(primary)
+		p.finishDrainLocked(primary)
+	}
+	log.Printf("[IP-ROTATION] Fallback proxy used: id=%s addr=%s primary=%s usage_count=%d", proxy.ID, proxy.Address, primaryID, usage)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConfirmFallbackMovesLeaseFromPrimary(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 2)
+	primary, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin)
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	fallbacks := pool.FallbacksFor(primary, SelectOptions{}, 1)
+	if len(fallbacks) != 1 {
+		t.Fatalf("got %d fallbacks, want 1", len(fallbacks))
+	}
+	fallback := fallbacks[0]
+	if got := fallback.ActiveRequests.Load(); got != 0 {
+		t.Fatalf("fallback holds %d leases before it is used, want 0", got)
+	}
+
+	if err := pool.ConfirmFallback(fallback.ID, primary.ID); err != nil {
+		t.Fatalf("confirm fallback: %v", err)
+	}
+	if got := fallback.ActiveRequests.Load(); got != 1 {
+		t.Errorf("fallback active requests = %d, want 1 after confirmation", got)
+	}
+	if got := primary.ActiveRequests.Load(); got != 0 {
+		t.Errorf("primary active requests = %d, want its unused lease released", got)
+	}
+
+	pool.RecordSuccess(fallback.ID, 80)
+	if got := fallback.ActiveRequests.Load(); got != 0 {
+		t.Errorf("fallback active requests = %d, want 0 after its result", got)
+	}
+	if got := fallback.UsageCount.Load(); got != 1 {
+		t.Errorf("fallback usage = %d, want 1", got)
+	}
+}
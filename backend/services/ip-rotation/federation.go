@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// federationHopHeader는 다른 리전 풀에서 중계된 요청임을 표시하는 헤더입니다.
+// 이 헤더가 있는 요청은 다시 중계하지 않으므로 풀 사이의 순환이 생기지 않습니다.
+const federationHopHeader = "X-IP-Rotation-Hop"
+
+// federationTimeout은 업스트림 풀 하나에 대한 /proxy/next 요청의 최대 대기 시간입니다.
+const federationTimeout = 5 * time.Second
+
+// parseUpstreamPools는 쉼표로 구분된 업스트림 풀 기본 URL 목록을 파싱합니다.
+func parseUpstreamPools(v string) []string {
+	var pools []string
+	for _, base := range strings.Split(v, ",") {
+		if base = strings.TrimRight(strings.TrimSpace(base), "/"); base != "" {
+			pools = append(pools, base)
+		}
+	}
+	return pools
+}
+
+// relayFederatedNext는 로컬 풀에 사용 가능한 프록시가 없을 때 설정된 업스트림 풀에 순서대로 /proxy/next를 요청하고,
+// 처음 성공한 응답을 그대로 중계합니다. 중계에 성공하면 true를 반환합니다.
+// 응답의 X-Proxy-Upstream 헤더로 결과를 기록(/proxy/record)할 풀을 알려줍니다.
+func relayFederatedNext(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get(federationHopHeader) != "" {
+		return false
+	}
+
+	globalIPPool.mu.RLock()
+	upstreams := append([]string(nil), globalIPPool.config.UpstreamPools...)
+	globalIPPool.mu.RUnlock()
+
+	for _, base := range upstreams {
+		resp, err := fetchUpstreamNext(r.Context(), base, r.URL.RawQuery)
+		if err != nil {
+			log.Printf("[IP-ROTATION] Federation to %s failed: %v", base, err)
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			log.Printf("[IP-ROTATION] Federation to %s failed: status=%d err=%v", base, resp.StatusCode, err)
+			continue
+		}
+
+		for _, name := range []string{"Content-Type", "X-Proxy-Id"} {
+			if v := resp.Header.Get(name); v != "" {
+				w.Header().Set(name, v)
+			}
+		}
+		w.Header().Set("X-Proxy-Upstream", base)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+
+		globalIPPool.metrics.servedFederated.Add(1)
+		log.Printf("[IP-ROTATION] Proxy request satisfied by upstream pool %s", base)
+		return true
+	}
+	return false
+}
+
+// fetchUpstreamNext는 업스트림 풀의 /proxy/next를 같은 쿼리로 호출합니다. 홉 헤더를 붙여 재중계를 막습니다.
+func fetchUpstreamNext(ctx context.Context, base, rawQuery string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, federationTimeout)
+	target := base + "/proxy/next"
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set(federationHopHeader, "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose는 응답 본문을 닫을 때 요청 컨텍스트도 함께 취소합니다.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close는 본문을 닫고 컨텍스트를 취소합니다.
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,52 @@
+package main
+
+import "math"
+
+// earthRadiusKm은 하버사인 거리 계산에 사용하는 지구 평균 반지름(km)입니다.
+const earthRadiusKm = 6371.0
+
+// nearestToleranceKm은 최근접 프록시와의 거리 차이가 이 값 이내인 프록시들을 동등하게 취급하여 부하를 분산합니다.
+const nearestToleranceKm = 50.0
+
+// haversineKm은 두 위경도 좌표 사이의 대원 거리(km)를 계산합니다.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// validCoordinates는 위도/경도 값이 유효 범위에 있는지 반환합니다.
+func validCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// selectNearest는 좌표가 설정된 프록시 중 대상 좌표에 가장 가까운 프록시들(허용 오차 이내)에서 무작위로 하나를 선택합니다.
+// 좌표가 설정된 프록시가 없으면 nil을 반환합니다.
+func selectNearest(proxies []*ProxyIP, targetLat, targetLon float64) *ProxyIP {
+	distances := make(map[*ProxyIP]float64, len(proxies))
+	minDist := math.MaxFloat64
+	for _, proxy := range proxies {
+		if proxy.Latitude == nil || proxy.Longitude == nil {
+			continue
+		}
+		d := haversineKm(targetLat, targetLon, *proxy.Latitude, *proxy.Longitude)
+		distances[proxy] = d
+		if d < minDist {
+			minDist = d
+		}
+	}
+	if len(distances) == 0 {
+		return nil
+	}
+
+	var nearest []*ProxyIP
+	for _, proxy := range proxies {
+		if d, ok := distances[proxy]; ok && d <= minDist+nearestToleranceKm {
+			nearest = append(nearest, proxy)
+		}
+	}
+	return nearest[secureRandomInt(len(nearest))]
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// geoVerifyInterval은 같은 프록시의 위치를 다시 확인하기까지의 최소 간격입니다.
+// 지오IP 서비스는 대개 호출 수가 제한되므로 헬스체크마다 조회하지 않습니다.
+const geoVerifyInterval = 24 * time.Hour
+
+// geoCorrectionKm은 좌표를 고칠 만큼 차이가 난다고 보는 거리입니다. 서비스마다 좌표가 조금씩 달라 작은 차이는 무시합니다.
+const geoCorrectionKm = 50.0
+
+// geoLocation은 지오IP 서비스가 알려준 프록시 출구 IP의 위치와 네트워크입니다.
+type geoLocation struct {
+	IP        string // exit IP as seen by the service; empty if not reported
+	ASN       string // "AS<number>"; empty if not reported
+	Country   string
+	City      string
+	Latitude  *float64
+	Longitude *float64
+}
+
+// parseGeoResponse는 지오IP 서비스의 JSON 응답을 해석합니다. 흔한 형식을 모두 받습니다:
+// ip-api.com(countryCode, lat, lon, query, as), ipapi.co(country_code, latitude, longitude, ip, asn),
+// ipinfo.io(country, loc "lat,lon", ip, org "AS15169 Google LLC"). 국가는 두 글자 코드만 인정합니다.
+// ip-api.com에서 AS 정보를 받으려면 fields 파라미터에 query와 as가 포함되어야 합니다.
+func parseGeoResponse(body []byte) (*geoLocation, error) {
+	var doc struct {
+		Country     string   `json:"country"`
+		CountryCode string   `json:"countryCode"`
+		CountryISO  string   `json:"country_code"`
+		City        string   `json:"city"`
+		Lat         *float64 `json:"lat"`
+		Lon         *float64 `json:"lon"`
+		Latitude    *float64 `json:"latitude"`
+		Longitude   *float64 `json:"longitude"`
+		Loc         string   `json:"loc"`
+		Query       string   `json:"query"`
+		IP          string   `json:"ip"`
+		AS          string   `json:"as"`
+		ASN         string   `json:"asn"`
+		Org         string   `json:"org"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	geo := &geoLocation{City: doc.City}
+	for _, ip := range []string{doc.Query, doc.IP} {
+		if net.ParseIP(ip) != nil {
+			geo.IP = ip
+			break
+		}
+	}
+	for _, asn := range []string{doc.AS, doc.ASN, doc.Org} {
+		if geo.ASN = parseASN(asn); geo.ASN != "" {
+			break
+		}
+	}
+	for _, code := range []string{doc.CountryCode, doc.CountryISO, doc.Country} {
+		if len(code) == 2 {
+			geo.Country = strings.ToUpper(code)
+			break
+		}
+	}
+	if geo.Country == "" {
+		return nil, errors.New("geo response has no two-letter country code")
+	}
+
+	lat, lon := doc.Lat, doc.Lon
+	if lat == nil || lon == nil {
+		lat, lon = doc.Latitude, doc.Longitude
+	}
+	if (lat == nil || lon == nil) && doc.Loc != "" {
+		if latStr, lonStr, ok := strings.Cut(doc.Loc, ","); ok {
+			la, errLat := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+			lo, errLon := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+			if errLat == nil && errLon == nil {
+				lat, lon = &la, &lo
+			}
+		}
+	}
+	if lat != nil && lon != nil && validCoordinates(*lat, *lon) {
+		geo.Latitude, geo.Longitude = lat, lon
+	}
+	return geo, nil
+}
+
+// geoVerifyDue는 프록시 위치를 다시 확인할 때가 되었는지 반환합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func geoVerifyDue(proxy *ProxyIP, now time.Time) bool {
+	return proxy.GeoVerifiedAt.IsZero() || now.Sub(proxy.GeoVerifiedAt) >= geoVerifyInterval
+}
+
+// lookupGeo는 프록시를 통해 geoURL을 조회합니다. 서비스는 요청을 보낸 IP, 즉 프록시의 출구 IP의 위치를 응답합니다.
+func (p *IPPool) lookupGeo(ctx context.Context, proxy *ProxyIP, geoURL string) (*geoLocation, error) {
+	proxyURL, header, err := p.proxyEndpoint(proxy)
+	if err != nil {
+		return nil, err
+	}
+	body, err := fetchThroughProxy(ctx, p.upstreamDial(), proxyURL, header, geoURL)
+	if err != nil {
+		return nil, err
+	}
+	return parseGeoResponse(body)
+}
+
+// applyGeoLocked는 확인된 위치가 프록시의 Country/City/좌표와 다르면 고치고 정정 내용을 로그로 남깁니다.
+// 출구 IP와 ASN이 응답에 있으면 ASN/Subnet도 함께 갱신합니다. 서비스가 주지 않은 값은 그대로 둡니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) applyGeoLocked(proxy *ProxyIP, geo *geoLocation, now time.Time) {
+	proxy.GeoVerifiedAt = now
+	p.noteExitIPLocked(proxy, geo.IP)
+
+	var corrections []string
+	if !strings.EqualFold(proxy.Country, geo.Country) {
+		corrections = append(corrections, "country="+strconv.Quote(proxy.Country)+"->"+geo.Country)
+		proxy.Country = geo.Country
+	}
+	if geo.City != "" && !strings.EqualFold(proxy.City, geo.City) {
+		corrections = append(corrections, "city="+strconv.Quote(proxy.City)+"->"+strconv.Quote(geo.City))
+		proxy.City = geo.City
+	}
+	if geo.Latitude != nil {
+		moved := proxy.Latitude == nil || proxy.Longitude == nil ||
+			haversineKm(*proxy.Latitude, *proxy.Longitude, *geo.Latitude, *geo.Longitude) > geoCorrectionKm
+		if moved {
+			corrections = append(corrections, "coordinates="+
+				strconv.FormatFloat(*geo.Latitude, 'f', 4, 64)+","+strconv.FormatFloat(*geo.Longitude, 'f', 4, 64))
+			proxy.Latitude, proxy.Longitude = geo.Latitude, geo.Longitude
+		}
+	}
+	if geo.ASN != "" && proxy.ASN != geo.ASN {
+		corrections = append(corrections, "asn="+strconv.Quote(proxy.ASN)+"->"+geo.ASN)
+		proxy.ASN = geo.ASN
+	}
+	if subnet := subnetOf(geo.IP); subnet != "" && proxy.Subnet != subnet {
+		corrections = append(corrections, "subnet="+strconv.Quote(proxy.Subnet)+"->"+subnet)
+		proxy.Subnet = subnet
+	}
+	if len(corrections) == 0 {
+		return
+	}
+	// Country feeds geographic selection and country targets
+	p.invalidateWeights()
+	log.Printf("[IP-ROTATION] Geolocation corrected: id=%s addr=%s %s", proxy.ID, proxy.Address, strings.Join(corrections, " "))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// hashRingReplicas는 해시 링에서 프록시 하나가 차지하는 가상 노드 수입니다. 많을수록 키 분포가 고릅니다.
+const hashRingReplicas = 100
+
+// hashRing은 consistent_hash 전략에서 키를 프록시에 매핑하는 해시 링입니다.
+// 프록시가 추가/제거되어도 해당 프록시 주변의 키만 재배치됩니다. p.mu 쓰기 잠금으로 보호됩니다.
+type hashRing struct {
+	members map[*ProxyIP]bool // candidate set the ring was built from
+	points  []uint64
+	proxies []*ProxyIP // proxies[i] owns points[i]
+}
+
+// hashKey는 링 위치 계산에 쓰는 64비트 해시를 반환합니다.
+// FNV-1a는 끝 글자만 다른 짧은 문자열("id#1", "id#2")의 상위 비트가 고르게 퍼지지 않으므로 fmix64로 섞습니다.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// matches는 링이 주어진 후보 집합으로 구성되었는지 반환합니다.
+func (r *hashRing) matches(proxies []*ProxyIP) bool {
+	if len(r.members) != len(proxies) {
+		return false
+	}
+	for _, proxy := range proxies {
+		if !r.members[proxy] {
+			return false
+		}
+	}
+	return true
+}
+
+// cachedHashRing은 현재 후보 목록에 대한 해시 링을 반환하며, 후보 집합이 바뀌었으면 다시 구성합니다.
+// 통계 변화와 무관하게 후보 집합이 같으면 재사용합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) cachedHashRing(proxies []*ProxyIP) *hashRing {
+	r := &p.ring
+	if r.matches(proxies) {
+		return r
+	}
+
+	type point struct {
+		hash  uint64
+		proxy *ProxyIP
+	}
+	points := make([]point, 0, len(proxies)*hashRingReplicas)
+	for _, proxy := range proxies {
+		// Ring positions derive from the proxy ID, so they survive restarts and re-ordering
+		for i := 0; i < hashRingReplicas; i++ {
+			points = append(points, point{hash: hashKey(proxy.ID + "#" + strconv.Itoa(i)), proxy: proxy})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].proxy.ID < points[j].proxy.ID
+	})
+
+	r.members = make(map[*ProxyIP]bool, len(proxies))
+	for _, proxy := range proxies {
+		r.members[proxy] = true
+	}
+	r.points = r.points[:0]
+	r.proxies = r.proxies[:0]
+	for _, pt := range points {
+		r.points = append(r.points, pt.hash)
+		r.proxies = append(r.proxies, pt.proxy)
+	}
+	return r
+}
+
+// lookup은 키 해시 이후 시계 방향으로 처음 만나는 프록시를 반환합니다.
+func (r *hashRing) lookup(key string) *ProxyIP {
+	if len(r.points) == 0 {
+		return nil
+	}
+	h := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0 // wrap around the ring
+	}
+	return r.proxies[i]
+}
+
+// selectConsistentHash는 opts.Key를 해시 링에 매핑하여 같은 키가 항상 같은 프록시로 가도록 선택합니다.
+// 키가 없으면 라운드로빈으로 대체합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) selectConsistentHash(proxies []*ProxyIP, opts SelectOptions) *ProxyIP {
+	if len(proxies) == 0 {
+		return nil
+	}
+	if opts.Key == "" {
+		return p.selectRoundRobin(proxies)
+	}
+	return p.cachedHashRing(proxies).lookup(opts.Key)
+}
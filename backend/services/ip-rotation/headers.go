@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// validateHeaders는 프록시 인증용 커스텀 헤더가 HTTP 헤더로 전송 가능한지 검사하고, 문제가 있으면 메시지를 반환합니다.
+func validateHeaders(headers map[string]string) string {
+	for name, value := range headers {
+		if name == "" || strings.IndexFunc(name, invalidHeaderNameRune) >= 0 {
+			return "invalid header name: " + name
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return "header value must not contain line breaks: " + name
+		}
+	}
+	return ""
+}
+
+// validateProxyHeaders는 validateHeaders에 더해 헤더를 보낼 수 있는 프로토콜(http, https)인지 검사합니다.
+// 헤더가 없으면 항상 통과합니다.
+func validateProxyHeaders(headers map[string]string, protocol string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	if msg := validateHeaders(headers); msg != "" {
+		return msg
+	}
+	if protocol = strings.ToLower(protocol); protocol != "http" && protocol != "https" {
+		return "custom headers are only supported for http and https proxies"
+	}
+	return ""
+}
+
+// validateMetadata는 프록시 메타데이터의 키가 비어 있지 않은지 검사하고, 문제가 있으면 설명을 반환합니다.
+func validateMetadata(metadata map[string]string) string {
+	for key := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return "metadata keys must not be empty"
+		}
+	}
+	return ""
+}
+
+// invalidHeaderNameRune은 HTTP 헤더 이름(token)에 쓸 수 없는 문자인지 반환합니다.
+func invalidHeaderNameRune(r rune) bool {
+	return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+}
+
+// ProxyHeader는 프록시에 보낼 커스텀 헤더(예: API 키 기반 Proxy-Authorization)를 http.Header로 반환합니다.
+// 헤더가 없으면 nil을 반환합니다.
+func (p *ProxyIP) ProxyHeader() http.Header {
+	if len(p.Headers) == 0 {
+		return nil
+	}
+	h := make(http.Header, len(p.Headers))
+	for name, value := range p.Headers {
+		h.Set(name, value)
+	}
+	return h
+}
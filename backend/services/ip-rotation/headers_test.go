@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPatchHeadersValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		headers  map[string]string
+		body     string
+		status   int
+	}{
+		{"string values", "http", nil, `{"headers":{"Proxy-Authorization":"Bearer x"}}`, http.StatusOK},
+		{"non-string value", "http", nil, `{"headers":{"X-Api-Key":123}}`, http.StatusUnprocessableEntity},
+		{"not an object", "http", nil, `{"headers":"X-Api-Key: 1"}`, http.StatusUnprocessableEntity},
+		{"headers on a socks proxy", "socks5", nil, `{"headers":{"X-Api-Key":"k"}}`, http.StatusUnprocessableEntity},
+		{"protocol change drops header support", "http", map[string]string{"X-Api-Key": "k"}, `{"protocol":"socks5"}`, http.StatusUnprocessableEntity},
+		{"protocol change with headers cleared", "http", map[string]string{"X-Api-Key": "k"}, `{"protocol":"socks5","headers":{}}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewIPPool(IPPoolConfig{})
+			previous := globalIPPool
+			globalIPPool = pool
+			t.Cleanup(func() { globalIPPool = previous })
+			proxy, err := pool.AddProxy(&ProxyIP{ID: "p", Address: tt.protocol + "://1.2.3.4:1080", Protocol: tt.protocol, Headers: tt.headers})
+			if err != nil {
+				t.Fatalf("add proxy: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodPatch, "/admin/proxy-pool/p", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handleProxyPoolByID(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body = %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				pool.mu.RLock()
+				defer pool.mu.RUnlock()
+				if proxy.Protocol != tt.protocol || len(proxy.Headers) != len(tt.headers) {
+					t.Errorf("rejected patch changed the proxy: protocol=%s headers=%v", proxy.Protocol, proxy.Headers)
+				}
+			}
+		})
+	}
+}
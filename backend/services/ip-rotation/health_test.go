@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stalledListener는 연결을 받기만 하고 응답하지 않는 프록시를 흉내 냅니다.
+func stalledListener(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		var held []net.Conn
+		defer func() {
+			for _, conn := range held {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			held = append(held, conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
+func TestRunHealthChecksStalledProxyDoesNotBlockSweep(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	pool := NewIPPool(IPPoolConfig{
+		HealthCheckURL:     "http://health.invalid/",
+		HealthCheckTimeout: 1,
+	})
+	stalled, err := pool.AddProxy(&ProxyIP{ID: "stalled", Address: "http://" + stalledListener(t)})
+	if err != nil {
+		t.Fatalf("add stalled proxy: %v", err)
+	}
+	good, err := pool.AddProxy(&ProxyIP{ID: "good", Address: healthy.URL})
+	if err != nil {
+		t.Fatalf("add healthy proxy: %v", err)
+	}
+
+	done := make(chan struct{})
+	began := time.Now()
+	go func() {
+		pool.runHealthChecks(0, nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("health sweep did not finish; a stalled proxy blocked it")
+	}
+	if elapsed := time.Since(began); elapsed > 3*time.Second {
+		t.Errorf("sweep took %v, want it bounded by the 1s check timeout", elapsed)
+	}
+
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if good.HealthStatus != "healthy" {
+		t.Errorf("healthy proxy status = %q, want healthy", good.HealthStatus)
+	}
+	if stalled.HealthStatus != "unhealthy" {
+		t.Errorf("stalled proxy status = %q, want unhealthy", stalled.HealthStatus)
+	}
+}
+
+// PATCH가 잠금 안에서 프로토콜/UDP 지원/주소를 바꾸는 동안 점검이 실행되어도 경합이 없어야 합니다(-race로 확인).
+func TestRunHealthChecksConcurrentPatch(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{HealthCheckTimeout: 1})
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "socks5://" + stalledListener(t), Protocol: "socks5", SupportsUDP: true})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+
+	stop := make(chan struct{})
+	patched := make(chan struct{})
+	go func() {
+		defer close(patched)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			pool.mu.Lock()
+			proxy.SupportsUDP = i%2 == 0
+			proxy.Username = "user"
+			proxy.Headers = map[string]string{"X-Attempt": "1"}
+			pool.mu.Unlock()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	pool.runHealthChecks(0, nil)
+	close(stop)
+	<-patched
+}
+
+func TestRunHealthChecksSkipsOverlappingSweep(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{HealthCheckURL: "http://health.invalid/", HealthCheckTimeout: 1})
+	if _, err := pool.AddProxy(&ProxyIP{ID: "stalled", Address: "http://" + stalledListener(t), TimeoutMs: 500}); err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		pool.runHealthChecks(0, nil)
+		close(done)
+	}()
+	// Wait for the first sweep to claim the in-flight flag
+	for !pool.healthSweeping.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	began := time.Now()
+	pool.runHealthChecks(0, nil)
+	if elapsed := time.Since(began); elapsed > 100*time.Millisecond {
+		t.Errorf("overlapping sweep ran for %v, want it skipped", elapsed)
+	}
+	<-done
+	if pool.healthSweeping.Load() {
+		t.Error("in-flight flag still set after the sweep finished")
+	}
+}
+
+// refusedAddress는 연결을 거부하는 주소를 반환합니다(열었다가 바로 닫은 포트).
+func refusedAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestCheckProxyHealthRetryBackoffStops(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "refused", Address: "http://" + refusedAddress(t)})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+
+	stop := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stop) })
+	began := time.Now()
+	// Five retries would back off for 7.5s in total
+	healthy, _, stopped := pool.checkProxyHealth(proxy, "", time.Second, maxHealthCheckRetries, time.Time{}, stop)
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("check returned after %v, want the backoff interrupted by stop", elapsed)
+	}
+	if healthy || !stopped {
+		t.Errorf("healthy=%t stopped=%t, want a stopped check without a verdict", healthy, stopped)
+	}
+}
+
+func TestCheckProxyHealthRetriesEndBeforeNextSweep(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "refused", Address: "http://" + refusedAddress(t)})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+
+	// Room for the first two retries (0.5s and 1s of backoff, 100ms timeout each) but not the third
+	began := time.Now()
+	healthy, _, stopped := pool.checkProxyHealth(proxy, "", 100*time.Millisecond, maxHealthCheckRetries, began.Add(2*time.Second), nil)
+	elapsed := time.Since(began)
+	if healthy || stopped {
+		t.Errorf("healthy=%t stopped=%t, want an unhealthy verdict", healthy, stopped)
+	}
+	if elapsed < 1500*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("check took %v, want the retries that fit before the deadline and no more", elapsed)
+	}
+}
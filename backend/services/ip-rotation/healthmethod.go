@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// validHealthCheckMethod는 HealthCheckMethod 값이 허용되는지 반환합니다. 빈 값은 기본값(HEAD)을 뜻합니다.
+func validHealthCheckMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodConnect:
+		return true
+	}
+	return false
+}
+
+// healthCheckMethod는 설정값을 정규화한 헬스체크 메서드를 반환합니다. 대역폭을 아끼기 위해 기본값은 HEAD입니다.
+func healthCheckMethod(method string) string {
+	if method == "" {
+		return http.MethodHead
+	}
+	return strings.ToUpper(method)
+}
+
+// checkProxyConnect는 HTTP(S) 프록시에 checkURL 호스트로의 CONNECT 터널을 열 수 있는지 확인합니다.
+// 터널만 검증하고 대상과는 데이터를 주고받지 않으므로, HTTPS 수집에 필요한 터널링 능력을 가장 적은 트래픽으로 점검합니다.
+func checkProxyConnect(ctx context.Context, dial dialFunc, proxyURL *url.URL, proxyHeader http.Header, checkURL string) error {
+	u, err := url.Parse(checkURL)
+	if err != nil {
+		return err
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := dialViaConnect(ctx, dial, proxyURL, proxyHeader, addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
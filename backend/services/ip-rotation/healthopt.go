@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// healthCheckEnabled는 프록시가 주기 헬스체크 대상인지 반환합니다. HealthCheckEnabled가 없으면 기본값 true입니다.
+func (p *ProxyIP) healthCheckEnabled() bool {
+	return p.HealthCheckEnabled == nil || *p.HealthCheckEnabled
+}
+
+// setHealthCheckEnabledLocked는 프록시의 헬스체크 사용 여부를 바꿉니다. nil은 기본값(사용)입니다.
+// 헬스체크를 끄면 점검 결과로 정해진 상태(unhealthy/degraded)를 unknown으로 되돌려, 점검 대상이 차단하는 프록시가
+// 실제 요청 통계로만 판단되게 합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) setHealthCheckEnabledLocked(proxy *ProxyIP, enabled *bool) {
+	was := proxy.healthCheckEnabled()
+	proxy.HealthCheckEnabled = enabled
+	if was == proxy.healthCheckEnabled() {
+		return
+	}
+	if !proxy.healthCheckEnabled() {
+		previous := proxy.HealthStatus
+		proxy.HealthStatus = "unknown"
+		proxy.ConsecutiveHealthy = 0
+		proxy.ConsecutiveUnhealthy = 0
+		proxy.UnhealthySince.Store(time.Time{})
+		p.healthTransports.drop(proxy.ID)
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Health checks disabled for proxy: id=%s health_status=%s -> unknown", proxy.ID, previous)
+		return
+	}
+	log.Printf("[IP-ROTATION] Health checks enabled for proxy: id=%s", proxy.ID)
+}
@@ -0,0 +1,133 @@
+package main
+
+import "time"
+
+// defaultHistorySize는 HistorySize가 설정되지 않았을 때 프록시별로 보관하는 이벤트 수입니다.
+const defaultHistorySize = 50
+
+// ProxyEventType은 프록시 이벤트 이력의 이벤트 종류를 나타냅니다.
+type ProxyEventType string
+
+const (
+	EventSuccess  ProxyEventType = "success"
+	EventFailure  ProxyEventType = "failure"
+	EventCaptcha  ProxyEventType = "captcha"
+	EventDisabled ProxyEventType = "disabled"
+	EventEnabled  ProxyEventType = "enabled"
+	EventBlocked  ProxyEventType = "blocked"
+)
+
+// ProxyEvent는 프록시에 발생한 단일 이벤트(성공/실패/CAPTCHA/비활성화/재활성화)를 나타냅니다.
+type ProxyEvent struct {
+	Type      ProxyEventType `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Reason    string         `json:"reason,omitempty"`
+	LatencyMs int64          `json:"latencyMs,omitempty"`
+}
+
+// eventRing은 고정 크기의 프록시 이벤트 링 버퍼입니다. 가득 차면 가장 오래된 이벤트를 덮어씁니다.
+type eventRing struct {
+	buf   []ProxyEvent
+	start int
+	count int
+}
+
+// newEventRing은 주어진 크기의 이벤트 링 버퍼를 생성합니다.
+func newEventRing(size int) *eventRing {
+	return &eventRing{buf: make([]ProxyEvent, size)}
+}
+
+// push는 이벤트를 추가하고, 용량을 초과하면 가장 오래된 이벤트를 버립니다.
+func (r *eventRing) push(e ProxyEvent) {
+	if len(r.buf) == 0 {
+		return
+	}
+	if r.count < len(r.buf) {
+		r.buf[(r.start+r.count)%len(r.buf)] = e
+		r.count++
+		return
+	}
+	r.buf[r.start] = e
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// last는 최근 n개의 이벤트를 오래된 순서로 반환합니다. n <= 0이면 전체를 반환합니다.
+func (r *eventRing) last(n int) []ProxyEvent {
+	if n <= 0 || n > r.count {
+		n = r.count
+	}
+	events := make([]ProxyEvent, 0, n)
+	for i := r.count - n; i < r.count; i++ {
+		events = append(events, r.buf[(r.start+i)%len(r.buf)])
+	}
+	return events
+}
+
+// resize는 버퍼 용량을 변경하며, 최근 이벤트를 우선 보존합니다.
+func (r *eventRing) resize(size int) {
+	if size == len(r.buf) {
+		return
+	}
+	events := r.last(size)
+	r.buf = make([]ProxyEvent, size)
+	r.start = 0
+	r.count = 0
+	for _, e := range events {
+		r.push(e)
+	}
+}
+
+// historySize는 설정된 프록시별 이력 크기를 반환합니다(미설정 시 기본값).
+func (p *IPPool) historySize() int {
+	if p.config.HistorySize <= 0 {
+		return defaultHistorySize
+	}
+	return p.config.HistorySize
+}
+
+// recordEvent는 프록시 이벤트 이력에 이벤트를 추가합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 하며, 이력 자체는 p.historyMu로 보호됩니다.
+func (p *IPPool) recordEvent(proxyID string, eventType ProxyEventType, reason string, latencyMs int64) {
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	ring, ok := p.history[proxyID]
+	if !ok {
+		ring = newEventRing(p.historySize())
+		p.history[proxyID] = ring
+	}
+	ring.push(ProxyEvent{
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Reason:    reason,
+		LatencyMs: latencyMs,
+	})
+}
+
+// resizeHistory는 모든 프록시 이력 버퍼를 현재 설정 크기로 맞춥니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) resizeHistory() {
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+
+	size := p.historySize()
+	for _, ring := range p.history {
+		ring.resize(size)
+	}
+}
+
+// GetProxyHistory는 특정 프록시의 최근 limit개 이벤트를 오래된 순서로 반환합니다.
+func (p *IPPool) GetProxyHistory(proxyID string, limit int) ([]ProxyEvent, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.proxies[proxyID]; !ok {
+		return nil, false
+	}
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+	ring, ok := p.history[proxyID]
+	if !ok {
+		return []ProxyEvent{}, true
+	}
+	return ring.last(limit), true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// 임대(lease) 보유 시간 추적 관련 한도입니다.
+const (
+	maxOpenLeases = 1024      // per-proxy lease start times kept; older ones are dropped first
+	maxLeaseAge   = time.Hour // leases older than this are treated as abandoned (never recorded)
+)
+
+// holdStats는 /proxy/next로 프록시를 받은 뒤 /proxy/record로 결과를 기록하기까지의 보유 시간을 집계합니다.
+// 결과 기록에는 임대 ID가 없으므로 가장 오래된 미결 임대와 짝지으며, 기록 경로는 p.mu 읽기 잠금만
+// 보유하므로 자체 뮤텍스로 보호합니다. JSON에는 집계 값만 직렬화됩니다(미결 임대는 저장되지 않음).
+type holdStats struct {
+	mu      sync.Mutex
+	open    []time.Time // lease start times, oldest first
+	samples int64
+	totalMs int64 // sum of recorded hold times; the average is computed on read so it doesn't drift
+	maxMs   int64
+}
+
+// holdSnapshot은 holdStats의 JSON 표현입니다.
+type holdSnapshot struct {
+	AvgMs   int64 `json:"avgMs"`
+	TotalMs int64 `json:"totalMs"`
+	MaxMs   int64 `json:"maxMs"`
+	Samples int64 `json:"samples"`
+	Open    int   `json:"open"` // leases handed out and not yet recorded
+}
+
+// start는 프록시가 선택된 시각을 미결 임대로 추가합니다. 한도를 넘으면 가장 오래된 임대를 버립니다.
+func (h *holdStats) start(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.open) >= maxOpenLeases {
+		h.open = h.open[1:]
+	}
+	h.open = append(h.open, at)
+}
+
+// finish는 가장 오래된 미결 임대를 닫고 보유 시간을 합계/최대값에 반영합니다.
+// maxLeaseAge보다 오래된 임대는 결과 없이 버려진 것으로 보고 건너뜁니다. 닫을 임대가 없으면 아무것도 하지 않습니다.
+func (h *holdStats) finish(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.open) > 0 {
+		started := h.open[0]
+		h.open = h.open[1:]
+		held := at.Sub(started)
+		if held > maxLeaseAge {
+			continue
+		}
+		ms := held.Milliseconds()
+		h.samples++
+		h.totalMs += ms
+		if ms > h.maxMs {
+			h.maxMs = ms
+		}
+		return
+	}
+}
+
+// expire는 maxLeaseAge보다 오래된 미결 임대를 결과 없이 버린 것으로 보고 지운 뒤, 남은 미결 임대 수를 반환합니다.
+func (h *holdStats) expire(now time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := 0
+	for i < len(h.open) && now.Sub(h.open[i]) > maxLeaseAge {
+		i++
+	}
+	h.open = h.open[i:]
+	return len(h.open)
+}
+
+// cancel은 가장 최근의 미결 임대를 보유 시간 기록 없이 버립니다. 결과 없이 반납된 선택에 씁니다.
+func (h *holdStats) cancel() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.open) > 0 {
+		h.open = h.open[:len(h.open)-1]
+	}
+}
+
+// reset은 집계 값을 지웁니다. 미결 임대는 아직 돌아올 수 있으므로 유지합니다.
+func (h *holdStats) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples, h.totalMs, h.maxMs = 0, 0, 0
+}
+
+// MarshalJSON은 집계 값과 미결 임대 수를 직렬화합니다.
+func (h *holdStats) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	snap := holdSnapshot{TotalMs: h.totalMs, MaxMs: h.maxMs, Samples: h.samples, Open: len(h.open)}
+	if h.samples > 0 {
+		snap.AvgMs = h.totalMs / h.samples
+	}
+	h.mu.Unlock()
+	return json.Marshal(snap)
+}
+
+// UnmarshalJSON은 저장된 집계 값을 복원합니다.
+func (h *holdStats) UnmarshalJSON(data []byte) error {
+	var snap holdSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.totalMs, h.maxMs, h.samples = snap.TotalMs, snap.MaxMs, snap.Samples
+	if h.totalMs == 0 && snap.AvgMs > 0 {
+		// State files written before totalMs was stored only kept the average
+		h.totalMs = snap.AvgMs * snap.Samples
+	}
+	return nil
+}
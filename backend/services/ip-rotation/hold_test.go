@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHoldStatsAverageDoesNotTruncate(t *testing.T) {
+	var h holdStats
+	base := time.Now()
+	// An integer running mean gets stuck once (ms - avg) / samples rounds to 0: these give 7 instead of 9
+	for _, ms := range []int64{0, 10, 10, 10, 10, 10, 10, 10, 10, 10} {
+		h.start(base)
+		h.finish(base.Add(time.Duration(ms) * time.Millisecond))
+	}
+
+	data, err := json.Marshal(&h)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var snap holdSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if snap.Samples != 10 || snap.TotalMs != 90 || snap.AvgMs != 9 || snap.MaxMs != 10 {
+		t.Errorf("snapshot = %+v, want samples=10 totalMs=90 avgMs=9 maxMs=10", snap)
+	}
+
+	var restored holdStats
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.totalMs != 90 || restored.samples != 10 {
+		t.Errorf("restored totalMs=%d samples=%d, want 90 and 10", restored.totalMs, restored.samples)
+	}
+}
+
+func TestHoldStatsRestoresLegacyAverage(t *testing.T) {
+	var h holdStats
+	if err := json.Unmarshal([]byte(`{"avgMs":250,"maxMs":900,"samples":4}`), &h); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if h.totalMs != 1000 {
+		t.Errorf("totalMs = %d, want 1000 derived from avgMs*samples", h.totalMs)
+	}
+}
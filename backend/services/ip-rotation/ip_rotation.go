@@ -1,41 +1,98 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // ProxyIP는 단일 프록시 설정과 통계 정보를 나타냅니다.
 type ProxyIP struct {
-	ID              string    `json:"id"`
-	Address         string    `json:"address"`  // e.g., "http://proxy.example.com:8080" or "socks5://10.0.0.1:1080"
-	Protocol        string    `json:"protocol"` // http, https, socks4, socks5
-	Username        string    `json:"username,omitempty"`
-	Password        string    `json:"password,omitempty"`
-	Country         string    `json:"country,omitempty"`
-	City            string    `json:"city,omitempty"`
-	Enabled         bool      `json:"enabled"`
-	UsageCount      int64     `json:"usageCount"`
-	LastUsed        time.Time `json:"lastUsed,omitempty"`
-	SuccessCount    int64     `json:"successCount"`
-	FailCount       int64     `json:"failCount"`
-	CaptchaCount    int64     `json:"captchaCount"`
-	AvgLatencyMs    int64     `json:"avgLatencyMs"`
-	CreatedAt       time.Time `json:"createdAt"`
-	DisabledAt      time.Time `json:"disabledAt,omitempty"` // When proxy was auto-disabled
-	LastHealthCheck time.Time `json:"lastHealthCheck,omitempty"`
-	HealthStatus    string    `json:"healthStatus,omitempty"` // healthy, unhealthy, unknown
+	ID                   string            `json:"id"`
+	Address              string            `json:"address"`  // e.g., "http://proxy.example.com:8080" or "socks5://10.0.0.1:1080"
+	Protocol             string            `json:"protocol"` // http, https, socks4, socks5
+	Username             string            `json:"username,omitempty"`
+	Password             string            `json:"password,omitempty"`
+	CredentialVersion    int64             `json:"credentialVersion"` // bumped whenever username/password change; returned by /proxy/next so clients can spot stale credentials
+	Country              string            `json:"country,omitempty"`
+	City                 string            `json:"city,omitempty"`
+	Enabled              bool              `json:"enabled"`
+	UsageCount           Counter           `json:"usageCount"`
+	LastUsed             time.Time         `json:"lastUsed,omitempty"`
+	SuccessCount         Counter           `json:"successCount"` // hot counters are atomic so recording only needs the read lock
+	FailCount            Counter           `json:"failCount"`
+	CaptchaCount         Counter           `json:"captchaCount"`
+	BlockCount           Counter           `json:"blockCount"`    // hard bans reported via /proxy/report-block
+	DisableCycles        Counter           `json:"disableCycles"` // auto-disable → cooldown re-enable round trips; a climbing count means the proxy flaps
+	AvgLatencyMs         Counter           `json:"avgLatencyMs"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	DisabledAt           time.Time         `json:"disabledAt,omitempty"` // When proxy was auto-disabled
+	LastHealthCheck      time.Time         `json:"lastHealthCheck,omitempty"`
+	HealthStatus         string            `json:"healthStatus,omitempty"` // healthy, degraded, unhealthy, unknown
+	Priority             int               `json:"priority"`               // higher tiers are used first; lower tiers are fallbacks
+	ResolvedIPs          []string          `json:"resolvedIps,omitempty"`  // pre-resolved host IPs (when preResolveDns is on)
+	ResolvedAt           time.Time         `json:"resolvedAt,omitempty"`
+	DisabledReason       string            `json:"disabledReason,omitempty"` // max_failures, quota_exhausted, admin, quarantine
+	MaxUsageCount        int64             `json:"maxUsageCount,omitempty"`  // daily usage cap; 0 uses config default
+	DailyUsage           int64             `json:"dailyUsage"`               // selections since last daily reset
+	RemainingQuota       *int64            `json:"remainingQuota,omitempty"` // nil when no cap applies
+	SupportsUDP          bool              `json:"supportsUdp,omitempty"`    // socks5 only; verified via UDP ASSOCIATE
+	UDPStatus            string            `json:"udpStatus,omitempty"`      // healthy, unhealthy, unknown (independent of HealthStatus)
+	WarmupProgress       Gauge             `json:"warmupProgress"`           // 0..1; fraction of warmupRequests completed (1 = fully warmed up)
+	Latitude             *float64          `json:"latitude,omitempty"`
+	Longitude            *float64          `json:"longitude,omitempty"`
+	Tags                 []string          `json:"tags,omitempty"`
+	ConsecutiveHealthy   int               `json:"consecutiveHealthy"`   // consecutive passing health checks
+	ConsecutiveUnhealthy int               `json:"consecutiveUnhealthy"` // consecutive failing health checks
+	Removed              bool              `json:"removed,omitempty"`    // soft-deleted: excluded from selection, stats retained
+	RemovedAt            time.Time         `json:"removedAt,omitempty"`
+	Draining             bool              `json:"draining,omitempty"`        // excluded from selection; in-flight requests may still record results
+	ActiveRequests       Counter           `json:"activeRequests"`            // selections not yet followed by a recorded result
+	Headers              map[string]string `json:"headers,omitempty"`         // sent to the proxy (CONNECT and plain requests), e.g. vendor API-key Proxy-Authorization
+	AnonymityLevel       string            `json:"anonymityLevel,omitempty"`  // transparent, anonymous, elite; empty until checked via anonymityCheckUrl
+	LastFailure          Timestamp         `json:"lastFailure"`               // most recent recorded failure; drives failureBackoffSeconds
+	UnhealthySince       Timestamp         `json:"unhealthySince"`            // start of the current unhealthy/failed streak; zero when healthy
+	TimeoutMs            int64             `json:"timeoutMs,omitempty"`       // per-proxy check/request timeout; 0 uses healthCheckTimeout
+	Provider             string            `json:"provider,omitempty"`        // vendor the proxy was bought from; stats aggregate per provider
+	KeepAliveStatus      string            `json:"keepAliveStatus,omitempty"` // supported, unsupported; empty until checked (keepAliveCheck)
+	Notes                string            `json:"notes,omitempty"`           // free-form operator annotation, e.g. "vendor X trial, expires March"
+	Metadata             map[string]string `json:"metadata,omitempty"`        // arbitrary operator key/value pairs (provenance, contract IDs, ...)
+	SupportsWebSocket    bool              `json:"supportsWebSocket"`         // WebSocket upgrade + echo verified through this proxy (websocketCheckUrl)
+	UserAgents           []string          `json:"userAgents,omitempty"`      // one is handed out with each selection; empty uses defaultUserAgents
+	TLSProfile           string            `json:"tlsProfile,omitempty"`      // client TLS fingerprint (e.g. JA3 or library profile name) to pair with this proxy; metadata only
+	ExpiresAt            time.Time         `json:"expiresAt,omitempty"`       // trial/paid window end; the proxy is disabled once it passes (zero = never)
+	GeoVerifiedAt        time.Time         `json:"geoVerifiedAt,omitempty"`   // last country/city check against geoVerifyUrl
+	ASN                  string            `json:"asn,omitempty"`             // e.g. "AS15169"; detected from the exit IP via geoVerifyUrl
+	Subnet               string            `json:"subnet,omitempty"`          // exit IP's /24 (IPv4) or /48 (IPv6); detected with ASN
+	CostPerRequest       float64           `json:"costPerRequest,omitempty"`  // estimated price per selection in the operator's currency; drives cost_aware and estimatedSpend
+	ActiveHours          *ActiveHours      `json:"activeHours,omitempty"`     // selectable only within these hours; Enabled is left alone outside them
+
+	MaintenanceWindows   []MaintenanceWindow `json:"maintenanceWindows,omitempty"`   // recurring times the proxy is disabled automatically, e.g. a provider's nightly reset
+	HealthCheckEnabled   *bool               `json:"healthCheckEnabled,omitempty"`   // false skips periodic health checks (e.g. the check target is firewalled); unset = true
+	MaxRequestsPerMinute int64               `json:"maxRequestsPerMinute,omitempty"` // selections allowed in any sliding 60s window; 0 = unlimited
+	ExitIP               string              `json:"exitIp,omitempty"`               // egress IP last reported by exitIpCheckUrl or geoVerifyUrl
+	IPVersion            int                 `json:"ipVersion,omitempty"`            // 4 or 6: exit IP family, else the address's (literal or resolved); 0 = unknown
+	HoldTime             holdStats           `json:"holdTime"`                       // time from /proxy/next to the matching /proxy/record; long holds hint at hanging requests
+	Score                Gauge               `json:"score"`                          // 0..100 quality from success rate, latency, captchas and recency (see ScoreWeights); the weighted strategy's input
+
+	recentCaptchas captchaWindow // captcha timestamps for the captcha_aware strategy; not persisted
+	recentRequests requestWindow // selection timestamps for maxRequestsPerMinute; not persisted
 }
 
 // RotationStrategy는 프록시 선택(로테이션) 전략을 정의합니다.
@@ -47,32 +104,90 @@ const (
 	StrategyLeastUsed  RotationStrategy = "least_used"
 	StrategyWeighted   RotationStrategy = "weighted"   // based on success rate
 	StrategyGeographic RotationStrategy = "geographic" // based on country/region
-)
 
-// validStrategies는 RotationStrategy 값 검증에 사용되는 허용 목록입니다.
-var validStrategies = map[RotationStrategy]bool{
-	StrategyRoundRobin: true,
-	StrategyRandom:     true,
-	StrategyLeastUsed:  true,
-	StrategyWeighted:   true,
-	StrategyGeographic: true,
-}
+	StrategyConsistentHash RotationStrategy = "consistent_hash" // same key (e.g. target host) -> same proxy
+	StrategyCaptchaAware   RotationStrategy = "captcha_aware"   // fewest captchas within captchaWindowMinutes
+	StrategyCostAware      RotationStrategy = "cost_aware"      // cheapest proxies whose success rate is within costQualityTolerance of the best
+	StrategyThompson       RotationStrategy = "thompson"        // Beta-Bernoulli bandit: highest success probability sampled from each proxy's results
+)
 
 // IPPoolConfig는 IP 풀의 동작(전략/쿨다운/헬스체크/영속화) 설정을 담습니다.
 type IPPoolConfig struct {
-	Strategy            RotationStrategy `json:"strategy"`
-	MaxFailures         int              `json:"maxFailures"`     // auto-disable after N failures
-	CooldownMinutes     int              `json:"cooldownMinutes"` // re-enable after cooldown
-	PreferredCountry    string           `json:"preferredCountry,omitempty"`
-	HealthCheckInterval int              `json:"healthCheckInterval"`       // seconds between health checks
-	HealthCheckTimeout  int              `json:"healthCheckTimeout"`        // seconds for health check timeout
-	PersistencePath     string           `json:"persistencePath,omitempty"` // path to save/load pool state
+	Strategy              RotationStrategy   `json:"strategy"`
+	MaxFailures           int                `json:"maxFailures"`     // auto-disable after N failures
+	CooldownMinutes       int                `json:"cooldownMinutes"` // re-enable after cooldown
+	PreferredCountry      string             `json:"preferredCountry,omitempty"`
+	HealthCheckInterval   int                `json:"healthCheckInterval"`         // seconds between health checks
+	HealthCheckTimeout    int                `json:"healthCheckTimeout"`          // seconds for health check timeout
+	HealthCheckURL        string             `json:"healthCheckUrl,omitempty"`    // if set, health checks fetch this URL through the proxy
+	HealthCheckMethod     string             `json:"healthCheckMethod,omitempty"` // GET, HEAD (default) or CONNECT; CONNECT only opens a tunnel to the check URL's host
+	HealthCheckRetries    int                `json:"healthCheckRetries"`          // extra attempts before a health check counts as failed; 0 = fail on the first error
+	PersistencePath       string             `json:"persistencePath,omitempty"`   // path to save/load pool state
+	HistorySize           int                `json:"historySize"`                 // max events kept per proxy history
+	AutoSaveInterval      int                `json:"autoSaveInterval"`            // seconds; auto-saves are coalesced to at most one per interval
+	PreResolveDNS         bool               `json:"preResolveDns"`               // resolve proxy hostnames ahead of time (opt-in; some providers need SNI/hostname)
+	DNSRefreshInterval    int                `json:"dnsRefreshInterval"`          // seconds; TTL for pre-resolved IPs
+	DefaultMaxUsage       int64              `json:"defaultMaxUsage"`             // daily usage cap per proxy; 0 = unlimited
+	DisableOnQuota        bool               `json:"disableOnQuota"`              // disable capped proxies until the daily reset
+	DailyResetHourUTC     int                `json:"dailyResetHourUtc"`           // hour (0-23, UTC) at which daily usage resets
+	SelectionWaitTimeout  int                `json:"selectionWaitTimeout"`        // seconds to wait for a usable proxy; 0 = fail fast
+	UDPCheckTarget        string             `json:"udpCheckTarget,omitempty"`    // IPv4 DNS server used to verify SOCKS5 UDP relaying; empty = handshake only
+	WarmupRequests        int                `json:"warmupRequests"`              // recorded results before a new proxy gets full weighted share; 0 = no warmup
+	RecordDedupWindow     int                `json:"recordDedupWindow"`           // seconds a /proxy/record requestId is remembered
+	RecordDedupSize       int                `json:"recordDedupSize"`             // max remembered requestIds (LRU)
+	HealthyThreshold      int                `json:"healthyThreshold"`            // consecutive passes to flip unhealthy -> healthy
+	UnhealthyThreshold    int                `json:"unhealthyThreshold"`          // consecutive failures to flip healthy -> unhealthy
+	DrainAutoDisable      bool               `json:"drainAutoDisable"`            // disable a draining proxy once its in-flight requests reach zero
+	SlowSelectionMs       int                `json:"slowSelectionMs"`             // log selections slower than this many milliseconds; 0 = never
+	ExitIPCheckURL        string             `json:"exitIpCheckUrl,omitempty"`    // IP echo service queried through each proxy by /admin/proxy-validate
+	UpstreamPools         []string           `json:"upstreamPools,omitempty"`     // peer pool base URLs asked (in order) when no local proxy is available
+	AnonymityCheckURL     string             `json:"anonymityCheckUrl,omitempty"` // plain-http header echo service; health checks classify each proxy's AnonymityLevel
+	GeoVerifyURL          string             `json:"geoVerifyUrl,omitempty"`      // geo-IP service queried through each proxy; health checks correct Country/City/coordinates and ASN/subnet (opt-in)
+	OriginIP              string             `json:"originIp,omitempty"`          // our egress IP; discovered via exitIpCheckUrl when empty
+	EliteOnly             bool               `json:"eliteOnly"`                   // only select proxies verified as elite
+	FailureBackoffSeconds int                `json:"failureBackoffSeconds"`       // skip a proxy for this long after a recorded failure; 0 = off
+	LatencyBucketsMs      []float64          `json:"latencyBucketsMs,omitempty"`  // upper bounds of the per-proxy latency histogram; empty = defaults
+	AutoRemoveAfterHours  int                `json:"autoRemoveAfterHours"`        // soft-remove proxies unhealthy/failed continuously this long; 0 = never
+	KeepAliveCheck        bool               `json:"keepAliveCheck"`              // health checks also verify connection reuse (needs healthCheckUrl)
+	BlockCooldownMinutes  int                `json:"blockCooldownMinutes"`        // re-enable blocked proxies after this long; 0 = stay disabled
+	NewProxyWeight        float64            `json:"newProxyWeight"`              // weighted-strategy score of a proxy with no results yet (before minWeight); 0 = default 50
+	MinWeight             float64            `json:"minWeight"`                   // weighted-strategy floor and exploration bonus for every proxy; 0 = default 10
+	ExplorationRate       float64            `json:"explorationRate"`             // probability (0..1) a weighted selection ignores weights and picks uniformly; 0 = off
+	ShadowStrategy        RotationStrategy   `json:"shadowStrategy,omitempty"`    // also evaluated on every selection and logged, never served; empty = off
+	CountryTargets        map[string]float64 `json:"countryTargets,omitempty"`    // minimum share (%) of recent selections per country; under-served countries are preferred
+	ASNAntiAffinity       int                `json:"asnAntiAffinity"`             // prefer proxies outside the ASNs/subnets of the last N selections; 0 = off
+	WebSocketCheckURL     string             `json:"websocketCheckUrl,omitempty"` // ws(s):// echo endpoint; health checks verify WebSocket upgrades through each proxy; empty = off
+	UpstreamProxy         string             `json:"upstreamProxy,omitempty"`     // http:// proxy tunneled through (CONNECT) to reach the pool's proxies; empty = direct
+	MinSuccessRate        float64            `json:"minSuccessRate"`              // percent; disable proxies whose success rate falls below this; 0 = off
+	MinSuccessSamples     int                `json:"minSuccessSamples"`           // recorded results required before minSuccessRate applies; 0 = default 100
+	MinEnabledProxies     int                `json:"minEnabledProxies"`           // failure auto-disables never drop the selectable pool below this; 0 = no floor
+	DefaultUserAgents     []string           `json:"defaultUserAgents,omitempty"` // handed out with selections of proxies that carry no userAgents of their own
+	CaptchaWindowMinutes  int                `json:"captchaWindowMinutes"`        // captcha_aware strategy only counts captchas this recent; 0 = default 60
+	RebalanceOrderMinutes int                `json:"rebalanceOrderMinutes"`       // periodically spread unhealthy/new proxies through the round-robin order; 0 = off
+	DegradedLatencyMs     int                `json:"degradedLatencyMs"`           // health checks slower than this mark the proxy degraded; 0 = off
+	CostQualityTolerance  float64            `json:"costQualityTolerance"`        // cost_aware: success-rate points a cheaper proxy may trail the best candidate by; 0 = default 10
+	ProviderSyncURL       string             `json:"providerSyncUrl,omitempty"`   // provider API listing current proxies; the pool follows it (add/update/remove); empty = off
+	ProviderSyncMinutes   int                `json:"providerSyncMinutes"`         // how often providerSyncUrl is fetched; 0 = default 60
+	ProviderSyncName      string             `json:"providerSyncName,omitempty"`  // provider recorded on synced proxies; sync only removes proxies of this provider; empty = "provider-sync"
+	AllowNewerStateSchema bool               `json:"allowNewerStateSchema"`       // load state files from newer builds anyway (unknown fields are dropped on the next save)
+	TagStrategies         StrategyOverrides  `json:"tagStrategies,omitempty"`     // strategy for selections filtered by one of these tags, e.g. residential → weighted
+	CountryStrategies     StrategyOverrides  `json:"countryStrategies,omitempty"` // strategy for selections filtered by one of these countries; tag overrides win
+	ScoreWeights          ScoreWeights       `json:"scoreWeights"`                // relative weights of the proxy score components; all 0 = defaults
 }
 
 // Validate는 IPPoolConfig 값이 유효한지 검사하고, 잘못된 설정이면 오류를 반환합니다.
 func (c *IPPoolConfig) Validate() error {
-	if c.Strategy != "" && !validStrategies[c.Strategy] {
-		return fmt.Errorf("invalid strategy: %s, must be one of: round_robin, random, least_used, weighted, geographic", c.Strategy)
+	if c.Strategy != "" && !validStrategy(c.Strategy) {
+		return fmt.Errorf("invalid strategy: %s, must be one of: %s", c.Strategy, strategyNames())
+	}
+	if c.ShadowStrategy != "" && !validStrategy(c.ShadowStrategy) {
+		return fmt.Errorf("invalid shadowStrategy: %s, must be one of: %s", c.ShadowStrategy, strategyNames())
+	}
+	if err := validateStrategyOverrides("tagStrategies", c.TagStrategies); err != nil {
+		return err
+	}
+	if err := validateStrategyOverrides("countryStrategies", c.CountryStrategies); err != nil {
+		return err
 	}
 	if c.MaxFailures < 0 {
 		return errors.New("maxFailures must be non-negative")
@@ -86,31 +201,233 @@ func (c *IPPoolConfig) Validate() error {
 	if c.HealthCheckTimeout < 0 {
 		return errors.New("healthCheckTimeout must be non-negative")
 	}
+	if c.HealthCheckRetries < 0 || c.HealthCheckRetries > maxHealthCheckRetries {
+		return fmt.Errorf("invalid healthCheckRetries: %d, must be between 0 and %d", c.HealthCheckRetries, maxHealthCheckRetries)
+	}
+	if c.HistorySize < 0 {
+		return errors.New("historySize must be non-negative")
+	}
+	if c.AutoSaveInterval < 0 {
+		return errors.New("autoSaveInterval must be non-negative")
+	}
+	if c.DNSRefreshInterval < 0 {
+		return errors.New("dnsRefreshInterval must be non-negative")
+	}
+	if c.DefaultMaxUsage < 0 {
+		return errors.New("defaultMaxUsage must be non-negative")
+	}
+	if c.DailyResetHourUTC < 0 || c.DailyResetHourUTC > 23 {
+		return errors.New("dailyResetHourUtc must be between 0 and 23")
+	}
+	if c.SelectionWaitTimeout < 0 {
+		return errors.New("selectionWaitTimeout must be non-negative")
+	}
+	if c.WarmupRequests < 0 {
+		return errors.New("warmupRequests must be non-negative")
+	}
+	if c.RecordDedupWindow < 0 {
+		return errors.New("recordDedupWindow must be non-negative")
+	}
+	if c.RecordDedupSize < 0 {
+		return errors.New("recordDedupSize must be non-negative")
+	}
+	if c.HealthyThreshold < 0 {
+		return errors.New("healthyThreshold must be non-negative")
+	}
+	if c.UnhealthyThreshold < 0 {
+		return errors.New("unhealthyThreshold must be non-negative")
+	}
+	if c.SlowSelectionMs < 0 {
+		return errors.New("slowSelectionMs must be non-negative")
+	}
+	if c.FailureBackoffSeconds < 0 {
+		return errors.New("failureBackoffSeconds must be non-negative")
+	}
+	if c.AutoRemoveAfterHours < 0 {
+		return errors.New("autoRemoveAfterHours must be non-negative")
+	}
+	if c.BlockCooldownMinutes < 0 {
+		return errors.New("blockCooldownMinutes must be non-negative")
+	}
+	if c.MinSuccessRate < 0 || c.MinSuccessRate > 100 {
+		return errors.New("minSuccessRate must be between 0 and 100")
+	}
+	if c.MinSuccessSamples < 0 {
+		return errors.New("minSuccessSamples must be non-negative")
+	}
+	if c.MinEnabledProxies < 0 {
+		return errors.New("minEnabledProxies must be non-negative")
+	}
+	if c.NewProxyWeight < 0 {
+		return errors.New("newProxyWeight must be positive (0 = default)")
+	}
+	if c.MinWeight < 0 {
+		return errors.New("minWeight must be positive (0 = default)")
+	}
+	if err := c.ScoreWeights.validate(); err != nil {
+		return err
+	}
+	if c.ExplorationRate < 0 || c.ExplorationRate > 1 {
+		return fmt.Errorf("invalid explorationRate: %g, must be between 0 and 1", c.ExplorationRate)
+	}
+	totalTarget := 0.0
+	for country, target := range c.CountryTargets {
+		if country == "" || target <= 0 || target > 100 {
+			return fmt.Errorf("invalid countryTargets entry %q: %g, must be a country with a share in (0, 100]", country, target)
+		}
+		totalTarget += target
+	}
+	if totalTarget > 100 {
+		return errors.New("countryTargets must not add up to more than 100")
+	}
+	for i, le := range c.LatencyBucketsMs {
+		if le <= 0 || (i > 0 && le <= c.LatencyBucketsMs[i-1]) {
+			return errors.New("latencyBucketsMs must be positive and strictly increasing")
+		}
+	}
+	if c.HealthCheckURL != "" {
+		u, err := url.Parse(c.HealthCheckURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid healthCheckUrl: %s, must be an absolute http(s) URL", c.HealthCheckURL)
+		}
+	}
+	if !validHealthCheckMethod(c.HealthCheckMethod) {
+		return fmt.Errorf("invalid healthCheckMethod: %s, must be one of: GET, HEAD, CONNECT", c.HealthCheckMethod)
+	}
+	if c.WebSocketCheckURL != "" {
+		u, err := url.Parse(c.WebSocketCheckURL)
+		if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			return fmt.Errorf("invalid websocketCheckUrl: %s, must be an absolute ws(s) URL", c.WebSocketCheckURL)
+		}
+	}
+	if c.UpstreamProxy != "" {
+		u, err := url.Parse(c.UpstreamProxy)
+		if err != nil || u.Scheme != "http" || u.Host == "" {
+			return fmt.Errorf("invalid upstreamProxy: %s, must be an absolute http URL", c.UpstreamProxy)
+		}
+	}
+	if c.RebalanceOrderMinutes < 0 {
+		return fmt.Errorf("invalid rebalanceOrderMinutes: %d, must be non-negative (0 = off)", c.RebalanceOrderMinutes)
+	}
+	if c.ASNAntiAffinity < 0 || c.ASNAntiAffinity > maxASNAntiAffinity {
+		return fmt.Errorf("invalid asnAntiAffinity: %d, must be between 0 and %d (0 = off)", c.ASNAntiAffinity, maxASNAntiAffinity)
+	}
+	if c.DegradedLatencyMs < 0 {
+		return fmt.Errorf("invalid degradedLatencyMs: %d, must be non-negative (0 = off)", c.DegradedLatencyMs)
+	}
+	if c.ProviderSyncURL != "" {
+		u, err := url.Parse(c.ProviderSyncURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid providerSyncUrl: %s, must be an absolute http(s) URL", c.ProviderSyncURL)
+		}
+	}
+	if c.ProviderSyncMinutes < 0 {
+		return fmt.Errorf("invalid providerSyncMinutes: %d, must be positive (0 = default)", c.ProviderSyncMinutes)
+	}
+	if c.CostQualityTolerance < 0 || c.CostQualityTolerance > 100 {
+		return fmt.Errorf("invalid costQualityTolerance: %g, must be between 0 and 100 (0 = default)", c.CostQualityTolerance)
+	}
+	if c.CaptchaWindowMinutes < 0 {
+		return fmt.Errorf("invalid captchaWindowMinutes: %d, must be positive (0 = default)", c.CaptchaWindowMinutes)
+	}
+	if msg := validateUserAgents(c.DefaultUserAgents); msg != "" {
+		return fmt.Errorf("invalid defaultUserAgents: %s", msg)
+	}
+	for _, base := range c.UpstreamPools {
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid upstreamPools entry: %s, must be an absolute http(s) URL", base)
+		}
+	}
+	if c.ExitIPCheckURL != "" {
+		u, err := url.Parse(c.ExitIPCheckURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid exitIpCheckUrl: %s, must be an absolute http(s) URL", c.ExitIPCheckURL)
+		}
+	}
+	if c.AnonymityCheckURL != "" {
+		// Headers added by the proxy are only visible to a plain-http target
+		u, err := url.Parse(c.AnonymityCheckURL)
+		if err != nil || u.Scheme != "http" || u.Host == "" {
+			return fmt.Errorf("invalid anonymityCheckUrl: %s, must be an absolute http URL", c.AnonymityCheckURL)
+		}
+	}
+	if c.GeoVerifyURL != "" {
+		u, err := url.Parse(c.GeoVerifyURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid geoVerifyUrl: %s, must be an absolute http(s) URL", c.GeoVerifyURL)
+		}
+	}
+	if c.OriginIP != "" && net.ParseIP(c.OriginIP) == nil {
+		return fmt.Errorf("invalid originIp: %s", c.OriginIP)
+	}
 	return nil
 }
 
 // IPPoolState는 IP 풀의 상태를 파일에 저장/복원하기 위한 직렬화 구조체입니다.
 type IPPoolState struct {
-	Proxies map[string]*ProxyIP `json:"proxies"`
-	Order   []string            `json:"order"`
-	Index   int                 `json:"index"`
-	Config  IPPoolConfig        `json:"config"`
-	SavedAt time.Time           `json:"savedAt"`
+	SchemaVersion int                 `json:"schemaVersion"` // see currentSchemaVersion; absent in files written before versioning
+	Proxies       map[string]*ProxyIP `json:"proxies"`
+	Order         []string            `json:"order"`
+	Index         int                 `json:"index"`
+	Config        IPPoolConfig        `json:"config"`
+	SavedAt       time.Time           `json:"savedAt"`
+	LastServed    string              `json:"lastServed,omitempty"` // round-robin resumes after this proxy even if order changed
 }
 
 // IPPool은 프록시 풀을 관리하고 로테이션/통계/헬스체크/영속화를 제공합니다.
 type IPPool struct {
-	mu                 sync.RWMutex
-	proxies            map[string]*ProxyIP
-	order              []string // for round-robin
-	index              int      // current index for round-robin
-	config             IPPoolConfig
-	cooldownTicker     *time.Ticker
-	healthCheckTicker  *time.Ticker
-	stopCooldown       chan struct{}
-	stopHealthCheck    chan struct{}
-	cooldownRunning    bool
-	healthCheckRunning bool
+	mu                  sync.RWMutex
+	proxies             map[string]*ProxyIP
+	order               []string // for round-robin
+	index               int      // current index for round-robin
+	shadowIndex         int      // round-robin cursor of the shadow strategy, kept apart from the live one
+	shadow              shadowStats
+	countryUsage        countryUsage // countries of recent selections, for countryTargets
+	recentNetworks      []string     // ASN/subnet of the last asnAntiAffinity selections, oldest first
+	config              IPPoolConfig
+	history             map[string]*eventRing // per-proxy recent events (not persisted)
+	historyMu           sync.Mutex            // guards history; lets the record path log events under the read lock
+	recordDedup         *dedupCache           // recently seen /proxy/record request IDs
+	metrics             *poolMetrics          // Prometheus counters exposed on /metrics
+	weightsGen          atomic.Uint64         // bumped on any change that affects weighted selection
+	paused              atomic.Bool           // set by /admin/pause; selection fails with ErrServicePaused
+	weights             weightCache           // cumulative weights reused while weightsGen is unchanged
+	ring                hashRing              // consistent_hash ring reused while the candidate set is unchanged
+	thompsonRand        *lockedRand           // Thompson sampling source; nil = global source, tests fix the seed
+	cooldownTicker      *time.Ticker
+	healthCheckTicker   *time.Ticker
+	stopCooldown        chan struct{}
+	stopHealthCheck     chan struct{}
+	cooldownRunning     bool
+	healthCheckRunning  bool
+	healthSweeping      atomic.Bool // set while runHealthChecks is in flight; overlapping sweeps are skipped
+	dnsTicker           *time.Ticker
+	stopDNS             chan struct{}
+	dnsRunning          bool
+	stopSweep           chan struct{}
+	sweepRunning        bool
+	stopDailyReset      chan struct{}
+	dailyResetRunning   bool
+	stopMaintenance     chan struct{}
+	maintenanceRunning  bool
+	stopRebalance       chan struct{}
+	rebalanceRunning    bool
+	stopExpiry          chan struct{}
+	expiryRunning       bool
+	stopProviderSync    chan struct{}
+	providerSyncRunning bool
+	providerSyncAuth    http.Header        // sent with provider listing fetches; from the environment only, never in config
+	providerSync        ProviderSyncStatus // outcome of the latest provider sync
+	stateCipher         *stateCipher       // encrypts the state file when PERSISTENCE_KEY is set; nil = plaintext
+	allowPlaintextState bool               // with a key set, still load a plaintext state file (one-time migration)
+	healthTransports    *transportCache    // per-proxy health-check transports, reused across checks
+	persistence         persistenceStatus  // outcome of recent state saves, surfaced on /health
+	saveDirty           chan struct{}      // buffered(1); signals the auto-saver that state changed
+	saveMu              sync.Mutex         // serializes state file writes (auto-saver vs. manual saves)
+	stopAutoSave        chan struct{}
+	autoSaveDone        chan struct{}
+	minEnabledLog       minEnabledLog // throttles the MinEnabledProxies log; guarded by mu
 }
 
 var (
@@ -120,59 +437,180 @@ var (
 
 // initIPPool은 환경 변수 기반 설정을 읽어 전역 IP 풀을 초기화합니다.
 func initIPPool() {
-	// Get config from environment
+	// Get config from environment; malformed values are logged and fall back to defaults
+	var env envConfig
 	strategy := RotationStrategy(os.Getenv("STRATEGY"))
 	if strategy == "" {
 		strategy = StrategyRoundRobin
 	}
 
-	maxFailures := 5
-	if v := os.Getenv("MAX_FAILURES"); v != "" {
-		fmt.Sscanf(v, "%d", &maxFailures)
-	}
-
-	cooldownMinutes := 30
-	if v := os.Getenv("COOLDOWN_MINUTES"); v != "" {
-		fmt.Sscanf(v, "%d", &cooldownMinutes)
+	maxFailures := env.Int("MAX_FAILURES", 5)
+	cooldownMinutes := env.Int("COOLDOWN_MINUTES", 30)
+	healthCheckInterval := env.Int("HEALTH_CHECK_INTERVAL", 300)
+	persistencePath := os.Getenv("PERSISTENCE_PATH")
+	historySize := env.Int("HISTORY_SIZE", defaultHistorySize)
+	autoSaveInterval := env.Int("AUTO_SAVE_INTERVAL", 2)
+	dnsRefreshInterval := env.Int("DNS_REFRESH_INTERVAL", defaultDNSRefreshInterval)
+	defaultMaxUsage := env.Int64("DEFAULT_MAX_USAGE", 0)
+	dailyResetHour := env.Int("DAILY_RESET_HOUR_UTC", 0)
+	selectionWaitTimeout := env.Int("SELECTION_WAIT_TIMEOUT", 0)
+	warmupRequests := env.Int("WARMUP_REQUESTS", 0)
+	recordDedupWindow := env.Int("RECORD_DEDUP_WINDOW", defaultRecordDedupWindow)
+	healthyThreshold := env.Int("HEALTHY_THRESHOLD", 2)
+	unhealthyThreshold := env.Int("UNHEALTHY_THRESHOLD", 3)
+	healthCheckRetries := env.Int("HEALTH_CHECK_RETRIES", 0)
+	slowSelectionMs := env.Int("SLOW_SELECTION_MS", 50)
+	failureBackoffSeconds := env.Int("FAILURE_BACKOFF_SECONDS", 0)
+	autoRemoveAfterHours := env.Int("AUTO_REMOVE_AFTER_HOURS", 0)
+	blockCooldownMinutes := env.Int("BLOCK_COOLDOWN_MINUTES", 360)
+	newProxyWeight := env.Float("NEW_PROXY_WEIGHT", defaultNewProxyWeight)
+	minWeight := env.Float("MIN_WEIGHT", defaultMinWeight)
+	explorationRate := env.Float("EXPLORATION_RATE", defaultExplorationRate)
+	minSuccessRate := env.Float("MIN_SUCCESS_RATE", 0)
+	minSuccessSamples := env.Int("MIN_SUCCESS_SAMPLES", defaultMinSuccessSamples)
+	minEnabledProxies := env.Int("MIN_ENABLED_PROXIES", 0)
+	captchaWindowMinutes := env.Int("CAPTCHA_WINDOW_MINUTES", defaultCaptchaWindowMinutes)
+	rebalanceOrderMinutes := env.Int("REBALANCE_ORDER_MINUTES", 0)
+	degradedLatencyMs := env.Int("DEGRADED_LATENCY_MS", 0)
+	asnAntiAffinity := env.Int("ASN_ANTI_AFFINITY", 0)
+	costQualityTolerance := env.Float("COST_QUALITY_TOLERANCE", defaultCostQualityTolerance)
+	providerSyncMinutes := env.Int("PROVIDER_SYNC_MINUTES", defaultProviderSyncMinutes)
+
+	udpCheckTarget := defaultUDPCheckTarget
+	if v, ok := os.LookupEnv("UDP_CHECK_TARGET"); ok {
+		udpCheckTarget = v
+	}
+
+	cfg := IPPoolConfig{
+		Strategy:              strategy,
+		MaxFailures:           maxFailures,
+		CooldownMinutes:       cooldownMinutes,
+		HealthCheckInterval:   healthCheckInterval,
+		HealthCheckTimeout:    10,
+		HealthCheckURL:        os.Getenv("HEALTH_CHECK_URL"),
+		HealthCheckMethod:     strings.ToUpper(os.Getenv("HEALTH_CHECK_METHOD")),
+		PersistencePath:       persistencePath,
+		HistorySize:           historySize,
+		AutoSaveInterval:      autoSaveInterval,
+		PreResolveDNS:         env.Bool("PRE_RESOLVE_DNS", false),
+		DNSRefreshInterval:    dnsRefreshInterval,
+		DefaultMaxUsage:       defaultMaxUsage,
+		DisableOnQuota:        env.Bool("DISABLE_ON_QUOTA", false),
+		DailyResetHourUTC:     dailyResetHour,
+		SelectionWaitTimeout:  selectionWaitTimeout,
+		UDPCheckTarget:        udpCheckTarget,
+		WarmupRequests:        warmupRequests,
+		RecordDedupWindow:     recordDedupWindow,
+		RecordDedupSize:       defaultRecordDedupSize,
+		HealthyThreshold:      healthyThreshold,
+		UnhealthyThreshold:    unhealthyThreshold,
+		HealthCheckRetries:    healthCheckRetries,
+		DrainAutoDisable:      env.Bool("DRAIN_AUTO_DISABLE", false),
+		SlowSelectionMs:       slowSelectionMs,
+		ExitIPCheckURL:        os.Getenv("EXIT_IP_CHECK_URL"),
+		UpstreamPools:         parseUpstreamPools(os.Getenv("UPSTREAM_POOLS")),
+		AnonymityCheckURL:     os.Getenv("ANONYMITY_CHECK_URL"),
+		GeoVerifyURL:          os.Getenv("GEO_VERIFY_URL"),
+		OriginIP:              os.Getenv("ORIGIN_IP"),
+		EliteOnly:             env.Bool("ELITE_ONLY", false),
+		FailureBackoffSeconds: failureBackoffSeconds,
+		LatencyBucketsMs:      parseLatencyBuckets(os.Getenv("LATENCY_BUCKETS_MS")),
+		AutoRemoveAfterHours:  autoRemoveAfterHours,
+		KeepAliveCheck:        env.Bool("KEEPALIVE_CHECK", false),
+		BlockCooldownMinutes:  blockCooldownMinutes,
+		NewProxyWeight:        newProxyWeight,
+		MinWeight:             minWeight,
+		ExplorationRate:       explorationRate,
+		ShadowStrategy:        RotationStrategy(os.Getenv("SHADOW_STRATEGY")),
+		CountryTargets:        parseCountryTargets(os.Getenv("COUNTRY_TARGETS")),
+		ASNAntiAffinity:       asnAntiAffinity,
+		UpstreamProxy:         os.Getenv("UPSTREAM_PROXY"),
+		WebSocketCheckURL:     os.Getenv("WEBSOCKET_CHECK_URL"),
+		MinSuccessRate:        minSuccessRate,
+		MinSuccessSamples:     minSuccessSamples,
+		MinEnabledProxies:     minEnabledProxies,
+		DefaultUserAgents:     parseUserAgents(os.Getenv("DEFAULT_USER_AGENTS")),
+		CaptchaWindowMinutes:  captchaWindowMinutes,
+		RebalanceOrderMinutes: rebalanceOrderMinutes,
+		DegradedLatencyMs:     degradedLatencyMs,
+		CostQualityTolerance:  costQualityTolerance,
+		ProviderSyncURL:       os.Getenv("PROVIDER_SYNC_URL"),
+		ProviderSyncMinutes:   providerSyncMinutes,
+		ProviderSyncName:      os.Getenv("PROVIDER_SYNC_NAME"),
+		AllowNewerStateSchema: env.Bool("ALLOW_NEWER_STATE_SCHEMA", false),
+		TagStrategies:         parseStrategyOverrides(&env, "TAG_STRATEGIES"),
+		CountryStrategies:     parseStrategyOverrides(&env, "COUNTRY_STRATEGIES"),
+		ScoreWeights:          parseScoreWeights(&env, "SCORE_WEIGHTS"),
 	}
-
-	healthCheckInterval := 300
-	if v := os.Getenv("HEALTH_CHECK_INTERVAL"); v != "" {
-		fmt.Sscanf(v, "%d", &healthCheckInterval)
+	if err := cfg.Validate(); err != nil {
+		env.problems = append(env.problems, err.Error())
+		log.Printf("[IP-ROTATION] Invalid config from environment: %v", err)
 	}
+	allowPlaintextState := env.Bool("PERSISTENCE_ALLOW_PLAINTEXT", false)
+	env.enforceStrict()
 
-	persistencePath := os.Getenv("PERSISTENCE_PATH")
+	globalIPPool = NewIPPool(cfg)
 
-	globalIPPool = NewIPPool(IPPoolConfig{
-		Strategy:            strategy,
-		MaxFailures:         maxFailures,
-		CooldownMinutes:     cooldownMinutes,
-		HealthCheckInterval: healthCheckInterval,
-		HealthCheckTimeout:  10,
-		PersistencePath:     persistencePath,
-	})
+	// The key never goes into the config so it can't leak through /admin/proxy-pool-config or the state file
+	stateKey, err := persistenceKeyFromEnv(os.Getenv("PERSISTENCE_KEY"))
+	if err != nil {
+		log.Fatalf("[IP-ROTATION] Refusing to start: %v", err)
+	}
+	globalIPPool.SetStateCipher(stateKey, allowPlaintextState)
+	if stateKey != nil {
+		log.Printf("[IP-ROTATION] State file encryption enabled (AES-GCM)")
+	}
 
 	// Load existing state if persistence path is set
 	if persistencePath != "" {
 		if err := globalIPPool.LoadFromFile(persistencePath); err != nil {
+			// Starting empty would let auto-save overwrite the newer file with an empty pool
+			if errors.Is(err, ErrUnsupportedSchema) {
+				log.Fatalf("[IP-ROTATION] Refusing to start: %v (set ALLOW_NEWER_STATE_SCHEMA=true to load it anyway)", err)
+			}
+			// Likewise an unreadable encrypted file (or a plaintext one when a key is expected)
+			if errors.Is(err, ErrStateEncryption) {
+				log.Fatalf("[IP-ROTATION] Refusing to start: %v", err)
+			}
 			log.Printf("[IP-ROTATION] Failed to load state: %v", err)
 		}
 	}
+
+	// Started after loading so the first sync diffs against the restored pool and keeps its stats
+	globalIPPool.SetProviderSyncAuth(providerSyncAuthFromEnv(os.Getenv("PROVIDER_SYNC_TOKEN"), os.Getenv("PROVIDER_SYNC_AUTH_HEADER")))
+	globalIPPool.StartProviderSync()
 }
 
 // NewIPPool은 주어진 설정으로 IPPool을 생성하고, 필요 시 쿨다운/헬스체크 루틴을 시작합니다.
 func NewIPPool(config IPPoolConfig) *IPPool {
 	pool := &IPPool{
-		proxies:         make(map[string]*ProxyIP),
-		order:           make([]string, 0),
-		index:           0,
-		config:          config,
-		stopCooldown:    make(chan struct{}),
-		stopHealthCheck: make(chan struct{}),
-	}
+		proxies:          make(map[string]*ProxyIP),
+		order:            make([]string, 0),
+		index:            0,
+		config:           config,
+		history:          make(map[string]*eventRing),
+		recordDedup:      newDedupCache(),
+		metrics:          newPoolMetrics(),
+		healthTransports: newTransportCache(),
+		stopCooldown:     make(chan struct{}),
+		stopHealthCheck:  make(chan struct{}),
+		stopDNS:          make(chan struct{}),
+		stopSweep:        make(chan struct{}),
+		stopDailyReset:   make(chan struct{}),
+		stopMaintenance:  make(chan struct{}),
+		stopRebalance:    make(chan struct{}),
+		stopExpiry:       make(chan struct{}),
+		stopProviderSync: make(chan struct{}),
+		saveDirty:        make(chan struct{}, 1),
+		stopAutoSave:     make(chan struct{}),
+		autoSaveDone:     make(chan struct{}),
+	}
+
+	pool.metrics.setLatencyBuckets(config.LatencyBucketsMs)
+	go pool.runAutoSaver()
 
 	// Start cooldown checker if cooldown is configured
-	if config.CooldownMinutes > 0 {
+	if config.CooldownMinutes > 0 || config.BlockCooldownMinutes > 0 {
 		pool.StartCooldownChecker()
 	}
 
@@ -181,6 +619,25 @@ func NewIPPool(config IPPoolConfig) *IPPool {
 		pool.StartHealthChecker()
 	}
 
+	// Start DNS pre-resolution if opted in
+	if config.PreResolveDNS {
+		pool.StartDNSResolver()
+	}
+
+	// Daily usage quotas can be set per proxy at any time, so the reset always runs
+	pool.StartDailyResetScheduler()
+	// Maintenance windows and expiry are per proxy as well
+	pool.StartMaintenanceScheduler()
+	pool.StartExpirySweeper()
+
+	if config.AutoRemoveAfterHours > 0 {
+		pool.StartDeadProxySweeper()
+	}
+
+	if config.RebalanceOrderMinutes > 0 {
+		pool.StartOrderRebalancer()
+	}
+
 	return pool
 }
 
@@ -202,6 +659,7 @@ func (p *IPPool) StartCooldownChecker() {
 			select {
 			case <-p.cooldownTicker.C:
 				p.checkAndReenableProxies()
+				p.expireAbandonedLeases()
 			case <-p.stopCooldown:
 				p.cooldownTicker.Stop()
 				log.Printf("[IP-ROTATION] Cooldown checker stopped")
@@ -222,27 +680,85 @@ func (p *IPPool) StopCooldownChecker() {
 	}
 }
 
+// cooldownWait는 reason으로 비활성화된 프록시가 쿨다운 체커에 의해 재활성화되기까지의 대기 시간을 반환합니다.
+// 할당량, 격리, drain 사유이거나 해당 쿨다운이 꺼져 있으면 false를 반환합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) cooldownWait(reason string) (time.Duration, bool) {
+	var wait time.Duration
+	switch reason {
+	case DisabledReasonQuota, DisabledReasonQuarantine, DisabledReasonDrained, DisabledReasonMaintenance, DisabledReasonExpired:
+		return 0, false
+	case DisabledReasonBlocked:
+		// Hard bans sit out a longer, separate cooldown
+		wait = time.Duration(p.config.BlockCooldownMinutes) * time.Minute
+	default:
+		wait = time.Duration(p.config.CooldownMinutes) * time.Minute
+	}
+	return wait, wait > 0
+}
+
+// ProxyView는 API 응답용 프록시 표현입니다. 저장하지 않고 조회 시점에 계산하는 필드를 덧붙이므로
+// 상태 파일에 쓰이거나 요청 본문에서 받아들여지지 않습니다.
+type ProxyView struct {
+	*ProxyIP
+	CooldownRemaining  int64 `json:"cooldownRemainingSeconds"` // seconds until auto re-enable; 0 when enabled, -1 when no cooldown applies
+	Expired            bool  `json:"expired"`                  // expiresAt has passed
+	OutsideActiveHours bool  `json:"outsideActiveHours"`       // activeHours is set and now is outside it
+}
+
+// viewLocked는 now 기준의 파생 필드로 proxy의 응답 표현을 만들고, 시간에 따라 회복되는 점수도 다시 계산합니다.
+// 점수는 원자적으로 기록하므로 읽기 잠금만으로 호출할 수 있습니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) viewLocked(proxy *ProxyIP, now time.Time) ProxyView {
+	p.updateScore(proxy, now)
+	return ProxyView{
+		ProxyIP:            proxy,
+		CooldownRemaining:  p.cooldownRemaining(proxy, now),
+		Expired:            proxy.expired(now),
+		OutsideActiveHours: proxy.outsideActiveHours(now),
+	}
+}
+
+// cooldownRemaining은 쿨다운 체커가 프록시를 재활성화하기까지 남은 초를 반환합니다.
+// 활성 프록시는 0, 쿨다운으로 재활성화되지 않는 프록시는 -1입니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) cooldownRemaining(proxy *ProxyIP, now time.Time) int64 {
+	if proxy.Enabled {
+		return 0
+	}
+	wait, ok := p.cooldownWait(proxy.DisabledReason)
+	if !ok || proxy.Removed || proxy.DisabledAt.IsZero() {
+		return -1
+	}
+	remaining := proxy.DisabledAt.Add(wait).Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	// Round up so a proxy with time left never reads as 0
+	return int64((remaining + time.Second - 1) / time.Second)
+}
+
 // checkAndReenableProxies는 비활성화된 프록시의 쿨다운 만료 여부를 확인하고 재활성화합니다.
 func (p *IPPool) checkAndReenableProxies() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.config.CooldownMinutes <= 0 {
-		return
-	}
-
-	cooldownDuration := time.Duration(p.config.CooldownMinutes) * time.Minute
 	now := time.Now()
 
 	for id, proxy := range p.proxies {
-		if !proxy.Enabled && !proxy.DisabledAt.IsZero() {
-			if now.Sub(proxy.DisabledAt) >= cooldownDuration {
-				proxy.Enabled = true
-				proxy.FailCount = 0 // Reset fail count on re-enable
-				proxy.DisabledAt = time.Time{}
-				log.Printf("[IP-ROTATION] Proxy re-enabled after cooldown: id=%s addr=%s", id, proxy.Address)
-			}
+		if proxy.Enabled || proxy.DisabledAt.IsZero() || proxy.Removed {
+			continue
+		}
+		wait, ok := p.cooldownWait(proxy.DisabledReason)
+		if !ok || now.Sub(proxy.DisabledAt) < wait {
+			continue
 		}
+		proxy.Enabled = true
+		proxy.FailCount.Store(0) // Reset fail count on re-enable
+		proxy.DisabledAt = time.Time{}
+		proxy.DisabledReason = ""
+		cycles := proxy.DisableCycles.Add(1)
+		p.metrics.cooldownEnables.Add(1)
+		p.recordEvent(id, EventEnabled, "cooldown expired", 0)
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Proxy re-enabled after cooldown: id=%s addr=%s cycles=%d", id, proxy.Address, cycles)
 	}
 }
 
@@ -259,15 +775,22 @@ func (p *IPPool) StartHealthChecker() {
 		interval = 300 // default 5 minutes
 	}
 	p.healthCheckTicker = time.NewTicker(time.Duration(interval) * time.Second)
+	stop := p.stopHealthCheck
 	p.mu.Unlock()
 
+	// Each sweep spreads its checks across the interval, so the aggregate rate stays one sweep per interval
+	spread := time.Duration(interval) * time.Second
+
 	go func() {
 		log.Printf("[IP-ROTATION] Health checker started (interval=%d seconds)", interval)
+		// Start the first sweep right away instead of waiting a full interval after boot.
+		// Sweeps run off the ticker goroutine so a tick during a slow sweep is skipped rather than queued.
+		go p.runHealthChecks(spread, stop)
 		for {
 			select {
 			case <-p.healthCheckTicker.C:
-				p.runHealthChecks()
-			case <-p.stopHealthCheck:
+				go p.runHealthChecks(spread, stop)
+			case <-stop:
 				p.healthCheckTicker.Stop()
 				log.Printf("[IP-ROTATION] Health checker stopped")
 				return
@@ -288,120 +811,731 @@ func (p *IPPool) StopHealthChecker() {
 }
 
 // runHealthChecks는 활성화된 프록시들에 대해 병렬 헬스체크를 수행하고 상태를 업데이트합니다.
-func (p *IPPool) runHealthChecks() {
+// spread가 0보다 크면 각 프록시의 점검 시작 시점을 [0, spread) 구간에 무작위로 분산하여
+// 모든 점검이 같은 순간에 몰리지 않도록 하고, 재시도도 spread 안에 끝나도록 제한합니다.
+// stop이 닫히면 아직 시작하지 않은 점검과 재시도 대기 중인 점검은 건너뜁니다.
+// 이전 점검 주기가 아직 끝나지 않았으면(느린 프록시, 수동 트리거와 겹침) 이번 주기는 건너뜁니다.
+func (p *IPPool) runHealthChecks(spread time.Duration, stop <-chan struct{}) {
+	if !p.healthSweeping.CompareAndSwap(false, true) {
+		log.Printf("[IP-ROTATION] Health check skipped: previous sweep still running")
+		return
+	}
+	defer p.healthSweeping.Store(false)
+
 	p.mu.RLock()
 	proxiesToCheck := make([]*ProxyIP, 0)
+	timeouts := make([]time.Duration, 0)
+	geoDue := make([]bool, 0)
+	protocols := make([]string, 0)
+	checkUDP := make([]bool, 0)
+	now := time.Now()
 	for _, proxy := range p.proxies {
-		if proxy.Enabled {
+		// Proxies opted out of health checks are judged by real-request stats only
+		if proxy.Enabled && proxy.healthCheckEnabled() {
 			proxiesToCheck = append(proxiesToCheck, proxy)
+			timeouts = append(timeouts, p.healthCheckTimeout(proxy))
+			geoDue = append(geoDue, geoVerifyDue(proxy, now))
+			// PATCH rewrites these under the lock while the checks run outside it
+			protocols = append(protocols, proxy.Protocol)
+			checkUDP = append(checkUDP, proxy.SupportsUDP && proxy.Protocol == "socks5")
 		}
 	}
 	timeout := p.config.HealthCheckTimeout
 	if timeout <= 0 {
 		timeout = 10
 	}
+	checkURL := p.config.HealthCheckURL
+	retries := p.config.HealthCheckRetries
+	udpTarget := p.config.UDPCheckTarget
+	anonymityURL := p.config.AnonymityCheckURL
+	originIP := p.config.OriginIP
+	exitIPURL := p.config.ExitIPCheckURL
+	keepAliveCheck := p.config.KeepAliveCheck && checkURL != ""
+	websocketURL := p.config.WebSocketCheckURL
+	geoURL := p.config.GeoVerifyURL
+	if p.config.UpstreamProxy != "" {
+		// Datagrams can't be tunneled through the upstream CONNECT proxy, so only the UDP handshake is checked
+		udpTarget = ""
+	}
 	p.mu.RUnlock()
 
+	if anonymityURL != "" && originIP == "" && exitIPURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+		originIP = discoverOriginIP(ctx, p.upstreamDial(), exitIPURL)
+		cancel()
+	}
+
+	// A scheduled sweep's retries end before the next tick instead of making it skip
+	var deadline time.Time
+	if spread > 0 {
+		deadline = now.Add(spread)
+	}
+
 	var wg sync.WaitGroup
-	for _, proxy := range proxiesToCheck {
+	for i, proxy := range proxiesToCheck {
 		wg.Add(1)
-		go func(px *ProxyIP) {
+		go func(px *ProxyIP, checkTimeout time.Duration, verifyGeo bool, protocol string, udp bool) {
 			defer wg.Done()
-			healthy := p.checkProxyHealth(px, time.Duration(timeout)*time.Second)
+			if spread > 0 {
+				jitter := time.NewTimer(time.Duration(secureRandomInt(int(spread/time.Millisecond))) * time.Millisecond)
+				defer jitter.Stop()
+				select {
+				case <-jitter.C:
+				case <-stop:
+					return
+				}
+			}
+			healthy, latency, stopped := p.checkProxyHealth(px, checkURL, checkTimeout, retries, deadline, stop)
+			if stopped {
+				return
+			}
+			udpStatus := ""
+			if udp {
+				udpStatus = "unhealthy"
+				if p.checkProxyUDP(px, udpTarget, checkTimeout) {
+					udpStatus = "healthy"
+				}
+			}
+			anonymity := ""
+			if healthy && anonymityURL != "" && protocol != "socks4" {
+				ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+				level, err := p.checkAnonymity(ctx, px, anonymityURL, originIP)
+				cancel()
+				if err != nil {
+					log.Printf("[IP-ROTATION] Anonymity check failed for %s: %v", px.ID, err)
+				}
+				anonymity = level
+			}
+			keepAlive := ""
+			if healthy && keepAliveCheck && protocol != "socks4" {
+				keepAlive = p.checkProxyKeepAlive(px, checkURL, checkTimeout)
+			}
+			websocket, websocketChecked := false, false
+			if healthy && websocketURL != "" && protocol != "socks4" {
+				websocket, websocketChecked = p.checkProxyWebSocket(px, websocketURL, checkTimeout)
+			}
+			var geo *geoLocation
+			if healthy && verifyGeo && geoURL != "" && protocol != "socks4" {
+				ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+				located, err := p.lookupGeo(ctx, px, geoURL)
+				cancel()
+				if err != nil {
+					log.Printf("[IP-ROTATION] Geolocation check failed for %s: %v", px.ID, err)
+				}
+				geo = located
+			}
 			p.mu.Lock()
 			px.LastHealthCheck = time.Now()
-			if healthy {
-				px.HealthStatus = "healthy"
-			} else {
-				px.HealthStatus = "unhealthy"
+			p.applyHealthResult(px, healthy, latency)
+			if udpStatus != "" {
+				px.UDPStatus = udpStatus
+			}
+			if anonymity != "" && anonymity != px.AnonymityLevel {
+				if anonymity == AnonymityTransparent {
+					log.Printf("[IP-ROTATION] Proxy leaks origin IP: id=%s addr=%s", px.ID, px.Address)
+				}
+				log.Printf("[IP-ROTATION] Anonymity level changed: id=%s %q -> %s", px.ID, px.AnonymityLevel, anonymity)
+				px.AnonymityLevel = anonymity
+				p.invalidateWeights()
+			}
+			if keepAlive != "" && keepAlive != px.KeepAliveStatus {
+				log.Printf("[IP-ROTATION] Keep-alive status changed: id=%s %q -> %s", px.ID, px.KeepAliveStatus, keepAlive)
+				px.KeepAliveStatus = keepAlive
+				p.invalidateWeights()
+			}
+			if websocketChecked && websocket != px.SupportsWebSocket {
+				log.Printf("[IP-ROTATION] WebSocket support changed: id=%s %v -> %v", px.ID, px.SupportsWebSocket, websocket)
+				px.SupportsWebSocket = websocket
+				p.invalidateWeights()
+			}
+			if geo != nil {
+				p.applyGeoLocked(px, geo, px.LastHealthCheck)
 			}
 			p.mu.Unlock()
-		}(proxy)
+		}(proxy, timeouts[i], geoDue[i], protocols[i], checkUDP[i])
 	}
 	wg.Wait()
 	log.Printf("[IP-ROTATION] Health check completed for %d proxies", len(proxiesToCheck))
 }
 
-// checkProxyHealth는 프록시 호스트에 TCP 연결을 시도하여 도달 가능 여부를 반환합니다.
-func (p *IPPool) checkProxyHealth(proxy *ProxyIP, timeout time.Duration) bool {
+// healthCheckTimeout은 프록시의 점검 제한 시간을 반환합니다. 프록시별 TimeoutMs가 HealthCheckTimeout보다 우선합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) healthCheckTimeout(proxy *ProxyIP) time.Duration {
+	if proxy.TimeoutMs > 0 {
+		return time.Duration(proxy.TimeoutMs) * time.Millisecond
+	}
+	if p.config.HealthCheckTimeout > 0 {
+		return time.Duration(p.config.HealthCheckTimeout) * time.Second
+	}
+	return 10 * time.Second
+}
+
+// applyHealthResult는 헬스체크 결과를 히스테리시스를 적용해 HealthStatus에 반영합니다.
+// unhealthy→healthy 전환에는 HealthyThreshold회, healthy→unhealthy 전환에는 UnhealthyThreshold회의
+// 연속 결과가 필요합니다. 상태가 unknown이면 첫 결과를 바로 반영합니다. healthy 상태에서 성공한 점검이
+// DegradedLatencyMs보다 느리면 degraded로 분류합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) applyHealthResult(proxy *ProxyIP, healthy bool, latency time.Duration) {
+	healthyThreshold := p.config.HealthyThreshold
+	if healthyThreshold <= 0 {
+		healthyThreshold = 1
+	}
+	unhealthyThreshold := p.config.UnhealthyThreshold
+	if unhealthyThreshold <= 0 {
+		unhealthyThreshold = 1
+	}
+
+	if healthy {
+		proxy.ConsecutiveHealthy++
+		proxy.ConsecutiveUnhealthy = 0
+	} else {
+		proxy.ConsecutiveUnhealthy++
+		proxy.ConsecutiveHealthy = 0
+	}
+
+	previous := proxy.HealthStatus
+	// Degraded counts as healthy for the hysteresis
+	status := previous
+	if status == HealthStatusDegraded {
+		status = "healthy"
+	}
+	switch {
+	case status != "healthy" && status != "unhealthy":
+		if healthy {
+			status = "healthy"
+		} else {
+			status = "unhealthy"
+		}
+	case status == "unhealthy" && proxy.ConsecutiveHealthy >= healthyThreshold:
+		status = "healthy"
+	case status == "healthy" && proxy.ConsecutiveUnhealthy >= unhealthyThreshold:
+		status = "unhealthy"
+	}
+	if status == "healthy" {
+		switch {
+		case healthy && p.slowHealthCheck(latency):
+			status = HealthStatusDegraded
+		case !healthy && previous == HealthStatusDegraded:
+			// A failure below the unhealthy threshold says nothing about latency
+			status = HealthStatusDegraded
+		}
+	}
+	proxy.HealthStatus = status
+
+	switch proxy.HealthStatus {
+	case "healthy", HealthStatusDegraded:
+		proxy.UnhealthySince.Store(time.Time{})
+	case "unhealthy":
+		markUnhealthyStreak(proxy)
+	}
+
+	if proxy.HealthStatus != previous {
+		// Health status decides the usable priority tier
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Health status changed: id=%s %s -> %s", proxy.ID, previous, proxy.HealthStatus)
+	}
+}
+
+// errNoProxyHost는 프록시 주소에서 host:port를 얻을 수 없을 때의 오류입니다.
+var errNoProxyHost = errors.New("proxy address has no host")
+
+// 헬스체크 재시도 관련 한도입니다.
+const (
+	maxHealthCheckRetries   = 5                      // upper bound for healthCheckRetries
+	healthCheckRetryBackoff = 500 * time.Millisecond // wait before retry n is n times this
+)
+
+// checkProxyHealth는 프록시 가용성을 점검합니다. checkURL이 설정되어 있으면 프록시를 통해 HTTP 요청을 수행하고,
+// 그렇지 않으면 프록시 호스트에 TCP 연결만 시도합니다. 각 시도는 timeout 이내로 제한되며, 점검에 걸린 시간을 함께 반환합니다.
+// 실패하면 짧은 대기 후 최대 retries번 다시 시도하고, 모든 시도가 실패해야 실패로 판정합니다. 첫 시도가 성공하면 추가 비용은 없습니다.
+// deadline이 주어지면(다음 점검 주기 시작 시각) 그 전에 끝낼 수 없는 재시도는 하지 않습니다.
+// 재시도 대기 중 stop이 닫히면 판정 없이 stopped=true를 반환합니다.
+func (p *IPPool) checkProxyHealth(proxy *ProxyIP, checkURL string, timeout time.Duration, retries int,
+	deadline time.Time, stop <-chan struct{}) (healthy bool, latency time.Duration, stopped bool) {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt) * healthCheckRetryBackoff
+			// Retries must not run into the next sweep, which would skip it
+			if !deadline.IsZero() && time.Now().Add(backoff+timeout).After(deadline) {
+				log.Printf("[IP-ROTATION] Health check attempt %d/%d failed for %s: %v; no time left before the next sweep",
+					attempt, retries+1, proxy.ID, err)
+				break
+			}
+			log.Printf("[IP-ROTATION] Health check attempt %d/%d failed for %s: %v; retrying", attempt, retries+1, proxy.ID, err)
+			wait := time.NewTimer(backoff)
+			select {
+			case <-wait.C:
+			case <-stop:
+				wait.Stop()
+				return false, latency, true
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		began := time.Now()
+		err = p.probeProxyHealth(ctx, proxy, checkURL)
+		latency = time.Since(began)
+		cancel()
+		if err == nil {
+			return true, latency, false
+		}
+	}
+	log.Printf("[IP-ROTATION] Health check failed for %s: %v", proxy.ID, err)
+	return false, latency, false
+}
+
+// probeProxyHealth는 checkProxyHealth의 점검 본체로, 실패 원인을 오류로 반환합니다. 점검은 ctx 데드라인으로 제한됩니다.
+func (p *IPPool) probeProxyHealth(ctx context.Context, proxy *ProxyIP, checkURL string) error {
+	p.mu.RLock()
 	proxyURL, err := proxy.GetProxyURL()
+	header := proxy.ProxyHeader()
+	protocol := proxy.Protocol
+	dialAddr := ""
+	if err == nil {
+		dialAddr = p.dialAddress(proxy, proxyURL.Host)
+	}
+	upstream := p.config.UpstreamProxy
+	method := healthCheckMethod(p.config.HealthCheckMethod)
+	p.mu.RUnlock()
 	if err != nil {
-		return false
+		return err
 	}
 
 	// Extract host:port from proxy URL
-	host := proxyURL.Host
-	if host == "" {
-		return false
+	if proxyURL.Host == "" {
+		return errNoProxyHost
+	}
+	dial := newDialFunc(upstream)
+
+	// net/http has no socks4 support, so those proxies only get the TCP check
+	if checkURL != "" && protocol != "socks4" {
+		if method == http.MethodConnect && (proxyURL.Scheme == "http" || proxyURL.Scheme == "https") {
+			if err := checkProxyConnect(ctx, dial, proxyURL, header, checkURL); err != nil {
+				return fmt.Errorf("connect check: %w", err)
+			}
+			return nil
+		}
+		// SOCKS proxies always tunnel, so CONNECT mode falls back to HEAD for them
+		if method == http.MethodConnect {
+			method = http.MethodHead
+		}
+		// Reuse the proxy's connection from the previous check when it is still open
+		transport := p.healthTransports.get(proxy.ID, proxyURL, header, upstream)
+		if err := checkProxyHTTP(ctx, transport, header, method, checkURL); err != nil {
+			return fmt.Errorf("http check: %w", err)
+		}
+		return nil
 	}
 
-	conn, err := net.DialTimeout("tcp", host, timeout)
+	conn, err := dial(ctx, "tcp", dialAddr)
 	if err != nil {
-		log.Printf("[IP-ROTATION] Health check failed for %s: %v", proxy.ID, err)
-		return false
+		return err
 	}
 	conn.Close()
+	return nil
+}
+
+// checkProxyKeepAlive는 프록시가 HTTP keep-alive(연결 재사용)를 지원하는지 timeout 이내로 점검하고
+// KeepAliveStatus 값을 반환합니다. 점검 자체가 실패하면 빈 문자열(판정 보류)을 반환합니다.
+func (p *IPPool) checkProxyKeepAlive(proxy *ProxyIP, checkURL string, timeout time.Duration) string {
+	proxyURL, header, err := p.proxyEndpoint(proxy)
+	if err != nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	reused, err := checkKeepAlive(ctx, p.upstreamDial(), proxyURL, header, checkURL)
+	if err != nil {
+		log.Printf("[IP-ROTATION] Keep-alive check failed for %s: %v", proxy.ID, err)
+		return ""
+	}
+	if !reused {
+		return KeepAliveUnsupported
+	}
+	return KeepAliveSupported
+}
+
+// checkProxyWebSocket은 프록시를 통한 WebSocket 업그레이드와 에코가 동작하는지 timeout 이내로 점검합니다.
+// 두 번째 반환값은 판정이 났는지 여부로, 점검 자체가 실패하면 false(판정 보류)입니다.
+func (p *IPPool) checkProxyWebSocket(proxy *ProxyIP, checkURL string, timeout time.Duration) (bool, bool) {
+	proxyURL, header, err := p.proxyEndpoint(proxy)
+	if err != nil {
+		return false, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	supported, err := checkWebSocket(ctx, p.upstreamDial(), proxyURL, header, checkURL)
+	if err != nil {
+		log.Printf("[IP-ROTATION] WebSocket check failed for %s: %v", proxy.ID, err)
+		return false, false
+	}
+	return supported, true
+}
+
+// checkProxyUDP는 SOCKS5 프록시의 UDP ASSOCIATE 및 UDP 릴레이 동작을 timeout 이내로 점검합니다.
+func (p *IPPool) checkProxyUDP(proxy *ProxyIP, target string, timeout time.Duration) bool {
+	p.mu.RLock()
+	proxyURL, err := proxy.GetProxyURL()
+	dialAddr := ""
+	if err == nil {
+		dialAddr = p.dialAddress(proxy, proxyURL.Host)
+	}
+	dial := newDialFunc(p.config.UpstreamProxy)
+	p.mu.RUnlock()
+	if err != nil || proxyURL.Host == "" {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	username := proxyURL.User.Username()
+	password, _ := proxyURL.User.Password()
+	if err := checkSOCKS5UDP(ctx, dial, username, password, dialAddr, target); err != nil {
+		log.Printf("[IP-ROTATION] UDP health check failed for %s: %v", proxy.ID, err)
+		return false
+	}
 	return true
 }
 
+// checkProxyHTTP는 프록시용 transport로 checkURL에 method(GET 또는 HEAD) 요청을 보내고 응답 상태를 확인합니다.
+// ctx의 데드라인이 연결, TLS 핸드셰이크, 응답 헤더/본문 수신 전체에 적용됩니다.
+func checkProxyHTTP(ctx context.Context, transport *http.Transport, proxyHeader http.Header, method, checkURL string) error {
+	_, err := doThroughTransport(ctx, transport, proxyHeader, method, checkURL)
+	return err
+}
+
+// fetchThroughProxy는 프록시를 통해 target으로 GET 요청을 보내고 응답 본문(최대 64KiB)을 반환합니다.
+// proxyHeader는 HTTPS 대상의 CONNECT 요청과 평문 HTTP 요청 모두에 실립니다. 4xx/5xx 응답은 오류로 처리합니다.
+// 프록시(또는 proxyURL이 nil이면 target)로의 연결은 dial로 엽니다.
+func fetchThroughProxy(ctx context.Context, dial dialFunc, proxyURL *url.URL, proxyHeader http.Header, target string) ([]byte, error) {
+	return requestThroughProxy(ctx, dial, proxyURL, proxyHeader, http.MethodGet, target)
+}
+
+// requestThroughProxy는 fetchThroughProxy와 같지만 요청 메서드를 지정할 수 있습니다. HEAD 응답은 본문이 비어 있습니다.
+func requestThroughProxy(ctx context.Context, dial dialFunc, proxyURL *url.URL, proxyHeader http.Header, method, target string) ([]byte, error) {
+	transport := &http.Transport{
+		Proxy:              http.ProxyURL(proxyURL),
+		ProxyConnectHeader: proxyHeader,
+		DialContext:        dial,
+		DisableKeepAlives:  true,
+	}
+	defer transport.CloseIdleConnections()
+	return doThroughTransport(ctx, transport, proxyHeader, method, target)
+}
+
+// doThroughTransport는 transport로 target에 method 요청을 보내고 응답 본문(최대 64KiB)을 반환합니다.
+// 4xx/5xx 응답은 오류로 처리합니다. 본문을 끝까지 읽은 연결은 transport가 재사용할 수 있습니다.
+func doThroughTransport(ctx context.Context, transport *http.Transport, proxyHeader http.Header, method, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Plain-HTTP targets are sent to the proxy directly, so the proxy sees the request headers
+	if req.URL.Scheme == "http" {
+		for name, values := range proxyHeader {
+			req.Header[name] = values
+		}
+	}
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read a bounded amount of the body so a stalled body is also covered by ctx
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return body, nil
+}
+
 // RunHealthCheckNow는 즉시 헬스체크를 비동기로 트리거합니다.
 func (p *IPPool) RunHealthCheckNow() {
-	go p.runHealthChecks()
+	go p.runHealthChecks(0, nil)
 }
 
+// 프록시 선택 실패 시 반환되는 오류입니다. 대기(SelectionWaitTimeout) 대상 여부 판단에 사용됩니다.
+var (
+	ErrNoProxyAvailable = errors.New("no enabled proxies available")
+	ErrQuotaExhausted   = errors.New("all enabled proxies have exhausted their daily usage quota")
+)
+
+// selectionPollInterval은 사용 가능한 프록시를 기다리는 동안 재시도하는 간격입니다.
+const selectionPollInterval = 200 * time.Millisecond
+
 // GetNextProxy는 설정된 로테이션 전략에 따라 다음 프록시를 선택하고 사용 통계를 갱신합니다.
 func (p *IPPool) GetNextProxy() (*ProxyIP, error) {
+	return p.GetNextProxyContext(context.Background())
+}
+
+// GetNextProxyContext는 GetNextProxy와 같지만, 사용 가능한 프록시를 기다리는 동안 ctx 취소를 존중합니다.
+func (p *IPPool) GetNextProxyContext(ctx context.Context) (*ProxyIP, error) {
+	return p.GetNextProxyWithStrategy(ctx, "")
+}
+
+// SelectOptions는 단일 프록시 선택 요청에만 적용되는 옵션입니다. 빈 값은 풀 설정을 따릅니다.
+type SelectOptions struct {
+	Strategy   RotationStrategy // overrides config.Strategy for this selection only
+	TargetLat  *float64         // geographic: prefer proxies nearest to this point
+	TargetLon  *float64
+	Key        string // consistent_hash: routing key such as the target host
+	HighVolume bool   // skip proxies known to break keep-alive when others are available
+	WebSocket  bool   // only proxies verified to carry WebSocket upgrades
+	Country    string // only proxies in this country; also picks a countryStrategies override
+	Tag        string // only proxies with this tag; also picks a tagStrategies override
+	IPVersion  int    // 4 or 6: only proxies exiting over that IP family; 0 = any
+
+	exclude []*ProxyIP // never chosen; fallback selection passes the proxies already handed out
+}
+
+// GetNextProxyWithStrategy는 주어진 전략으로 한 번만 프록시를 선택합니다(config.Strategy는 변경하지 않음).
+// strategy가 비어 있으면 설정된 전략을 사용합니다.
+func (p *IPPool) GetNextProxyWithStrategy(ctx context.Context, strategy RotationStrategy) (*ProxyIP, error) {
+	return p.GetNextProxyWithOptions(ctx, SelectOptions{Strategy: strategy})
+}
+
+// GetNextProxyWithOptions는 요청별 옵션을 적용해 프록시를 선택합니다. SelectionWaitTimeout이 설정되어 있으면
+// 사용 가능한 프록시가 생길 때까지 해당 시간 또는 ctx가 끝날 때까지 대기합니다.
+func (p *IPPool) GetNextProxyWithOptions(ctx context.Context, opts SelectOptions) (*ProxyIP, error) {
+	if opts.Strategy != "" && !validStrategy(opts.Strategy) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStrategy, opts.Strategy)
+	}
+	if (opts.TargetLat == nil) != (opts.TargetLon == nil) {
+		return nil, errors.New("targetLat and targetLon must be set together")
+	}
+	if p.Paused() {
+		return nil, ErrServicePaused
+	}
+
+	p.mu.RLock()
+	wait := time.Duration(p.config.SelectionWaitTimeout) * time.Second
+	p.mu.RUnlock()
+
+	var deadline <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		proxy, err := p.tryNextProxy(opts)
+		if err == nil || wait <= 0 || !isSelectionUnavailable(err) {
+			return proxy, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, err
+		case <-time.After(selectionPollInterval):
+		}
+	}
+}
+
+// tryNextProxy는 대기 없이 한 번 프록시 선택을 시도합니다.
+// 잠금 대기를 포함한 소요 시간을 메트릭으로 기록합니다.
+func (p *IPPool) tryNextProxy(opts SelectOptions) (*ProxyIP, error) {
+	start := time.Now()
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	enabledProxies := p.getEnabledProxies()
-	if len(enabledProxies) == 0 {
-		return nil, errors.New("no enabled proxies available")
-	}
-
-	var selected *ProxyIP
-
-	switch p.config.Strategy {
-	case StrategyRoundRobin:
-		selected = p.selectRoundRobin(enabledProxies)
-	case StrategyRandom:
-		selected = p.selectRandom(enabledProxies)
-	case StrategyLeastUsed:
-		selected = p.selectLeastUsed(enabledProxies)
-	case StrategyWeighted:
-		selected = p.selectWeighted(enabledProxies)
-	case StrategyGeographic:
-		selected = p.selectGeographic(enabledProxies)
-	default:
-		selected = p.selectRoundRobin(enabledProxies)
+	strategy := p.strategyForLocked(opts)
+	defer p.observeSelection(strategy, start)
+
+	selected, err := p.pickProxyLocked(strategy, opts)
+	if err != nil {
+		return nil, err
 	}
+	// Evaluated before usage is recorded so both strategies see the same pool state
+	p.shadowSelectLocked(selected, strategy, opts)
 
 	if selected != nil {
-		selected.UsageCount++
-		selected.LastUsed = time.Now()
-		log.Printf("[IP-ROTATION] Selected proxy: id=%s addr=%s strategy=%s usage_count=%d",
-			selected.ID, selected.Address, p.config.Strategy, selected.UsageCount)
+		acquireActive(selected)
+		usage := p.markUsedLocked(selected, strategy)
+		log.Printf("[IP-ROTATION] Selected proxy: id=%s addr=%s strategy=%s priority=%d usage_count=%d",
+			selected.ID, selected.Address, strategy, selected.Priority, usage)
 	}
 
 	return selected, nil
 }
 
-// getEnabledProxies는 Enabled=true인 프록시 목록을 반환합니다.
+// markUsedLocked는 프록시 사용을 기록합니다(사용 횟수, 마지막 사용 시각, 할당량, 국가/네트워크 분산, 메트릭).
+// 새 사용 횟수를 반환합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) markUsedLocked(proxy *ProxyIP, strategy RotationStrategy) int64 {
+	usage := proxy.UsageCount.Add(1)
+	proxy.LastUsed = time.Now()
+	proxy.noteRequest(proxy.LastUsed)
+	if proxy.CaptchaCount.Load() > 0 {
+		// The CAPTCHA penalty is relative to usage, so this proxy's weight just changed
+		p.invalidateWeights()
+	}
+	p.consumeQuota(proxy)
+	p.countryUsage.record(proxy.Country)
+	p.noteNetworkLocked(proxy)
+	p.metrics.incSelection(strategy, proxy.ID)
+	return usage
+}
+
+// pickProxyLocked는 후보 필터링(활성/할당량/우선순위 티어)과 전략 적용만 수행하고 사용 통계는 갱신하지 않습니다.
+// 라운드로빈 커서는 전진합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) pickProxyLocked(strategy RotationStrategy, opts SelectOptions) (*ProxyIP, error) {
+	enabledProxies, err := p.candidatesLocked(strategy, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.selectorFor(strategy, opts).Select(enabledProxies), nil
+}
+
+// candidatesLocked는 전략이 고를 후보 목록(활성, 할당량, 백오프, eliteOnly, 우선순위 티어, 국가 목표 비율 반영)을 반환합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) candidatesLocked(strategy RotationStrategy, opts SelectOptions) ([]*ProxyIP, error) {
+	enabledProxies := p.getEnabledProxies()
+	if len(opts.exclude) > 0 {
+		enabledProxies = slices.DeleteFunc(enabledProxies, func(proxy *ProxyIP) bool {
+			return slices.Contains(opts.exclude, proxy)
+		})
+	}
+	if opts.Country != "" || opts.Tag != "" {
+		filter := ProxyFilter{Country: opts.Country, Tag: opts.Tag}
+		enabledProxies = slices.DeleteFunc(enabledProxies, func(proxy *ProxyIP) bool {
+			return !filter.Matches(proxy)
+		})
+	}
+	if len(enabledProxies) == 0 {
+		return nil, ErrNoProxyAvailable
+	}
+
+	// Some targets treat IPv6 clients differently, so jobs may pin the exit family
+	if opts.IPVersion != 0 {
+		enabledProxies = filterIPVersion(enabledProxies, opts.IPVersion)
+		if len(enabledProxies) == 0 {
+			return nil, fmt.Errorf("%w: IPv%d", ErrNoIPVersionProxy, opts.IPVersion)
+		}
+	}
+
+	// Skip proxies that have used up their daily quota
+	enabledProxies = p.filterUnderQuota(enabledProxies)
+	if len(enabledProxies) == 0 {
+		return nil, ErrQuotaExhausted
+	}
+
+	// Pace each IP like a human would, however much rotation pressure there is
+	enabledProxies = filterUnderRateLimit(enabledProxies)
+	if len(enabledProxies) == 0 {
+		return nil, ErrRateLimited
+	}
+
+	// Give proxies that just failed time to recover
+	enabledProxies = p.filterBackedOff(enabledProxies)
+
+	// Proxies that were never checked or that reveal a proxy hop are not eligible
+	if p.config.EliteOnly {
+		enabledProxies = filterElite(enabledProxies)
+		if len(enabledProxies) == 0 {
+			return nil, ErrNoEliteProxy
+		}
+	}
+
+	// WebSocket jobs fail outright on proxies that drop the upgrade
+	if opts.WebSocket {
+		enabledProxies = filterWebSocket(enabledProxies)
+		if len(enabledProxies) == 0 {
+			return nil, ErrNoWebSocketProxy
+		}
+	}
+
+	// High-volume jobs pay a fresh handshake per request on proxies without keep-alive
+	if opts.HighVolume {
+		enabledProxies = preferKeepAlive(enabledProxies)
+	}
+
+	// Strategies only see the highest-priority tier that has usable proxies
+	enabledProxies = selectPriorityTier(enabledProxies)
+
+	// Slow-but-alive proxies are a fallback within the tier
+	enabledProxies = preferNonDegraded(enabledProxies)
+
+	// Spread consecutive selections across networks so one block doesn't hit them all
+	enabledProxies = p.preferOtherNetworks(enabledProxies)
+
+	// Steer toward countries below their target share; explicit routing keys and coordinates take precedence
+	if strategy != StrategyConsistentHash && opts.TargetLat == nil {
+		enabledProxies = p.preferUnderservedCountry(enabledProxies)
+	}
+	return enabledProxies, nil
+}
+
+// observeSelection은 선택 소요 시간을 기록하고, SlowSelectionMs를 넘으면 경고 로그를 남깁니다.
+// 호출자는 p.mu 잠금을 보유해야 합니다.
+func (p *IPPool) observeSelection(strategy RotationStrategy, start time.Time) {
+	elapsed := time.Since(start)
+	threshold := time.Duration(p.config.SlowSelectionMs) * time.Millisecond
+	slow := threshold > 0 && elapsed >= threshold
+	p.metrics.observeSelection(strategy, elapsed, slow)
+	if slow {
+		log.Printf("[IP-ROTATION] Slow proxy selection: strategy=%s took=%s pool_size=%d threshold=%s",
+			strategy, elapsed, len(p.proxies), threshold)
+	}
+}
+
+// getEnabledProxies는 Enabled=true이고 soft-removed, drain 중, 기한 만료, 활성 시간대(ActiveHours) 밖이 아닌 프록시 목록을 반환합니다.
+// 만료된 프록시는 정리 루틴이 비활성화하기 전에도 제외됩니다.
 func (p *IPPool) getEnabledProxies() []*ProxyIP {
+	now := time.Now()
 	var enabled []*ProxyIP
 	for _, proxy := range p.proxies {
-		if proxy.Enabled {
+		if proxy.Enabled && !proxy.Removed && !proxy.Draining && !proxy.expired(now) && !proxy.outsideActiveHours(now) {
 			enabled = append(enabled, proxy)
 		}
 	}
 	return enabled
 }
 
-// selectRoundRobin은 라운드로빈 순서(order)를 기준으로 다음 사용 가능한 프록시를 선택합니다.
+// selectPriorityTier는 사용 가능한(unhealthy가 아닌) 프록시가 있는 가장 높은 우선순위 티어의 프록시 목록을 반환합니다.
+// 모든 티어에 사용 가능한 프록시가 없으면 입력 목록을 그대로 반환합니다.
+func selectPriorityTier(proxies []*ProxyIP) []*ProxyIP {
+	var tier []*ProxyIP
+	bestPriority := 0
+	for _, proxy := range proxies {
+		if proxy.HealthStatus == "unhealthy" {
+			continue
+		}
+		switch {
+		case tier == nil || proxy.Priority > bestPriority:
+			tier = []*ProxyIP{proxy}
+			bestPriority = proxy.Priority
+		case proxy.Priority == bestPriority:
+			tier = append(tier, proxy)
+		}
+	}
+	if len(tier) == 0 {
+		return proxies
+	}
+	return tier
+}
+
+// selectRoundRobin은 라운드로빈 순서(order)를 기준으로 후보 목록에 포함된 다음 프록시를 선택합니다.
 func (p *IPPool) selectRoundRobin(proxies []*ProxyIP) *ProxyIP {
 	if len(proxies) == 0 {
 		return nil
 	}
+	candidates := make(map[string]bool, len(proxies))
+	for _, proxy := range proxies {
+		candidates[proxy.ID] = true
+	}
 	// Find valid index
 	if p.index >= len(p.order) {
 		p.index = 0
@@ -415,7 +1549,7 @@ func (p *IPPool) selectRoundRobin(proxies []*ProxyIP) *ProxyIP {
 		}
 		id := p.order[p.index]
 		p.index++
-		if proxy, ok := p.proxies[id]; ok && proxy.Enabled {
+		if proxy, ok := p.proxies[id]; ok && candidates[id] {
 			return proxy
 		}
 		attempts++
@@ -456,51 +1590,29 @@ func (p *IPPool) selectLeastUsed(proxies []*ProxyIP) *ProxyIP {
 		return nil
 	}
 	min := proxies[0]
+	minUsage := min.UsageCount.Load()
 	for _, proxy := range proxies[1:] {
-		if proxy.UsageCount < min.UsageCount {
-			min = proxy
+		if usage := proxy.UsageCount.Load(); usage < minUsage {
+			min, minUsage = proxy, usage
 		}
 	}
 	return min
 }
 
 // selectWeighted는 성공률과 CAPTCHA 패널티 기반 가중치 랜덤 선택으로 프록시를 선택합니다.
+// 누적 가중치는 풀이 변경될 때까지 캐시되므로, 정상 상태에서는 이진 탐색만 수행합니다.
 func (p *IPPool) selectWeighted(proxies []*ProxyIP) *ProxyIP {
 	if len(proxies) == 0 {
 		return nil
 	}
 
-	// Calculate weights based on success rate
-	// Use a minimum weight to give all proxies some chance
-	const minWeight = 10.0
-	weights := make([]float64, len(proxies))
-	totalWeight := 0.0
-
-	for i, proxy := range proxies {
-		total := proxy.SuccessCount + proxy.FailCount
-		var baseWeight float64
-		if total == 0 {
-			// New proxy gets a neutral weight (50% success assumed + exploration bonus)
-			baseWeight = 50.0 + minWeight
-		} else {
-			rate := float64(proxy.SuccessCount) / float64(total) * 100
-			baseWeight = rate + minWeight
-		}
-
-		captchaRate := float64(proxy.CaptchaCount) / float64(proxy.UsageCount+1)
-		captchaPenalty := 1.0 - (captchaRate * 0.7)
-		if captchaPenalty < 0.1 {
-			captchaPenalty = 0.1
-		}
-
-		weight := baseWeight * captchaPenalty
-		if weight < minWeight {
-			weight = minWeight
-		}
-		weights[i] = weight
-		totalWeight += weight
+	// Occasionally ignore weights so low-scoring proxies still get retried
+	if p.explore() {
+		return p.selectRandom(proxies)
 	}
 
+	weights := p.cachedWeights(proxies)
+	totalWeight := weights.total()
 	if totalWeight <= 0 {
 		return proxies[secureRandomInt(len(proxies))]
 	}
@@ -514,131 +1626,312 @@ func (p *IPPool) selectWeighted(proxies []*ProxyIP) *ProxyIP {
 	randVal := float64(randN.Int64()) / 1000.0
 
 	// Select based on cumulative weight
-	cumulative := 0.0
-	for i, weight := range weights {
-		cumulative += weight
-		if randVal < cumulative {
-			return proxies[i]
-		}
+	return weights.pick(randVal)
+}
+
+// selectionWeights는 weighted 전략의 신규 프록시 가중치와 최소 가중치를 반환합니다. 0이면 기본값을 사용합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) selectionWeights() (newWeight, minWeight float64) {
+	newWeight, minWeight = p.config.NewProxyWeight, p.config.MinWeight
+	if newWeight <= 0 {
+		newWeight = defaultNewProxyWeight
+	}
+	if minWeight <= 0 {
+		minWeight = defaultMinWeight
 	}
+	return newWeight, minWeight
+}
+
+// proxyWeight는 캐시된 점수(Score)에 워밍업 진행도를 반영한 프록시의 선택 가중치를 계산합니다.
+// minWeight는 모든 프록시에 주는 최소 가중치(탐색 보너스)입니다.
+func proxyWeight(proxy *ProxyIP, minWeight float64) float64 {
+	// Proxies still warming up get a reduced share that ramps up with proven results
+	return (proxy.Score.Load() + minWeight) * warmupFactor(proxy)
+}
+
+// warmupFactor는 워밍업 진행도에 따른 가중치 배율을 반환합니다(신규 10%에서 완료 시 100%까지 선형 증가).
+func warmupFactor(proxy *ProxyIP) float64 {
+	const minShare = 0.1
+	return minShare + (1-minShare)*proxy.WarmupProgress.Load()
+}
 
-	// Fallback to last proxy
-	return proxies[len(proxies)-1]
+// updateWarmup은 기록된 성공/실패 수를 기준으로 프록시의 워밍업 진행도를 갱신합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) updateWarmup(proxy *ProxyIP) {
+	if p.config.WarmupRequests <= 0 {
+		proxy.WarmupProgress.Store(1)
+		return
+	}
+	progress := float64(proxy.SuccessCount.Load()+proxy.FailCount.Load()) / float64(p.config.WarmupRequests)
+	if progress > 1 {
+		progress = 1
+	}
+	proxy.WarmupProgress.Store(progress)
 }
 
-// selectGeographic은 선호 국가 설정이 있으면 해당 국가 프록시를 우선 선택하고, 없으면 라운드로빈으로 폴백합니다.
-func (p *IPPool) selectGeographic(proxies []*ProxyIP) *ProxyIP {
+// selectGeographic은 대상 좌표가 주어지면 가장 가까운 프록시를, 그렇지 않으면 선호 국가 프록시를 우선 선택하고,
+// 둘 다 해당하지 않으면 라운드로빈으로 폴백합니다.
+func (p *IPPool) selectGeographic(proxies []*ProxyIP, opts SelectOptions) *ProxyIP {
 	if len(proxies) == 0 {
 		return nil
 	}
-	// Prefer proxies matching configured country
-	if p.config.PreferredCountry != "" {
-		var matchingProxies []*ProxyIP
-		for _, proxy := range proxies {
-			if strings.EqualFold(proxy.Country, p.config.PreferredCountry) {
-				matchingProxies = append(matchingProxies, proxy)
-			}
-		}
-		if len(matchingProxies) > 0 {
-			// Use round-robin among matching proxies
-			return matchingProxies[secureRandomInt(len(matchingProxies))]
+	// Prefer the nearest proxies when a target point is given
+	if opts.TargetLat != nil && opts.TargetLon != nil {
+		if nearest := selectNearest(proxies, *opts.TargetLat, *opts.TargetLon); nearest != nil {
+			return nearest
 		}
 	}
+	// Prefer proxies matching configured country
+	if matchingProxies := preferredCountryProxies(proxies, p.config.PreferredCountry); len(matchingProxies) > 0 {
+		return matchingProxies[secureRandomInt(len(matchingProxies))]
+	}
 	// Fallback to round-robin
 	return p.selectRoundRobin(proxies)
 }
 
 // RecordSuccess는 특정 프록시의 성공 결과와 평균 지연시간을 기록합니다.
+// 카운터가 원자적이므로 읽기 잠금만 사용하여 동시 기록이 서로를 직렬화하지 않습니다.
 func (p *IPPool) RecordSuccess(proxyID string, latencyMs int64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.mu.RLock()
+	proxy, ok := p.proxies[proxyID]
+	if !ok {
+		p.mu.RUnlock()
+		return
+	}
+	success := proxy.SuccessCount.Add(1)
+	proxy.UnhealthySince.Store(time.Time{})
+	recordLatency(proxy, latencyMs)
+	if latencyMs > 0 {
+		p.metrics.observeLatency(proxyID, latencyMs)
+	}
+	p.updateWarmup(proxy)
+	p.updateScore(proxy, time.Now())
+	p.invalidateWeights()
+	p.recordEvent(proxyID, EventSuccess, "", latencyMs)
+	log.Printf("[IP-ROTATION] Success recorded: id=%s success=%d fail=%d latency=%dms",
+		proxyID, success, proxy.FailCount.Load(), latencyMs)
+	drained := releaseActive(proxy) == 0 && proxy.Draining
+	p.mu.RUnlock()
 
-	if proxy, ok := p.proxies[proxyID]; ok {
-		proxy.SuccessCount++
-		// Update average latency
-		total := proxy.SuccessCount + proxy.FailCount
-		if total > 0 {
-			proxy.AvgLatencyMs = (proxy.AvgLatencyMs*(total-1) + latencyMs) / total
+	if drained {
+		p.finishDrain(proxyID)
+	}
+}
+
+// recordLatency는 성공/실패 총합을 기준으로 평균 지연시간을 갱신합니다. 동시 갱신은 CAS로 조정합니다.
+func recordLatency(proxy *ProxyIP, latencyMs int64) {
+	total := proxy.SuccessCount.Load() + proxy.FailCount.Load()
+	if total <= 0 {
+		return
+	}
+	for {
+		old := proxy.AvgLatencyMs.Load()
+		if proxy.AvgLatencyMs.CompareAndSwap(old, (old*(total-1)+latencyMs)/total) {
+			return
 		}
-		log.Printf("[IP-ROTATION] Success recorded: id=%s success=%d fail=%d latency=%dms",
-			proxyID, proxy.SuccessCount, proxy.FailCount, latencyMs)
 	}
 }
 
 // RecordCaptcha는 특정 프록시에 CAPTCHA 발생을 기록하여 선택 가중치에 반영될 수 있도록 합니다.
 func (p *IPPool) RecordCaptcha(proxyID string, captchaType string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
 	if proxy, ok := p.proxies[proxyID]; ok {
-		proxy.CaptchaCount++
+		count := proxy.CaptchaCount.Add(1)
+		proxy.recentCaptchas.add(time.Now())
+		p.updateScore(proxy, time.Now())
+		p.invalidateWeights()
+		p.recordEvent(proxyID, EventCaptcha, captchaType, 0)
 		log.Printf("[IP-ROTATION] CAPTCHA recorded: id=%s count=%d type=%s",
-			proxyID, proxy.CaptchaCount, captchaType)
+			proxyID, count, captchaType)
 	}
 }
 
 // RecordFailure는 특정 프록시의 실패를 기록하고, 임계치 초과 시 자동으로 비활성화합니다.
+// 실패 기록은 읽기 잠금으로 처리하고, 비활성화가 필요한 경우에만 쓰기 잠금을 잡습니다.
 func (p *IPPool) RecordFailure(proxyID string, reason string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.mu.RLock()
+	proxy, ok := p.proxies[proxyID]
+	if !ok {
+		p.mu.RUnlock()
+		return
+	}
+	fails := proxy.FailCount.Add(1)
+	proxy.LastFailure.Store(time.Now())
+	p.updateWarmup(proxy)
+	p.updateScore(proxy, time.Now())
+	p.invalidateWeights()
+	p.recordEvent(proxyID, EventFailure, reason, 0)
+	log.Printf("[IP-ROTATION] Failure recorded: id=%s success=%d fail=%d reason=%s",
+		proxyID, proxy.SuccessCount.Load(), fails, reason)
+	maxFailures := p.config.MaxFailures
+	lowSuccess := p.belowSuccessFloor(proxy)
+	drained := releaseActive(proxy) == 0 && proxy.Draining
+	p.mu.RUnlock()
 
-	if proxy, ok := p.proxies[proxyID]; ok {
-		proxy.FailCount++
-		log.Printf("[IP-ROTATION] Failure recorded: id=%s success=%d fail=%d reason=%s",
-			proxyID, proxy.SuccessCount, proxy.FailCount, reason)
+	if drained {
+		p.finishDrain(proxyID)
+	}
+	if (maxFailures <= 0 || fails < int64(maxFailures)) && !lowSuccess {
+		return
+	}
 
-		// Auto-disable if too many failures
-		if p.config.MaxFailures > 0 && proxy.FailCount >= int64(p.config.MaxFailures) {
-			proxy.Enabled = false
-			proxy.DisabledAt = time.Now()
-			log.Printf("[IP-ROTATION] Proxy auto-disabled due to failures: id=%s (will re-enable after %d minutes)",
-				proxyID, p.config.CooldownMinutes)
-		}
+	// Auto-disable if too many failures or a chronically low success rate (re-check under the write lock)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	proxy, ok = p.proxies[proxyID]
+	if !ok || !proxy.Enabled {
+		return
+	}
+	maxed := p.config.MaxFailures > 0 && proxy.FailCount.Load() >= int64(p.config.MaxFailures)
+	if !maxed && !p.belowSuccessFloor(proxy) {
+		return
+	}
+	if !p.autoDisableAllowedLocked(proxy) {
+		return
+	}
+	if maxed {
+		proxy.Enabled = false
+		proxy.DisabledAt = time.Now()
+		proxy.DisabledReason = DisabledReasonMaxFailures
+		markUnhealthyStreak(proxy)
+		p.metrics.autoDisables.Add(1)
+		p.recordEvent(proxyID, EventDisabled, "max failures reached", 0)
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Proxy auto-disabled due to failures: id=%s (will re-enable after %d minutes)",
+			proxyID, p.config.CooldownMinutes)
+	} else {
+		p.disableLowSuccessLocked(proxy)
 	}
 }
 
 // AddProxy는 프록시를 풀에 추가하고 형식/프로토콜을 검증한 뒤 기본값을 설정합니다.
-func (p *IPPool) AddProxy(proxy *ProxyIP) error {
+// 풀에 저장된 프록시를 반환합니다(soft-removed 프록시가 복원된 경우 기존 항목). 같은 ID의 프록시가 이미 있으면
+// 덮어쓰지 않고 ErrProxyExists를 반환합니다. soft-removed 항목은 새 프록시로 대체됩니다.
+func (p *IPPool) AddProxy(proxy *ProxyIP) (*ProxyIP, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	return p.addProxyLocked(proxy)
+}
 
-	if proxy.ID == "" {
-		proxy.ID = "proxy_" + randomID()
-	}
-	if proxy.Address == "" {
-		return errors.New("proxy address is required")
+// addProxyLocked는 AddProxy의 본체입니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) addProxyLocked(proxy *ProxyIP) (*ProxyIP, error) {
+	if proxy.Protocol == "" {
+		proxy.Protocol = "http"
 	}
 
-	// Validate proxy address format
-	if _, err := url.Parse(proxy.Address); err != nil {
-		return fmt.Errorf("invalid proxy address format: %w", err)
+	if verr := validateProxy(proxy); verr.HasErrors() {
+		return nil, verr
 	}
+	proxy.Protocol = strings.ToLower(proxy.Protocol)
 
-	if proxy.Protocol == "" {
-		proxy.Protocol = "http"
+	// Re-adding a soft-removed address restores the original entry with its learned stats
+	if restored := p.restoreRemovedLocked(proxy.Address); restored != nil {
+		p.autoSave()
+		return restored, nil
 	}
 
-	// Validate protocol
-	validProtocols := map[string]bool{"http": true, "https": true, "socks4": true, "socks5": true}
-	if !validProtocols[strings.ToLower(proxy.Protocol)] {
-		return fmt.Errorf("invalid protocol: %s, must be one of: http, https, socks4, socks5", proxy.Protocol)
+	if proxy.ID == "" {
+		proxy.ID = "proxy_" + randomID()
+	}
+	// Replacing a live entry would silently drop its learned stats; updates go through PATCH or the diff import
+	if existing, exists := p.proxies[proxy.ID]; exists && !existing.Removed {
+		return nil, fmt.Errorf("%w: %s", ErrProxyExists, proxy.ID)
 	}
-	proxy.Protocol = strings.ToLower(proxy.Protocol)
 
 	proxy.CreatedAt = time.Now()
 	proxy.Enabled = true
 	proxy.HealthStatus = "unknown"
-
+	proxy.AnonymityLevel = ""
+	proxy.SupportsWebSocket = false // only health checks may vouch for WebSocket support
+	proxy.ExitIP = ""
+	p.refreshIPVersion(proxy)
+	proxy.UnhealthySince.Store(time.Time{})
+	proxy.DailyUsage = 0
+	p.refreshQuota(proxy)
+	p.updateWarmup(proxy)
+	p.updateScore(proxy, time.Now())
+	if proxy.SupportsUDP {
+		proxy.UDPStatus = "unknown"
+	}
+
+	if _, exists := p.proxies[proxy.ID]; !exists {
+		p.order = append(p.order, proxy.ID)
+	}
 	p.proxies[proxy.ID] = proxy
-	p.order = append(p.order, proxy.ID)
+	p.invalidateWeights()
 
 	log.Printf("[IP-ROTATION] Proxy added: id=%s addr=%s protocol=%s country=%s",
 		proxy.ID, proxy.Address, proxy.Protocol, proxy.Country)
 
+	if p.config.PreResolveDNS {
+		go p.resolveProxy(proxy.ID)
+	}
+
 	// Auto-save if persistence is configured
 	p.autoSave()
 
-	return nil
+	return proxy, nil
+}
+
+// validateProxy는 프록시 입력값을 검사하고 필드별 오류를 모아 반환합니다.
+func validateProxy(proxy *ProxyIP) *ValidationError {
+	verr := &ValidationError{}
+
+	if proxy.Address == "" {
+		verr.Add("address", "proxy address is required")
+	} else if u, err := url.Parse(proxy.Address); err != nil {
+		verr.Add("address", fmt.Sprintf("invalid proxy address format: %v", err))
+	} else if u.Host == "" {
+		verr.Add("address", "proxy address must include scheme and host, e.g. http://host:port")
+	}
+
+	// Validate protocol
+	validProtocols := map[string]bool{"http": true, "https": true, "socks4": true, "socks5": true}
+	if !validProtocols[strings.ToLower(proxy.Protocol)] {
+		verr.Add("protocol", fmt.Sprintf("invalid protocol: %s, must be one of: http, https, socks4, socks5", proxy.Protocol))
+	}
+
+	if proxy.MaxUsageCount < 0 {
+		verr.Add("maxUsageCount", "maxUsageCount must be non-negative")
+	}
+	if proxy.MaxRequestsPerMinute < 0 {
+		verr.Add("maxRequestsPerMinute", "maxRequestsPerMinute must be non-negative")
+	}
+	if proxy.TimeoutMs < 0 {
+		verr.Add("timeoutMs", "timeoutMs must be non-negative")
+	}
+	if proxy.CostPerRequest < 0 {
+		verr.Add("costPerRequest", "costPerRequest must be non-negative")
+	}
+	if proxy.SupportsUDP && !strings.EqualFold(proxy.Protocol, "socks5") {
+		verr.Add("supportsUdp", "UDP support can only be declared for socks5 proxies")
+	}
+	if (proxy.Latitude == nil) != (proxy.Longitude == nil) {
+		verr.Add("latitude", "latitude and longitude must be set together")
+	} else if proxy.Latitude != nil && !validCoordinates(*proxy.Latitude, *proxy.Longitude) {
+		verr.Add("latitude", "latitude must be within [-90, 90] and longitude within [-180, 180]")
+	}
+	if msg := validateMetadata(proxy.Metadata); msg != "" {
+		verr.Add("metadata", msg)
+	}
+	if msg := validateUserAgents(proxy.UserAgents); msg != "" {
+		verr.Add("userAgents", msg)
+	}
+	if msg := validateMaintenanceWindows(proxy.MaintenanceWindows); msg != "" {
+		verr.Add("maintenanceWindows", msg)
+	}
+	if proxy.ActiveHours != nil {
+		if msg := proxy.ActiveHours.validate(); msg != "" {
+			verr.Add("activeHours", msg)
+		}
+	}
+	if msg := validateProxyHeaders(proxy.Headers, proxy.Protocol); msg != "" {
+		verr.Add("headers", msg)
+	}
+
+	return verr
 }
 
 // RemoveProxy는 풀에서 프록시를 제거하고 라운드로빈 순서도 갱신합니다.
@@ -647,18 +1940,10 @@ func (p *IPPool) RemoveProxy(id string) error {
 	defer p.mu.Unlock()
 
 	if _, ok := p.proxies[id]; !ok {
-		return errors.New("proxy not found")
+		return ErrProxyNotFound
 	}
 
-	delete(p.proxies, id)
-
-	// Remove from order
-	for i, oid := range p.order {
-		if oid == id {
-			p.order = append(p.order[:i], p.order[i+1:]...)
-			break
-		}
-	}
+	p.deleteProxyLocked(id)
 
 	log.Printf("[IP-ROTATION] Proxy removed: id=%s", id)
 
@@ -668,14 +1953,126 @@ func (p *IPPool) RemoveProxy(id string) error {
 	return nil
 }
 
-// GetAllProxies는 풀에 등록된 모든 프록시 목록을 반환합니다.
-func (p *IPPool) GetAllProxies() []*ProxyIP {
+// SoftRemoveProxy는 프록시를 삭제하지 않고 비활성화 및 removed로 표시합니다.
+// 선택 대상에서는 제외되지만 통계는 유지되어, 같은 주소로 다시 추가하면 복원됩니다.
+func (p *IPPool) SoftRemoveProxy(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[id]
+	if !ok {
+		return ErrProxyNotFound
+	}
+	if proxy.Removed {
+		return nil
+	}
+
+	now := time.Now()
+	proxy.Removed = true
+	proxy.RemovedAt = now
+	if proxy.Enabled {
+		proxy.Enabled = false
+		proxy.DisabledAt = now
+		proxy.DisabledReason = DisabledReasonRemoved
+	}
+	p.recordEvent(id, EventDisabled, "soft removed", 0)
+	p.healthTransports.drop(id)
+	p.invalidateWeights()
+
+	log.Printf("[IP-ROTATION] Proxy soft-removed: id=%s addr=%s", id, proxy.Address)
+
+	p.autoSave()
+	return nil
+}
+
+// restoreRemovedLocked는 주소가 같은 soft-removed 프록시가 있으면 재활성화하여 반환합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) restoreRemovedLocked(address string) *ProxyIP {
+	for id, proxy := range p.proxies {
+		if !proxy.Removed || proxy.Address != address {
+			continue
+		}
+		proxy.Removed = false
+		proxy.RemovedAt = time.Time{}
+		// A re-added proxy gets a fresh auto-removal window
+		proxy.UnhealthySince.Store(time.Time{})
+		if proxy.DisabledReason == DisabledReasonRemoved {
+			proxy.Enabled = true
+			proxy.DisabledAt = time.Time{}
+			proxy.DisabledReason = ""
+		}
+		p.recordEvent(id, EventEnabled, "restored from soft removal", 0)
+		p.invalidateWeights()
+		log.Printf("[IP-ROTATION] Proxy restored: id=%s addr=%s", id, address)
+		return proxy
+	}
+	return nil
+}
+
+// PurgeRemoved는 soft-removed 상태인 모든 프록시를 영구 삭제하고 삭제된 ID 목록을 반환합니다.
+func (p *IPPool) PurgeRemoved() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	purged := make([]string, 0)
+	for id, proxy := range p.proxies {
+		if proxy.Removed {
+			purged = append(purged, id)
+		}
+	}
+	sort.Strings(purged)
+	for _, id := range purged {
+		p.deleteProxyLocked(id)
+	}
+
+	log.Printf("[IP-ROTATION] Purged %d soft-removed proxies", len(purged))
+	if len(purged) > 0 {
+		p.autoSave()
+	}
+	return purged
+}
+
+// deleteProxyLocked는 프록시와 관련 상태를 풀에서 영구 삭제합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) deleteProxyLocked(id string) {
+	delete(p.proxies, id)
+	p.healthTransports.drop(id)
+	p.metrics.dropProxy(id)
+	p.invalidateWeights()
+	p.historyMu.Lock()
+	delete(p.history, id)
+	p.historyMu.Unlock()
+
+	// Remove from order, keeping the round-robin cursor on the same next proxy
+	for i, oid := range p.order {
+		if oid == id {
+			last := len(p.order) - 1
+			copy(p.order[i:], p.order[i+1:])
+			p.order[last] = "" // drop the stale reference left in the backing array
+			p.order = p.order[:last]
+			if i < p.index {
+				p.index--
+			}
+			if i < p.shadowIndex {
+				p.shadowIndex--
+			}
+			break
+		}
+	}
+	// Shrink the backing array once it is mostly unused
+	if cap(p.order) > 64 && len(p.order) < cap(p.order)/4 {
+		p.order = append(make([]string, 0, len(p.order)*2), p.order...)
+	}
+}
+
+// GetAllProxies는 풀에 등록된 모든 프록시를 조회 시점의 파생 필드와 함께 반환합니다.
+func (p *IPPool) GetAllProxies() []ProxyView {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	proxies := make([]*ProxyIP, 0, len(p.proxies))
+	now := time.Now()
+	proxies := make([]ProxyView, 0, len(p.proxies))
 	for _, proxy := range p.proxies {
-		proxies = append(proxies, proxy)
+		proxies = append(proxies, p.viewLocked(proxy, now))
 	}
 	return proxies
 }
@@ -685,30 +2082,67 @@ func (p *IPPool) GetPoolStats() map[string]any {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	var totalUsage, totalSuccess, totalFail, totalCaptcha int64
+	var totalUsage, totalSuccess, totalFail, totalCaptcha, totalBlocks int64
 	enabledCount := 0
 	disabledCount := 0
 	healthyCount := 0
+	degradedCount := 0
 	unhealthyCount := 0
+	drainingCount := 0
+	anonymityLevels := map[string]int{}
+	tiers := make(map[int]map[string]int)
+	usages := make([]int64, 0, len(p.proxies))
 
 	for _, proxy := range p.proxies {
-		totalUsage += proxy.UsageCount
-		totalSuccess += proxy.SuccessCount
-		totalFail += proxy.FailCount
-		totalCaptcha += proxy.CaptchaCount
+		if !proxy.Removed {
+			usages = append(usages, proxy.UsageCount.Load())
+		}
+		tier, ok := tiers[proxy.Priority]
+		if !ok {
+			tier = map[string]int{"total": 0, "enabled": 0, "healthy": 0, "degraded": 0, "unhealthy": 0}
+			tiers[proxy.Priority] = tier
+		}
+		tier["total"]++
+		if proxy.Enabled {
+			tier["enabled"]++
+		}
+		switch proxy.HealthStatus {
+		case "healthy":
+			tier["healthy"]++
+		case HealthStatusDegraded:
+			tier["degraded"]++
+		case "unhealthy":
+			tier["unhealthy"]++
+		}
+
+		totalUsage += proxy.UsageCount.Load()
+		totalSuccess += proxy.SuccessCount.Load()
+		totalFail += proxy.FailCount.Load()
+		totalCaptcha += proxy.CaptchaCount.Load()
+		totalBlocks += proxy.BlockCount.Load()
 		if proxy.Enabled {
 			enabledCount++
 		} else {
 			disabledCount++
 		}
+		if proxy.Draining {
+			drainingCount++
+		}
+		if proxy.AnonymityLevel != "" {
+			anonymityLevels[proxy.AnonymityLevel]++
+		}
 		switch proxy.HealthStatus {
 		case "healthy":
 			healthyCount++
+		case HealthStatusDegraded:
+			degradedCount++
 		case "unhealthy":
 			unhealthyCount++
 		}
 	}
 
+	persistence := p.persistence.snapshot(p.config.PersistencePath != "")
+
 	successRate := float64(0)
 	if totalSuccess+totalFail > 0 {
 		successRate = float64(totalSuccess) / float64(totalSuccess+totalFail) * 100
@@ -720,43 +2154,81 @@ func (p *IPPool) GetPoolStats() map[string]any {
 	}
 
 	return map[string]any{
-		"totalProxies":     len(p.proxies),
-		"enabledProxies":   enabledCount,
-		"disabledProxies":  disabledCount,
-		"healthyProxies":   healthyCount,
-		"unhealthyProxies": unhealthyCount,
-		"totalUsage":       totalUsage,
-		"totalSuccess":     totalSuccess,
-		"totalFail":        totalFail,
-		"totalCaptcha":     totalCaptcha,
-		"successRate":      fmt.Sprintf("%.2f%%", successRate),
-		"captchaRate":      fmt.Sprintf("%.2f%%", captchaRate),
-		"strategy":         p.config.Strategy,
-		"currentIndex":     p.index,
-		"cooldownMinutes":  p.config.CooldownMinutes,
-		"maxFailures":      p.config.MaxFailures,
+		"totalProxies":       len(p.proxies),
+		"enabledProxies":     enabledCount,
+		"disabledProxies":    disabledCount,
+		"healthyProxies":     healthyCount,
+		"degradedProxies":    degradedCount,
+		"unhealthyProxies":   unhealthyCount,
+		"drainingProxies":    drainingCount,
+		"anonymityLevels":    anonymityLevels,
+		"totalUsage":         totalUsage,
+		"totalSuccess":       totalSuccess,
+		"totalFail":          totalFail,
+		"totalCaptcha":       totalCaptcha,
+		"totalBlocks":        totalBlocks,
+		"successRate":        fmt.Sprintf("%.2f%%", successRate),
+		"captchaRate":        fmt.Sprintf("%.2f%%", captchaRate),
+		"strategy":           p.config.Strategy,
+		"currentIndex":       p.index,
+		"cooldownMinutes":    p.config.CooldownMinutes,
+		"maxFailures":        p.config.MaxFailures,
+		"tiers":              tiers,
+		"usageFairness":      usageFairness(usages),
+		"servedLocal":        p.metrics.servedLocal.Load(),
+		"servedFederated":    p.metrics.servedFederated.Load(),
+		"persistence":        persistence,
+		"persistenceHealthy": persistence["healthy"],
+		"shadow":             p.shadowSummaryLocked(),
+		"countryUsage":       p.countryUsageSummaryLocked(),
+		"asnCounts":          p.asnCountsLocked(),
+		"ipVersionCounts":    p.ipVersionCountsLocked(),
+		"estimatedSpend":     p.estimatedSpendLocked(),
+		"providerSync":       p.providerSync,
+		"stateChanges":       p.metrics.stateChangeCounts(),
 	}
 }
 
 // UpdateConfig는 설정을 검증 후 적용하고, 변경 사항에 따라 백그라운드 루틴을 재시작합니다.
 func (p *IPPool) UpdateConfig(cfg IPPoolConfig) error {
 	if err := cfg.Validate(); err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
 	}
 
 	p.mu.Lock()
 	oldCooldown := p.config.CooldownMinutes
+	oldBlockCooldown := p.config.BlockCooldownMinutes
 	oldHealthInterval := p.config.HealthCheckInterval
+	oldPreResolve := p.config.PreResolveDNS
+	oldDNSRefresh := p.config.DNSRefreshInterval
+	oldResetHour := p.config.DailyResetHourUTC
+	oldAutoRemove := p.config.AutoRemoveAfterHours
+	oldRebalance := p.config.RebalanceOrderMinutes
+	oldSyncURL := p.config.ProviderSyncURL
+	oldSyncMinutes := p.config.ProviderSyncMinutes
+	if cfg.ShadowStrategy != p.config.ShadowStrategy {
+		// A new shadow strategy starts a fresh comparison
+		p.shadow.reset()
+		p.shadowIndex = 0
+	}
 	p.config = cfg
+	p.resizeHistory()
+	p.metrics.setLatencyBuckets(cfg.LatencyBucketsMs)
+	for _, proxy := range p.proxies {
+		p.refreshQuota(proxy)
+		p.updateWarmup(proxy)
+		p.updateScore(proxy, time.Now())
+	}
+	p.invalidateWeights()
 	p.mu.Unlock()
 
 	log.Printf("[IP-ROTATION] Config updated: strategy=%s maxFailures=%d cooldown=%dm healthInterval=%ds",
 		cfg.Strategy, cfg.MaxFailures, cfg.CooldownMinutes, cfg.HealthCheckInterval)
 
 	// Restart cooldown checker if cooldown setting changed
-	if cfg.CooldownMinutes != oldCooldown {
+	if cfg.CooldownMinutes != oldCooldown || cfg.BlockCooldownMinutes != oldBlockCooldown {
 		p.StopCooldownChecker()
-		if cfg.CooldownMinutes > 0 {
+		if cfg.CooldownMinutes > 0 || cfg.BlockCooldownMinutes > 0 {
 			p.StartCooldownChecker()
 		}
 	}
@@ -769,13 +2241,48 @@ func (p *IPPool) UpdateConfig(cfg IPPoolConfig) error {
 		}
 	}
 
+	// Restart daily reset scheduler if the reset hour changed
+	if cfg.DailyResetHourUTC != oldResetHour {
+		p.StopDailyResetScheduler()
+		p.StartDailyResetScheduler()
+	}
+
+	// Restart DNS resolver if pre-resolution settings changed
+	if cfg.PreResolveDNS != oldPreResolve || cfg.DNSRefreshInterval != oldDNSRefresh {
+		p.StopDNSResolver()
+		if cfg.PreResolveDNS {
+			p.StartDNSResolver()
+		}
+	}
+
+	// Restart dead proxy sweeper if auto-removal was toggled or retuned
+	if cfg.AutoRemoveAfterHours != oldAutoRemove {
+		p.StopDeadProxySweeper()
+		if cfg.AutoRemoveAfterHours > 0 {
+			p.StartDeadProxySweeper()
+		}
+	}
+
+	if cfg.RebalanceOrderMinutes != oldRebalance {
+		p.StopOrderRebalancer()
+		if cfg.RebalanceOrderMinutes > 0 {
+			p.StartOrderRebalancer()
+		}
+	}
+
+	if cfg.ProviderSyncURL != oldSyncURL || cfg.ProviderSyncMinutes != oldSyncMinutes {
+		p.StopProviderSync()
+		p.StartProviderSync()
+	}
+
 	// Auto-save if persistence is configured
 	p.autoSave()
 
 	return nil
 }
 
-// GetProxyURL은 프록시 주소(Address)에 인증 정보가 있으면 포함하여 url.URL을 반환합니다.
+// GetProxyURL은 프록시 주소(Address)에 인증 정보(사용자 이름과 비밀번호 모두)가 있으면 포함하여 url.URL을 반환합니다.
+// 인증 정보는 url.Userinfo로 설정되므로 String() 결과에서 특수 문자가 퍼센트 인코딩됩니다.
 func (p *ProxyIP) GetProxyURL() (*url.URL, error) {
 	proxyAddr := p.Address
 	if p.Username != "" && p.Password != "" {
@@ -790,24 +2297,55 @@ func (p *ProxyIP) GetProxyURL() (*url.URL, error) {
 	return url.Parse(proxyAddr)
 }
 
+// proxyEndpoint는 프록시 URL(인증 정보 포함)과 프록시 헤더를 p.mu 읽기 잠금 아래에서 읽습니다. 점검은 잠금 밖에서
+// 실행되는 동안 PATCH가 주소, 인증 정보, 헤더를 바꿀 수 있으므로 점검 코드는 이 값을 사용합니다.
+// 호출자는 p.mu를 보유하지 않아야 합니다.
+func (p *IPPool) proxyEndpoint(proxy *ProxyIP) (*url.URL, http.Header, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	proxyURL, err := proxy.GetProxyURL()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proxyURL, proxy.ProxyHeader(), nil
+}
+
 // ========== Persistence Functions ==========
 
-// SaveToFile은 현재 풀 상태를 JSON 파일로 저장합니다.
+// SaveToFile은 현재 풀 상태를 JSON 파일로 저장하고, 결과를 영속화 상태(persistenceHealthy)에 반영합니다.
+// 저장은 한 번에 하나만 실행되며, 동시에 들어온 호출은 앞선 저장이 끝날 때까지 기다립니다.
 func (p *IPPool) SaveToFile(path string) error {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+	err := p.writeStateFile(path)
+	p.persistence.recordSaveResult(err)
+	return err
+}
+
+// writeStateFile은 SaveToFile의 저장 본체입니다.
+func (p *IPPool) writeStateFile(path string) error {
 	p.mu.RLock()
 	state := IPPoolState{
-		Proxies: p.proxies,
-		Order:   p.order,
-		Index:   p.index,
-		Config:  p.config,
-		SavedAt: time.Now(),
-	}
-	p.mu.RUnlock()
-
+		SchemaVersion: currentSchemaVersion,
+		Proxies:       p.proxies,
+		Order:         p.order,
+		Index:         p.index,
+		Config:        p.config,
+		SavedAt:       time.Now(),
+		LastServed:    p.lastServedLocked(),
+	}
+	// Marshal under the read lock so concurrent mutations can't race the encoder
 	data, err := json.MarshalIndent(state, "", "  ")
+	stateCipher := p.stateCipher
+	p.mu.RUnlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal pool state: %w", err)
 	}
+	if stateCipher != nil {
+		if data, err = stateCipher.seal(data); err != nil {
+			return fmt.Errorf("failed to encrypt pool state: %w", err)
+		}
+	}
 
 	// Ensure directory exists
 	dir := filepath.Dir(path)
@@ -815,7 +2353,13 @@ func (p *IPPool) SaveToFile(path string) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	// Write to a temp file and rename so readers never see a partially written state
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -823,7 +2367,9 @@ func (p *IPPool) SaveToFile(path string) error {
 	return nil
 }
 
-// LoadFromFile은 JSON 파일에서 풀 상태를 로드하여 적용합니다.
+// LoadFromFile은 JSON 파일에서 풀 상태를 로드하여 적용합니다. PERSISTENCE_KEY로 암호화된 파일은 복호화해 읽으며,
+// 키 설정과 파일 형식이 맞지 않으면 ErrStateEncryption을 반환합니다. 이전 스키마 버전의 파일은 migrateState로 변환해 읽고,
+// 더 새로운 버전의 파일은 AllowNewerStateSchema가 꺼져 있으면 ErrUnsupportedSchema로 거부합니다.
 func (p *IPPool) LoadFromFile(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -834,6 +2380,18 @@ func (p *IPPool) LoadFromFile(path string) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	p.mu.RLock()
+	allowNewer := p.config.AllowNewerStateSchema
+	data, err = p.decodeStateLocked(data)
+	p.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to load pool state %s: %w", path, err)
+	}
+	data, err = migrateState(data, allowNewer)
+	if err != nil {
+		return fmt.Errorf("failed to load pool state %s: %w", path, err)
+	}
+
 	var state IPPoolState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return fmt.Errorf("failed to unmarshal pool state: %w", err)
@@ -841,11 +2399,22 @@ func (p *IPPool) LoadFromFile(path string) error {
 
 	p.mu.Lock()
 	p.proxies = state.Proxies
+	p.healthTransports.closeAll()
 	p.order = state.Order
 	p.index = state.Index
 	if state.Config.Strategy != "" {
 		p.config = state.Config
+		p.metrics.setLatencyBuckets(p.config.LatencyBucketsMs)
 	}
+	p.repairOrder()
+	p.index = p.resumeIndexLocked(state.LastServed, state.Index)
+	for _, proxy := range p.proxies {
+		p.updateWarmup(proxy)
+		p.updateScore(proxy, time.Now())
+		proxy.ActiveRequests.Store(0) // in-flight requests did not survive the restart
+		p.refreshIPVersion(proxy)
+	}
+	p.invalidateWeights()
 	p.mu.Unlock()
 
 	log.Printf("[IP-ROTATION] Pool state loaded from: %s (saved at: %s, proxies: %d)",
@@ -854,16 +2423,160 @@ func (p *IPPool) LoadFromFile(path string) error {
 	return nil
 }
 
-// autoSave는 PersistencePath가 설정된 경우 풀 상태를 비동기로 저장합니다.
+// lastServedLocked는 라운드로빈 커서 직전(마지막으로 제공된) 프록시 ID를 반환합니다. 아직 없으면 빈 문자열입니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) lastServedLocked() string {
+	if p.index <= 0 || p.index > len(p.order) {
+		return ""
+	}
+	return p.order[p.index-1]
+}
+
+// resumeIndexLocked는 복원된 order 기준으로 라운드로빈을 이어갈 커서를 계산합니다. lastServed가 order에 있으면
+// 그 다음 위치를, 없으면 저장된 index를 order 길이로 나눈 나머지를 사용해 첫 프록시들에 몰리지 않게 합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) resumeIndexLocked(lastServed string, savedIndex int) int {
+	n := len(p.order)
+	if n == 0 || savedIndex < 0 {
+		return 0
+	}
+	if lastServed != "" {
+		if i := slices.Index(p.order, lastServed); i >= 0 {
+			return (i + 1) % n
+		}
+	}
+	return savedIndex % n
+}
+
+// repairOrder는 로드된 상태의 proxies와 order 사이의 불일치를 복구합니다.
+// proxies에 없는(또는 중복된) order 항목을 제거하고, order에 없는 프록시를 ID 순으로 뒤에 추가합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) repairOrder() {
+	if p.proxies == nil {
+		p.proxies = make(map[string]*ProxyIP)
+	}
+	for id, proxy := range p.proxies {
+		if proxy == nil {
+			delete(p.proxies, id)
+			log.Printf("[IP-ROTATION] State repair: dropped empty proxy entry %s", id)
+		}
+	}
+
+	seen := make(map[string]bool, len(p.order))
+	repaired := make([]string, 0, len(p.proxies))
+	dropped := 0
+	for _, id := range p.order {
+		if _, ok := p.proxies[id]; !ok || seen[id] {
+			dropped++
+			continue
+		}
+		seen[id] = true
+		repaired = append(repaired, id)
+	}
+
+	missing := make([]string, 0)
+	for id := range p.proxies {
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	sort.Strings(missing)
+	repaired = append(repaired, missing...)
+
+	p.order = repaired
+	if p.index < 0 || p.index >= len(p.order) {
+		p.index = 0
+	}
+
+	if dropped > 0 || len(missing) > 0 {
+		log.Printf("[IP-ROTATION] State repair: dropped %d dangling/duplicate order entries, appended %d proxies missing from order",
+			dropped, len(missing))
+	}
+}
+
+// autoSave는 풀 상태가 변경되었음을 자동 저장 루틴에 알립니다.
+// 잠금 보유 여부와 관계없이 호출할 수 있으며 블로킹하지 않습니다. 연속된 호출은 한 번의 저장으로 병합됩니다.
 func (p *IPPool) autoSave() {
-	if p.config.PersistencePath != "" {
-		go func() {
-			// Release lock before saving
-			if err := p.SaveToFile(p.config.PersistencePath); err != nil {
-				log.Printf("[IP-ROTATION] Auto-save failed: %v", err)
+	select {
+	case p.saveDirty <- struct{}{}:
+	default:
+		// A save is already pending
+	}
+}
+
+// runAutoSaver는 변경 알림을 받으면 AutoSaveInterval 동안 대기한 뒤 한 번 저장하는 단일 백그라운드 루틴입니다.
+// 중지 요청 시 대기 중인 변경 사항이 있으면 즉시 저장한 후 종료합니다.
+func (p *IPPool) runAutoSaver() {
+	defer close(p.autoSaveDone)
+	for {
+		select {
+		case <-p.saveDirty:
+		case <-p.stopAutoSave:
+			// Flush a change that raced with the stop request
+			select {
+			case <-p.saveDirty:
+				p.saveIfConfigured()
+			default:
 			}
-		}()
+			return
+		}
+
+		p.mu.RLock()
+		interval := p.config.AutoSaveInterval
+		p.mu.RUnlock()
+		if interval <= 0 {
+			interval = 2
+		}
+
+		timer := time.NewTimer(time.Duration(interval) * time.Second)
+		select {
+		case <-timer.C:
+			p.saveIfConfigured()
+		case <-p.stopAutoSave:
+			timer.Stop()
+			p.saveIfConfigured()
+			return
+		}
+	}
+}
+
+// saveIfConfigured는 PersistencePath가 설정된 경우 풀 상태를 저장합니다.
+func (p *IPPool) saveIfConfigured() {
+	p.mu.RLock()
+	path := p.config.PersistencePath
+	p.mu.RUnlock()
+	if path == "" {
+		return
+	}
+	if err := p.SaveToFile(path); err != nil {
+		p.persistence.logSaveFailure(err)
+		return
+	}
+	p.persistence.logRecovery(path)
+}
+
+// Shutdown은 백그라운드 루틴을 중지하고, 저장 대기 중인 변경 사항을 디스크에 기록합니다.
+func (p *IPPool) Shutdown() {
+	p.StopCooldownChecker()
+	p.StopHealthChecker()
+	p.StopDNSResolver()
+	p.StopDailyResetScheduler()
+	p.StopDeadProxySweeper()
+	p.StopMaintenanceScheduler()
+	p.StopOrderRebalancer()
+	p.StopExpirySweeper()
+	p.StopProviderSync()
+	p.healthTransports.closeAll()
+
+	p.mu.Lock()
+	select {
+	case <-p.stopAutoSave:
+		// Already shut down
+	default:
+		close(p.stopAutoSave)
 	}
+	p.mu.Unlock()
+	<-p.autoSaveDone
 }
 
 // ResetStats는 모든 프록시의 통계 값을 초기화합니다.
@@ -872,12 +2585,22 @@ func (p *IPPool) ResetStats() {
 	defer p.mu.Unlock()
 
 	for _, proxy := range p.proxies {
-		proxy.UsageCount = 0
-		proxy.SuccessCount = 0
-		proxy.FailCount = 0
-		proxy.CaptchaCount = 0
-		proxy.AvgLatencyMs = 0
-	}
+		proxy.UsageCount.Store(0)
+		proxy.SuccessCount.Store(0)
+		proxy.FailCount.Store(0)
+		proxy.CaptchaCount.Store(0)
+		proxy.recentCaptchas.reset()
+		proxy.BlockCount.Store(0)
+		proxy.DisableCycles.Store(0)
+		proxy.AvgLatencyMs.Store(0)
+		proxy.HoldTime.reset()
+		proxy.LastFailure.Store(time.Time{})
+		proxy.DailyUsage = 0
+		p.refreshQuota(proxy)
+		p.updateWarmup(proxy)
+		p.updateScore(proxy, time.Now())
+	}
+	p.invalidateWeights()
 
 	log.Printf("[IP-ROTATION] Statistics reset for all proxies")
 }
@@ -889,18 +2612,31 @@ func (p *IPPool) ResetProxyStats(proxyID string) error {
 
 	proxy, ok := p.proxies[proxyID]
 	if !ok {
-		return errors.New("proxy not found")
-	}
-
-	proxy.UsageCount = 0
-	proxy.SuccessCount = 0
-	proxy.FailCount = 0
-	proxy.CaptchaCount = 0
-	proxy.AvgLatencyMs = 0
+		return ErrProxyNotFound
+	}
+
+	proxy.UsageCount.Store(0)
+	proxy.SuccessCount.Store(0)
+	proxy.FailCount.Store(0)
+	proxy.CaptchaCount.Store(0)
+	proxy.recentCaptchas.reset()
+	proxy.BlockCount.Store(0)
+	proxy.DisableCycles.Store(0)
+	proxy.AvgLatencyMs.Store(0)
+	proxy.HoldTime.reset()
+	proxy.LastFailure.Store(time.Time{})
+	proxy.UnhealthySince.Store(time.Time{})
+	proxy.DailyUsage = 0
+	p.refreshQuota(proxy)
+	p.updateWarmup(proxy)
+	p.updateScore(proxy, time.Now())
+	p.invalidateWeights()
 	// Re-enable if disabled
 	if !proxy.Enabled {
 		proxy.Enabled = true
 		proxy.DisabledAt = time.Time{}
+		proxy.DisabledReason = ""
+		p.recordEvent(proxyID, EventEnabled, "stats reset", 0)
 	}
 
 	log.Printf("[IP-ROTATION] Statistics reset for proxy: %s", proxyID)
@@ -920,9 +2656,10 @@ func randomID() string {
 
 // calculateSuccessRate는 성공/실패 카운트를 기반으로 성공률(%)을 계산합니다.
 func calculateSuccessRate(p *ProxyIP) float64 {
-	total := p.SuccessCount + p.FailCount
+	success := p.SuccessCount.Load()
+	total := success + p.FailCount.Load()
 	if total == 0 {
 		return 100.0
 	}
-	return float64(p.SuccessCount) / float64(total) * 100
+	return float64(success) / float64(total) * 100
 }
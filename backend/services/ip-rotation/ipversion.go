@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// 프록시 출구의 IP 주소 체계입니다(ProxyIP.IPVersion, /proxy/next?ipVersion=).
+const (
+	IPVersion4 = 4
+	IPVersion6 = 6
+)
+
+// ErrNoIPVersionProxy는 요청한 주소 체계(IPv4/IPv6)의 프록시가 후보에 하나도 없을 때 반환됩니다.
+var ErrNoIPVersionProxy = errors.New("no enabled proxies with the requested IP version")
+
+// parseIPVersion은 "4", "6", "ipv4", "ipv6"(대소문자 무시)를 주소 체계로 바꿉니다. 빈 문자열은 0(제한 없음)입니다.
+func parseIPVersion(v string) (int, error) {
+	switch strings.ToLower(v) {
+	case "":
+		return 0, nil
+	case "4", "ipv4":
+		return IPVersion4, nil
+	case "6", "ipv6":
+		return IPVersion6, nil
+	}
+	return 0, fmt.Errorf("invalid ipVersion: %s, must be 4 or 6", v)
+}
+
+// ipVersionOf는 IP 문자열의 주소 체계를 반환합니다. IP가 아니면 0입니다.
+func ipVersionOf(ip string) int {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return 0
+	case parsed.To4() != nil:
+		return IPVersion4
+	default:
+		return IPVersion6
+	}
+}
+
+// refreshIPVersion은 프록시의 주소 체계를 다시 정합니다. 확인된 출구 IP(ExitIP)를 우선하고, 없으면 주소의 호스트가
+// IP 리터럴인지, 그다음 DNS 사전 조회 결과(ResolvedIPs)를 봅니다. 알 수 없으면 0입니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) refreshIPVersion(proxy *ProxyIP) {
+	version := ipVersionOf(proxy.ExitIP)
+	if version == 0 {
+		if u, err := url.Parse(proxy.Address); err == nil {
+			version = ipVersionOf(u.Hostname())
+		}
+	}
+	if version == 0 && len(proxy.ResolvedIPs) > 0 {
+		version = ipVersionOf(proxy.ResolvedIPs[0])
+	}
+	if version != proxy.IPVersion {
+		proxy.IPVersion = version
+		// The family feeds the ipVersion filter
+		p.invalidateWeights()
+	}
+}
+
+// noteExitIPLocked는 헬스체크/검증에서 확인한 출구 IP를 기록하고 주소 체계를 갱신합니다. IP가 아니면 무시합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) noteExitIPLocked(proxy *ProxyIP, ip string) {
+	if ipVersionOf(ip) == 0 || proxy.ExitIP == ip {
+		return
+	}
+	previous := proxy.IPVersion
+	proxy.ExitIP = ip
+	p.refreshIPVersion(proxy)
+	if proxy.IPVersion != previous {
+		log.Printf("[IP-ROTATION] Exit IP family detected: id=%s exit_ip=%s ip_version=%d", proxy.ID, ip, proxy.IPVersion)
+	}
+}
+
+// filterIPVersion은 주소 체계가 version인 프록시만 반환합니다. 주소 체계를 모르는 프록시는 제외됩니다.
+func filterIPVersion(proxies []*ProxyIP, version int) []*ProxyIP {
+	matching := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy.IPVersion == version {
+			matching = append(matching, proxy)
+		}
+	}
+	return matching
+}
+
+// ipVersionCountsLocked는 soft-removed가 아닌 프록시 수를 주소 체계별(ipv4, ipv6, unknown)로 셉니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) ipVersionCountsLocked() map[string]int {
+	counts := map[string]int{"ipv4": 0, "ipv6": 0, "unknown": 0}
+	for _, proxy := range p.proxies {
+		if proxy.Removed {
+			continue
+		}
+		switch proxy.IPVersion {
+		case IPVersion4:
+			counts["ipv4"]++
+		case IPVersion6:
+			counts["ipv6"]++
+		default:
+			counts["unknown"]++
+		}
+	}
+	return counts
+}
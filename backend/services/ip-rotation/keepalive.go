@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+)
+
+// KeepAliveStatus 값입니다. 빈 값은 아직 점검되지 않았음을 뜻합니다.
+const (
+	KeepAliveSupported   = "supported"
+	KeepAliveUnsupported = "unsupported"
+)
+
+// checkKeepAlive는 프록시를 통해 target으로 GET 요청을 연속 두 번 보내고, 두 번째 요청이
+// 첫 번째 연결을 재사용했는지(httptrace GotConnInfo.Reused) 반환합니다. 점검은 ctx 데드라인으로 제한됩니다.
+func checkKeepAlive(ctx context.Context, dial dialFunc, proxyURL *url.URL, proxyHeader http.Header, target string) (bool, error) {
+	transport := &http.Transport{
+		Proxy:               http.ProxyURL(proxyURL),
+		ProxyConnectHeader:  proxyHeader,
+		DialContext:         dial,
+		MaxIdleConnsPerHost: 1,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	reused := false
+	for i := 0; i < 2; i++ {
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused },
+		}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, target, nil)
+		if err != nil {
+			return false, err
+		}
+		if req.URL.Scheme == "http" {
+			for name, values := range proxyHeader {
+				req.Header[name] = values
+			}
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return false, err
+		}
+		// The body must be fully drained before the connection can return to the idle pool
+		_, err = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		resp.Body.Close()
+		if err != nil {
+			return false, err
+		}
+		if resp.StatusCode >= 400 {
+			return false, fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+	}
+	return reused, nil
+}
+
+// preferKeepAlive는 keep-alive 미지원으로 확인된 프록시를 후보에서 제외합니다. 점검 전(빈 값) 프록시는 유지하며,
+// 모든 후보가 제외되면 입력 목록을 그대로 반환합니다.
+func preferKeepAlive(proxies []*ProxyIP) []*ProxyIP {
+	preferred := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy.KeepAliveStatus != KeepAliveUnsupported {
+			preferred = append(preferred, proxy)
+		}
+	}
+	if len(preferred) == 0 {
+		return proxies
+	}
+	return preferred
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DisabledReasonMaintenance는 유지보수 시간대(MaintenanceWindows) 동안 자동 비활성화된 프록시의 사유입니다.
+// 시간대가 끝나면 유지보수 점검 루틴이 다시 활성화하며, 쿨다운 체커는 건드리지 않습니다.
+const DisabledReasonMaintenance = "maintenance"
+
+// maintenanceCheckInterval은 유지보수 시간대 진입/종료를 확인하는 주기입니다.
+const maintenanceCheckInterval = time.Minute
+
+// maintenanceDays는 MaintenanceWindow.Days에 쓸 수 있는 요일 약어입니다.
+var maintenanceDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// MaintenanceWindow는 프록시를 자동으로 쉬게 할 반복 시간대입니다. End가 Start보다 이르면 자정을 넘는 시간대이며,
+// 이때 Days는 시간대가 시작하는 요일 기준입니다.
+type MaintenanceWindow struct {
+	Start    string   `json:"start"`              // "HH:MM", inclusive
+	End      string   `json:"end"`                // "HH:MM", exclusive
+	Days     []string `json:"days,omitempty"`     // mon..sun; empty = every day
+	Timezone string   `json:"timezone,omitempty"` // IANA name, e.g. "Asia/Seoul"; empty = UTC
+}
+
+// validate는 시간대 정의가 올바른지 검사하고, 문제가 있으면 설명을 반환합니다.
+func (w MaintenanceWindow) validate() string {
+	start, okStart := parseClock(w.Start)
+	end, okEnd := parseClock(w.End)
+	if !okStart || !okEnd {
+		return "start and end must be HH:MM"
+	}
+	if start == end {
+		return "start and end must differ"
+	}
+	for _, day := range w.Days {
+		if _, ok := maintenanceDays[strings.ToLower(day)]; !ok {
+			return "invalid day: " + day + ", must be one of: mon, tue, wed, thu, fri, sat, sun"
+		}
+	}
+	if w.Timezone != "" {
+		if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return "invalid timezone: " + w.Timezone
+		}
+	}
+	return ""
+}
+
+// active는 now가 이 시간대 안에 있는지 반환합니다. validate를 통과한 시간대여야 합니다.
+func (w MaintenanceWindow) active(now time.Time) bool {
+	loc := time.UTC
+	if w.Timezone != "" {
+		if l, err := time.LoadLocation(w.Timezone); err == nil {
+			loc = l
+		}
+	}
+	t := now.In(loc)
+	start, _ := parseClock(w.Start)
+	end, _ := parseClock(w.End)
+	minute := t.Hour()*60 + t.Minute()
+
+	if start < end {
+		return minute >= start && minute < end && w.onDay(t.Weekday())
+	}
+	// Wraps past midnight: the early-morning part belongs to the previous day's window
+	if minute >= start {
+		return w.onDay(t.Weekday())
+	}
+	return minute < end && w.onDay((t.Weekday()+6)%7)
+}
+
+// onDay는 시간대가 day에 시작하는지 반환합니다.
+func (w MaintenanceWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if maintenanceDays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock은 "HH:MM"을 자정 이후 분으로 변환합니다.
+func parseClock(v string) (int, bool) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// validateMaintenanceWindows는 프록시의 유지보수 시간대 목록을 검사하고, 문제가 있으면 설명을 반환합니다.
+func validateMaintenanceWindows(windows []MaintenanceWindow) string {
+	for i, w := range windows {
+		if msg := w.validate(); msg != "" {
+			return fmt.Sprintf("window %d: %s", i, msg)
+		}
+	}
+	return ""
+}
+
+// inMaintenance는 프록시가 지금 유지보수 시간대 중 하나에 있는지 반환합니다.
+func inMaintenance(proxy *ProxyIP, now time.Time) bool {
+	for _, w := range proxy.MaintenanceWindows {
+		if w.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMaintenanceLocked는 프록시가 유지보수 시간대에 들어갔으면 비활성화하고, 벗어났으면 다시 활성화합니다.
+// 다른 사유로 비활성화된 프록시와 통계는 건드리지 않습니다. 상태가 바뀌었으면 true를 반환합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) applyMaintenanceLocked(proxy *ProxyIP, now time.Time) bool {
+	if proxy.Removed {
+		return false
+	}
+	in := inMaintenance(proxy, now)
+	switch {
+	case in && proxy.Enabled:
+		proxy.Enabled = false
+		proxy.DisabledAt = now
+		proxy.DisabledReason = DisabledReasonMaintenance
+		p.recordEvent(proxy.ID, EventDisabled, "maintenance window", 0)
+		log.Printf("[IP-ROTATION] Proxy disabled for maintenance window: id=%s", proxy.ID)
+	case !in && !proxy.Enabled && proxy.DisabledReason == DisabledReasonMaintenance:
+		proxy.Enabled = true
+		proxy.DisabledAt = time.Time{}
+		proxy.DisabledReason = ""
+		p.recordEvent(proxy.ID, EventEnabled, "maintenance window ended", 0)
+		log.Printf("[IP-ROTATION] Proxy re-enabled after maintenance window: id=%s", proxy.ID)
+	default:
+		return false
+	}
+	p.invalidateWeights()
+	return true
+}
+
+// checkMaintenanceWindows는 모든 프록시에 유지보수 시간대를 적용하고, 바뀐 프록시 수를 반환합니다.
+func (p *IPPool) checkMaintenanceWindows() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	changed := 0
+	for _, proxy := range p.proxies {
+		if p.applyMaintenanceLocked(proxy, now) {
+			changed++
+		}
+	}
+	if changed > 0 {
+		p.autoSave()
+	}
+	return changed
+}
+
+// StartMaintenanceScheduler는 유지보수 시간대에 맞춰 프록시를 비활성화/재활성화하는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartMaintenanceScheduler() {
+	p.mu.Lock()
+	if p.maintenanceRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.maintenanceRunning = true
+	stop := p.stopMaintenance
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Maintenance window scheduler started (interval=%s)", maintenanceCheckInterval)
+		ticker := time.NewTicker(maintenanceCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.checkMaintenanceWindows()
+			case <-stop:
+				log.Printf("[IP-ROTATION] Maintenance window scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopMaintenanceScheduler는 유지보수 시간대 루틴을 중지합니다.
+func (p *IPPool) StopMaintenanceScheduler() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.maintenanceRunning {
+		close(p.stopMaintenance)
+		p.maintenanceRunning = false
+		p.stopMaintenance = make(chan struct{})
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// selectionDurationBuckets는 선택 소요 시간 히스토그램의 버킷 상한(초)입니다.
+var selectionDurationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+// defaultLatencyBucketsMs는 LatencyBucketsMs가 설정되지 않았을 때 쓰는 프록시 지연시간 히스토그램 버킷 상한(밀리초)입니다.
+var defaultLatencyBucketsMs = []float64{50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// openMetricsContentType은 OpenMetrics 형식 응답의 Content-Type입니다.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// latencyHistogram은 한 프록시의 요청 지연시간 분포입니다. 버킷 경계는 poolMetrics.latencyBounds를 따릅니다.
+type latencyHistogram struct {
+	buckets []int64 // cumulative counts per latencyBounds entry
+	sum     float64 // milliseconds
+	count   int64
+}
+
+// selectionKey는 선택 카운터의 레이블 조합(전략, 프록시 ID)입니다.
+type selectionKey struct {
+	strategy RotationStrategy
+	proxyID  string
+}
+
+// poolMetrics는 Prometheus 텍스트 형식으로 노출되는 누적 메트릭입니다. 풀 잠금과 독립된 자체 잠금을 사용합니다.
+type poolMetrics struct {
+	mu              sync.Mutex
+	selections      map[selectionKey]int64
+	durationBuckets []int64 // cumulative counts per selectionDurationBuckets entry
+	durationSum     float64
+	durationCount   int64
+	slowSelections  map[RotationStrategy]int64
+	servedLocal     Counter   // /proxy/next requests answered from this pool
+	servedFederated Counter   // /proxy/next requests relayed from an upstream pool
+	autoDisables    Counter   // proxies disabled by max failures, the success-rate floor or block reports
+	cooldownEnables Counter   // proxies re-enabled after their cooldown expired
+	manualEnables   Counter   // proxies enabled by an admin (PATCH or bulk action)
+	manualDisables  Counter   // proxies disabled or quarantined by an admin
+	latencyBounds   []float64 // milliseconds; histograms are reset when the bounds change
+	latency         map[string]*latencyHistogram
+}
+
+// newPoolMetrics는 비어 있는 메트릭 저장소를 생성합니다.
+func newPoolMetrics() *poolMetrics {
+	return &poolMetrics{
+		selections:      make(map[selectionKey]int64),
+		durationBuckets: make([]int64, len(selectionDurationBuckets)),
+		slowSelections:  make(map[RotationStrategy]int64),
+		latencyBounds:   defaultLatencyBucketsMs,
+		latency:         make(map[string]*latencyHistogram),
+	}
+}
+
+// setLatencyBuckets는 지연시간 히스토그램의 버킷 경계(밀리초)를 설정합니다. 비어 있으면 기본값을 사용합니다.
+// 경계가 바뀌면 이전 분포와 섞이지 않도록 기존 히스토그램을 모두 초기화합니다.
+func (m *poolMetrics) setLatencyBuckets(bounds []float64) {
+	if len(bounds) == 0 {
+		bounds = defaultLatencyBucketsMs
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.Equal(m.latencyBounds, bounds) {
+		return
+	}
+	m.latencyBounds = append([]float64(nil), bounds...)
+	m.latency = make(map[string]*latencyHistogram)
+}
+
+// observeLatency는 프록시의 요청 지연시간(밀리초) 한 건을 히스토그램에 기록합니다.
+func (m *poolMetrics) observeLatency(proxyID string, latencyMs int64) {
+	ms := float64(latencyMs)
+	m.mu.Lock()
+	h, ok := m.latency[proxyID]
+	if !ok {
+		h = &latencyHistogram{buckets: make([]int64, len(m.latencyBounds))}
+		m.latency[proxyID] = h
+	}
+	for i, le := range m.latencyBounds {
+		if ms <= le {
+			h.buckets[i]++
+		}
+	}
+	h.sum += ms
+	h.count++
+	m.mu.Unlock()
+}
+
+// observeSelection은 한 번의 선택 소요 시간을 히스토그램에 기록하고, slow가 true이면 느린 선택 카운터를 증가시킵니다.
+func (m *poolMetrics) observeSelection(strategy RotationStrategy, elapsed time.Duration, slow bool) {
+	seconds := elapsed.Seconds()
+	m.mu.Lock()
+	for i, le := range selectionDurationBuckets {
+		if seconds <= le {
+			m.durationBuckets[i]++
+		}
+	}
+	m.durationSum += seconds
+	m.durationCount++
+	if slow {
+		m.slowSelections[strategy]++
+	}
+	m.mu.Unlock()
+}
+
+// incSelection은 전략/프록시별 선택 카운터를 1 증가시킵니다.
+func (m *poolMetrics) incSelection(strategy RotationStrategy, proxyID string) {
+	m.mu.Lock()
+	m.selections[selectionKey{strategy: strategy, proxyID: proxyID}]++
+	m.mu.Unlock()
+}
+
+// dropProxy는 삭제된 프록시의 지연시간 히스토그램과 선택 카운터를 지웁니다. 지우지 않으면 프록시가
+// 교체될 때마다 /metrics의 시계열이 끝없이 늘어납니다.
+func (m *poolMetrics) dropProxy(proxyID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.latency, proxyID)
+	for key := range m.selections {
+		if key.proxyID == proxyID {
+			delete(m.selections, key)
+		}
+	}
+}
+
+// stateChange는 프록시 활성 상태 전환 카운터 하나입니다.
+type stateChange struct {
+	kind  string
+	count int64
+}
+
+// stateChanges는 활성 상태 전환 카운터를 메트릭 레이블 순서대로 반환합니다.
+func (m *poolMetrics) stateChanges() []stateChange {
+	return []stateChange{
+		{"auto_disable", m.autoDisables.Load()},
+		{"cooldown_enable", m.cooldownEnables.Load()},
+		{"manual_enable", m.manualEnables.Load()},
+		{"manual_disable", m.manualDisables.Load()},
+	}
+}
+
+// stateChangeCounts는 활성 상태 전환 카운터를 풀 통계(stateChanges)용 맵으로 반환합니다.
+func (m *poolMetrics) stateChangeCounts() map[string]int64 {
+	counts := make(map[string]int64, 4)
+	for _, change := range m.stateChanges() {
+		counts[change.kind] = change.count
+	}
+	return counts
+}
+
+// escapeLabel은 Prometheus 레이블 값에 쓸 수 있도록 역슬래시, 큰따옴표, 개행을 이스케이프합니다.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// metricsWriter는 Prometheus 텍스트 형식과 OpenMetrics 형식의 차이(카운터 이름, # EOF)를 흡수합니다.
+type metricsWriter struct {
+	io.Writer
+	openMetrics bool
+}
+
+// writeMetricHeader는 메트릭의 HELP/TYPE 줄을 출력합니다.
+// OpenMetrics에서는 카운터 메타데이터에 _total 접미사를 붙이지 않습니다(샘플 이름에만 붙음).
+func writeMetricHeader(w io.Writer, name, metricType, help string) {
+	if mw, ok := w.(*metricsWriter); ok && mw.openMetrics && metricType == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// WriteMetrics는 풀 상태 게이지와 누적 카운터, 히스토그램을 Prometheus 텍스트 노출 형식으로 출력합니다.
+// openMetrics가 true이면 OpenMetrics 1.0 형식으로 출력합니다.
+func (p *IPPool) WriteMetrics(out io.Writer, openMetrics bool) {
+	w := &metricsWriter{Writer: out, openMetrics: openMetrics}
+	if openMetrics {
+		defer fmt.Fprint(w, "# EOF\n")
+	}
+
+	p.mu.RLock()
+	total := len(p.proxies)
+	enabled, healthy, degraded, unhealthy := 0, 0, 0, 0
+	for _, proxy := range p.proxies {
+		if proxy.Enabled {
+			enabled++
+		}
+		switch proxy.HealthStatus {
+		case "healthy":
+			healthy++
+		case HealthStatusDegraded:
+			degraded++
+		case "unhealthy":
+			unhealthy++
+		}
+	}
+	strategy := p.config.Strategy
+	shadowStrategy := p.config.ShadowStrategy
+	// Shadow counters only change under the write lock, so these loads are consistent with each other
+	shadowSelections, shadowMatches, shadowErrors := p.shadow.Selections.Load(), p.shadow.Matches.Load(), p.shadow.Errors.Load()
+	p.mu.RUnlock()
+
+	writeMetricHeader(w, "ip_rotation_proxies", "gauge", "Number of proxies in the pool by state.")
+	fmt.Fprintf(w, "ip_rotation_proxies{state=\"total\"} %d\n", total)
+	fmt.Fprintf(w, "ip_rotation_proxies{state=\"enabled\"} %d\n", enabled)
+	fmt.Fprintf(w, "ip_rotation_proxies{state=\"healthy\"} %d\n", healthy)
+	fmt.Fprintf(w, "ip_rotation_proxies{state=\"degraded\"} %d\n", degraded)
+	fmt.Fprintf(w, "ip_rotation_proxies{state=\"unhealthy\"} %d\n", unhealthy)
+
+	writeMetricHeader(w, "ip_rotation_strategy_info", "gauge", "Currently configured rotation strategy.")
+	fmt.Fprintf(w, "ip_rotation_strategy_info{strategy=\"%s\"} 1\n", escapeLabel(string(strategy)))
+
+	writeMetricHeader(w, "ip_rotation_next_served_total", "counter", "Proxy requests served locally or relayed from an upstream pool.")
+	fmt.Fprintf(w, "ip_rotation_next_served_total{source=\"local\"} %d\n", p.metrics.servedLocal.Load())
+	fmt.Fprintf(w, "ip_rotation_next_served_total{source=\"federated\"} %d\n", p.metrics.servedFederated.Load())
+
+	writeMetricHeader(w, "ip_rotation_proxy_state_changes_total", "counter", "Proxy enable/disable transitions by cause; a high cooldown_enable rate means proxies are flapping.")
+	for _, change := range p.metrics.stateChanges() {
+		fmt.Fprintf(w, "ip_rotation_proxy_state_changes_total{kind=\"%s\"} %d\n", change.kind, change.count)
+	}
+
+	if shadowStrategy != "" {
+		label := escapeLabel(string(shadowStrategy))
+		writeMetricHeader(w, "ip_rotation_shadow_selections_total", "counter", "Shadow strategy picks compared with the live selection, by outcome.")
+		fmt.Fprintf(w, "ip_rotation_shadow_selections_total{strategy=\"%s\",outcome=\"match\"} %d\n", label, shadowMatches)
+		fmt.Fprintf(w, "ip_rotation_shadow_selections_total{strategy=\"%s\",outcome=\"differ\"} %d\n", label, shadowSelections-shadowMatches-shadowErrors)
+		fmt.Fprintf(w, "ip_rotation_shadow_selections_total{strategy=\"%s\",outcome=\"error\"} %d\n", label, shadowErrors)
+	}
+
+	p.metrics.mu.Lock()
+	keys := make([]selectionKey, 0, len(p.metrics.selections))
+	for k := range p.metrics.selections {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].strategy != keys[j].strategy {
+			return keys[i].strategy < keys[j].strategy
+		}
+		return keys[i].proxyID < keys[j].proxyID
+	})
+	writeMetricHeader(w, "ip_rotation_selections_total", "counter", "Proxy selections by strategy and selected proxy.")
+	for _, k := range keys {
+		fmt.Fprintf(w, "ip_rotation_selections_total{strategy=\"%s\",proxy_id=\"%s\"} %d\n",
+			escapeLabel(string(k.strategy)), escapeLabel(k.proxyID), p.metrics.selections[k])
+	}
+
+	writeMetricHeader(w, "ip_rotation_selection_duration_seconds", "histogram", "Time spent selecting a proxy, including lock wait.")
+	for i, le := range selectionDurationBuckets {
+		fmt.Fprintf(w, "ip_rotation_selection_duration_seconds_bucket{le=\"%g\"} %d\n", le, p.metrics.durationBuckets[i])
+	}
+	fmt.Fprintf(w, "ip_rotation_selection_duration_seconds_bucket{le=\"+Inf\"} %d\n", p.metrics.durationCount)
+	fmt.Fprintf(w, "ip_rotation_selection_duration_seconds_sum %g\n", p.metrics.durationSum)
+	fmt.Fprintf(w, "ip_rotation_selection_duration_seconds_count %d\n", p.metrics.durationCount)
+
+	slowStrategies := make([]string, 0, len(p.metrics.slowSelections))
+	for s := range p.metrics.slowSelections {
+		slowStrategies = append(slowStrategies, string(s))
+	}
+	sort.Strings(slowStrategies)
+	writeMetricHeader(w, "ip_rotation_slow_selections_total", "counter", "Selections that exceeded slowSelectionMs, by strategy.")
+	for _, s := range slowStrategies {
+		fmt.Fprintf(w, "ip_rotation_slow_selections_total{strategy=\"%s\"} %d\n",
+			escapeLabel(s), p.metrics.slowSelections[RotationStrategy(s)])
+	}
+
+	latencyIDs := make([]string, 0, len(p.metrics.latency))
+	for id := range p.metrics.latency {
+		latencyIDs = append(latencyIDs, id)
+	}
+	sort.Strings(latencyIDs)
+	writeMetricHeader(w, "ip_rotation_proxy_latency_seconds", "histogram", "Request latency reported via /proxy/record, by proxy.")
+	for _, id := range latencyIDs {
+		h := p.metrics.latency[id]
+		label := escapeLabel(id)
+		for i, le := range p.metrics.latencyBounds {
+			fmt.Fprintf(w, "ip_rotation_proxy_latency_seconds_bucket{proxy_id=\"%s\",le=\"%g\"} %d\n", label, le/1000, h.buckets[i])
+		}
+		fmt.Fprintf(w, "ip_rotation_proxy_latency_seconds_bucket{proxy_id=\"%s\",le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(w, "ip_rotation_proxy_latency_seconds_sum{proxy_id=\"%s\"} %g\n", label, h.sum/1000)
+		fmt.Fprintf(w, "ip_rotation_proxy_latency_seconds_count{proxy_id=\"%s\"} %d\n", label, h.count)
+	}
+	p.metrics.mu.Unlock()
+}
+
+// parseLatencyBuckets는 쉼표로 구분된 버킷 경계(밀리초) 목록을 파싱합니다. 숫자가 아닌 항목은 건너뜁니다.
+func parseLatencyBuckets(v string) []float64 {
+	var bounds []float64
+	for _, s := range strings.Split(v, ",") {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			bounds = append(bounds, f)
+		}
+	}
+	return bounds
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDeleteProxyDropsMetricsSeries(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 2)
+	for i := 0; i < 4; i++ {
+		proxy, err := pool.GetNextProxyWithStrategy(context.Background(), StrategyRoundRobin)
+		if err != nil {
+			t.Fatalf("select: %v", err)
+		}
+		pool.RecordSuccess(proxy.ID, 120)
+	}
+
+	if err := pool.RemoveProxy("p0"); err != nil {
+		t.Fatalf("remove proxy: %v", err)
+	}
+
+	var out strings.Builder
+	pool.WriteMetrics(&out, false)
+	metrics := out.String()
+	if strings.Contains(metrics, `"p0"`) {
+		t.Errorf("metrics still contain series for the deleted proxy:\n%s", metrics)
+	}
+	if !strings.Contains(metrics, `"p1"`) {
+		t.Errorf("metrics lost series for the remaining proxy:\n%s", metrics)
+	}
+}
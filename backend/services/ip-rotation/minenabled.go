@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// minEnabledLogInterval은 최소 풀 크기 때문에 막힌 자동 비활성화를 로그로 남기는 최소 간격입니다.
+const minEnabledLogInterval = time.Minute
+
+// minEnabledLog는 막힌 자동 비활성화 로그를 minEnabledLogInterval마다 한 번으로 줄입니다. 광범위한 장애 중에는
+// 실패가 기록될 때마다 막히므로 그대로 남기면 로그가 넘칩니다. p.mu 쓰기 잠금으로 보호됩니다.
+type minEnabledLog struct {
+	lastLoggedAt time.Time
+	suppressed   int // blocked auto-disables not logged since lastLoggedAt
+}
+
+// autoDisableAllowedLocked는 proxy를 실패 때문에 자동 비활성화해도 선택 가능한 프록시(eligibleLocked) 수가
+// MinEnabledProxies 아래로 내려가지 않는지 반환합니다. 이미 선택되지 않는 프록시(unhealthy, drain 중 등)는 비활성화해도
+// 선택 가능한 수가 그대로이므로 항상 허용합니다. 막는 경우 간격을 두고 로그를 남기며, 광범위한 장애 중에도 남은 프록시로
+// 로테이션을 이어가게 합니다. 분당 요청 한도에 잠시 걸린 프록시도 곧 다시 선택되므로 셉니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) autoDisableAllowedLocked(proxy *ProxyIP) bool {
+	floor := p.config.MinEnabledProxies
+	if floor <= 0 || !p.eligibleLocked(proxy) {
+		return true
+	}
+	selectable := 0
+	for _, candidate := range p.proxies {
+		if p.eligibleLocked(candidate) {
+			selectable++
+		}
+	}
+	if selectable > floor {
+		return true
+	}
+
+	throttle := &p.minEnabledLog
+	if time.Since(throttle.lastLoggedAt) < minEnabledLogInterval {
+		throttle.suppressed++
+		return false
+	}
+	log.Printf("[IP-ROTATION] Auto-disable prevented by minimum pool size: id=%s fail=%d selectable=%d min_enabled=%d suppressed=%d",
+		proxy.ID, proxy.FailCount.Load(), selectable, floor, throttle.suppressed)
+	throttle.lastLoggedAt = time.Now()
+	throttle.suppressed = 0
+	return false
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestAutoDisableFloorCountsSelectableProxies(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{MaxFailures: 1, MinEnabledProxies: 2}, 3)
+	pool.mu.Lock()
+	pool.proxies["p2"].HealthStatus = "unhealthy"
+	pool.mu.Unlock()
+
+	// p2 is enabled but not selectable, so only p0 and p1 count toward the floor
+	for i := 0; i < 3; i++ {
+		pool.RecordFailure("p0", "test")
+	}
+	// Disabling a proxy that is not selectable does not shrink the selectable pool
+	pool.RecordFailure("p2", "test")
+
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if !pool.proxies["p0"].Enabled {
+		t.Error("p0 was disabled, dropping the selectable pool below the floor")
+	}
+	if pool.proxies["p2"].Enabled {
+		t.Error("unhealthy p2 was kept enabled by the floor")
+	}
+	// Only the first blocked failure is logged within the interval
+	if got := pool.minEnabledLog.suppressed; got != 2 {
+		t.Errorf("suppressed log lines = %d, want 2", got)
+	}
+}
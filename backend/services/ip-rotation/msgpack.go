@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// msgpackContentType은 MessagePack 응답의 Content-Type입니다.
+const msgpackContentType = "application/msgpack"
+
+// acceptsMsgpack은 Accept 헤더가 MessagePack을 요청하는지 반환합니다(application/msgpack 또는 application/x-msgpack).
+func acceptsMsgpack(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case msgpackContentType, "application/x-msgpack":
+			return true
+		}
+	}
+	return false
+}
+
+// writeNegotiated는 클라이언트가 MessagePack을 요청하면 data를 MessagePack으로, 아니면 JSON으로 응답합니다.
+// MessagePack으로 인코딩할 수 없는 값이 섞여 있으면 JSON으로 응답합니다.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, status int, data any) {
+	w.Header().Add("Vary", "Accept")
+	if !acceptsMsgpack(r.Header.Get("Accept")) {
+		writeJSON(w, status, data)
+		return
+	}
+	body, err := appendMsgpack(nil, data)
+	if err != nil {
+		writeJSON(w, status, data)
+		return
+	}
+	w.Header().Set("Content-Type", msgpackContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// appendMsgpack은 v를 MessagePack으로 인코딩해 b에 덧붙입니다. /proxy/next 응답에 쓰이는 기본 타입
+// (nil, bool, 정수, float64, string, []string, map[string]string, map[string]any, []map[string]any, *int64)만 지원합니다.
+// 맵 키는 정렬해 출력이 결정적입니다.
+func appendMsgpack(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int64:
+		return appendMsgpackInt(b, v), nil
+	case *int64:
+		if v == nil {
+			return append(b, 0xc0), nil
+		}
+		return appendMsgpackInt(b, *v), nil
+	case float64:
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case string:
+		return appendMsgpackString(b, v), nil
+	case []string:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, s := range v {
+			b = appendMsgpackString(b, s)
+		}
+		return b, nil
+	case map[string]string:
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		for _, key := range sortedKeys(v) {
+			b = appendMsgpackString(b, key)
+			b = appendMsgpackString(b, v[key])
+		}
+		return b, nil
+	case map[string]any:
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		for _, key := range sortedKeys(v) {
+			b = appendMsgpackString(b, key)
+			var err error
+			if b, err = appendMsgpack(b, v[key]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return b, nil
+	case []map[string]any:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for i, entry := range v {
+			var err error
+			if b, err = appendMsgpack(b, entry); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+}
+
+// appendMsgpackInt는 정수를 가장 짧은 MessagePack 정수 형식으로 덧붙입니다.
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128:
+		return append(b, byte(n))
+	case n >= 0 && n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n >= 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	case n >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// appendMsgpackString은 문자열을 MessagePack str 형식으로 덧붙입니다.
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgpackHeader는 배열/맵의 길이 헤더를 덧붙입니다. fix는 4비트 길이 형식, w16/w32는 16/32비트 길이 형식의 태그입니다.
+func appendMsgpackHeader(b []byte, n int, fix, w16, w32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, w16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, w32), uint32(n))
+	}
+}
+
+// sortedKeys는 맵의 키를 정렬해 반환합니다.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppendMsgpackMapSlice(t *testing.T) {
+	got, err := appendMsgpack(nil, map[string]any{
+		"fallbacks": []map[string]any{{"proxyId": "a"}, {}},
+	})
+	if err != nil {
+		t.Fatalf("appendMsgpack: %v", err)
+	}
+	want := []byte{
+		0x81, 0xa9, 'f', 'a', 'l', 'l', 'b', 'a', 'c', 'k', 's',
+		0x92,
+		0x81, 0xa7, 'p', 'r', 'o', 'x', 'y', 'I', 'd', 0xa1, 'a',
+		0x80,
+	}
+	if string(got) != string(want) {
+		t.Errorf("appendMsgpack = % x, want % x", got, want)
+	}
+}
+
+func TestGetNextProxyFallbacksAsMsgpack(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 3)
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+
+	req := httptest.NewRequest(http.MethodGet, "/proxy/next?fallbacks=2", nil)
+	req.Header.Set("Accept", msgpackContentType)
+	rec := httptest.NewRecorder()
+	handleGetNextProxy(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != msgpackContentType {
+		t.Errorf("Content-Type = %q, want %q (the response fell back to JSON)", got, msgpackContentType)
+	}
+}
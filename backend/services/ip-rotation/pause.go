@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"log"
+)
+
+// ErrServicePaused는 관리자가 로테이션을 일시 중지한 동안 프록시 선택이 반환하는 오류입니다.
+var ErrServicePaused = errors.New("rotation service is paused; no proxies are handed out until it is resumed")
+
+// Pause는 프록시 선택을 중지합니다. 풀 상태와 결과 기록은 그대로 유지됩니다.
+// 상태가 바뀌었으면 true를 반환합니다.
+func (p *IPPool) Pause() bool {
+	if !p.paused.CompareAndSwap(false, true) {
+		return false
+	}
+	log.Printf("[IP-ROTATION] Rotation paused: proxy selection disabled")
+	return true
+}
+
+// Resume은 Pause로 중지한 프록시 선택을 다시 시작합니다. 상태가 바뀌었으면 true를 반환합니다.
+func (p *IPPool) Resume() bool {
+	if !p.paused.CompareAndSwap(true, false) {
+		return false
+	}
+	log.Printf("[IP-ROTATION] Rotation resumed: proxy selection enabled")
+	return true
+}
+
+// Paused는 로테이션이 일시 중지되었는지 반환합니다.
+func (p *IPPool) Paused() bool {
+	return p.paused.Load()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// saveErrorLogInterval은 같은 저장 오류가 반복될 때 로그를 다시 남기기까지의 최소 간격입니다.
+const saveErrorLogInterval = 5 * time.Minute
+
+// persistenceStatus는 최근 상태 저장 결과를 추적합니다. 풀 잠금과 별도로 자체 mu로 보호됩니다.
+type persistenceStatus struct {
+	mu            sync.Mutex
+	lastSuccess   time.Time
+	lastFailure   time.Time
+	lastError     string
+	failures      int // consecutive failed saves since the last success
+	lastLoggedAt  time.Time
+	lastLoggedErr string
+	suppressed    int // identical failures not logged since lastLoggedAt
+}
+
+// recordSaveResult는 저장 시도 결과를 기록합니다.
+func (s *persistenceStatus) recordSaveResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if err == nil {
+		s.lastSuccess = now
+		s.failures = 0
+		s.lastError = ""
+		return
+	}
+	s.lastFailure = now
+	s.lastError = err.Error()
+	s.failures++
+}
+
+// logSaveFailure는 자동 저장 실패를 로그로 남기되, 같은 오류는 saveErrorLogInterval마다 한 번만 기록하고
+// 그 사이에 생략된 횟수를 함께 출력합니다.
+func (s *persistenceStatus) logSaveFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := err.Error()
+	if msg == s.lastLoggedErr && time.Since(s.lastLoggedAt) < saveErrorLogInterval {
+		s.suppressed++
+		return
+	}
+	if s.suppressed > 0 {
+		log.Printf("[IP-ROTATION] Auto-save failed: %v (%d similar failures suppressed, consecutive_failures=%d)", err, s.suppressed, s.failures)
+	} else {
+		log.Printf("[IP-ROTATION] Auto-save failed: %v (consecutive_failures=%d)", err, s.failures)
+	}
+	s.lastLoggedAt = time.Now()
+	s.lastLoggedErr = msg
+	s.suppressed = 0
+}
+
+// logRecovery는 실패 후 첫 저장 성공 시 복구 로그를 남기고 로그 억제 상태를 초기화합니다.
+func (s *persistenceStatus) logRecovery(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastLoggedErr == "" {
+		return
+	}
+	log.Printf("[IP-ROTATION] Auto-save recovered: path=%s", path)
+	s.lastLoggedErr = ""
+	s.lastLoggedAt = time.Time{}
+	s.suppressed = 0
+}
+
+// snapshot은 /health와 GetPoolStats에 노출할 저장 상태를 반환합니다.
+// configured가 false(PersistencePath 미설정)이면 항상 healthy입니다.
+func (s *persistenceStatus) snapshot(configured bool) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := map[string]any{
+		"configured": configured,
+		"healthy":    !configured || s.failures == 0,
+	}
+	if !s.lastSuccess.IsZero() {
+		status["lastSuccessfulSave"] = s.lastSuccess
+	}
+	if s.failures > 0 {
+		status["consecutiveFailures"] = s.failures
+		status["lastFailure"] = s.lastFailure
+		status["lastError"] = s.lastError
+	}
+	return status
+}
+
+// PersistenceHealthy는 마지막 상태 저장 시도가 성공했는지(또는 영속화가 꺼져 있는지) 반환합니다.
+func (p *IPPool) PersistenceHealthy() bool {
+	p.mu.RLock()
+	configured := p.config.PersistencePath != ""
+	p.mu.RUnlock()
+
+	p.persistence.mu.Lock()
+	defer p.persistence.mu.Unlock()
+	return !configured || p.persistence.failures == 0
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SelectionProbability는 지금 GetNextProxy를 호출했을 때 특정 프록시가 선택될 확률과 그 근거입니다.
+// 라우팅 키, 좌표 같은 요청별 옵션이 없는 기본 선택을 기준으로 합니다.
+type SelectionProbability struct {
+	ProxyID       string           `json:"proxyId"`
+	Strategy      RotationStrategy `json:"strategy"`
+	Probability   float64          `json:"probability"`
+	Candidates    int              `json:"candidates"`    // proxies the strategy chooses among after filtering
+	Deterministic bool             `json:"deterministic"` // probability is exactly 0 or 1 for the next call
+	Explanation   string           `json:"explanation"`
+}
+
+// SelectionProbability는 현재 전략과 풀 상태에서 id 프록시의 선택 확률을 계산합니다.
+// 라운드로빈 커서 등 풀 상태는 바꾸지 않습니다.
+func (p *IPPool) SelectionProbability(id string) (*SelectionProbability, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy, ok := p.proxies[id]
+	if !ok {
+		return nil, ErrProxyNotFound
+	}
+	strategy := p.config.Strategy
+	if !validStrategy(strategy) {
+		strategy = StrategyRoundRobin
+	}
+	result := &SelectionProbability{ProxyID: id, Strategy: strategy}
+
+	candidates, err := p.candidatesLocked(strategy, SelectOptions{})
+	if err != nil {
+		result.Deterministic = true
+		result.Explanation = "no proxy can be selected: " + err.Error()
+		return result, nil
+	}
+	result.Candidates = len(candidates)
+	if !slices.Contains(candidates, proxy) {
+		result.Deterministic = true
+		result.Explanation = p.exclusionReasonLocked(proxy)
+		return result, nil
+	}
+
+	n := float64(len(candidates))
+	switch strategy {
+	case StrategyRandom:
+		result.Probability = 1 / n
+		result.Explanation = fmt.Sprintf("uniform choice among %d candidates", len(candidates))
+	case StrategyWeighted:
+		result.Probability, result.Explanation = p.weightedProbabilityLocked(proxy, candidates)
+	case StrategyCaptchaAware:
+		fewest := p.fewestRecentCaptchas(candidates)
+		if slices.Contains(fewest, proxy) {
+			result.Probability = 1 / float64(len(fewest))
+		}
+		result.Explanation = fmt.Sprintf("uniform choice among the %d of %d candidates with the fewest captchas in the last %s",
+			len(fewest), len(candidates), p.captchaWindowDuration())
+	case StrategyGeographic:
+		if preferred := preferredCountryProxies(candidates, p.config.PreferredCountry); len(preferred) > 0 {
+			if slices.Contains(preferred, proxy) {
+				result.Probability = 1 / float64(len(preferred))
+			}
+			result.Explanation = fmt.Sprintf("uniform choice among the %d candidates in preferred country %s",
+				len(preferred), p.config.PreferredCountry)
+			break
+		}
+		result.Deterministic = true
+		result.Probability, result.Explanation = p.nextRoundRobinLocked(proxy, candidates,
+			"no candidate in the preferred country, so geographic falls back to round robin")
+	case StrategyLeastUsed:
+		result.Deterministic = true
+		if p.selectLeastUsed(candidates) == proxy {
+			result.Probability = 1
+			result.Explanation = "least_used is deterministic: this proxy has the lowest usage"
+		} else {
+			result.Explanation = "least_used is deterministic: another candidate has lower usage"
+		}
+	case StrategyConsistentHash:
+		result.Deterministic = true
+		result.Probability, result.Explanation = p.nextRoundRobinLocked(proxy, candidates,
+			"without a routing key consistent_hash falls back to round robin; with a key the hash ring fixes the proxy")
+	case StrategyCostAware:
+		cheapest := p.cheapestComparable(candidates)
+		if slices.Contains(cheapest, proxy) {
+			result.Probability = 1 / float64(len(cheapest))
+		}
+		result.Explanation = fmt.Sprintf("uniform choice among the %d of %d candidates that are cheapest within %g points of the best success rate",
+			len(cheapest), len(candidates), p.costQualityTolerance())
+	case StrategyThompson:
+		result.Probability, result.Explanation = p.thompsonProbabilityLocked(proxy, candidates)
+	case StrategyRoundRobin:
+		result.Deterministic = true
+		result.Probability, result.Explanation = p.nextRoundRobinLocked(proxy, candidates, "round_robin is deterministic")
+	default:
+		// Strategies added through RegisterStrategy don't describe their distribution
+		result.Explanation = fmt.Sprintf("proxy is one of %d candidates; strategy %s does not report selection probabilities",
+			len(candidates), strategy)
+	}
+	return result, nil
+}
+
+// weightedProbabilityLocked는 weighted 전략에서 proxy의 선택 확률을 가중치 비율과 탐색 확률(explorationRate)로 계산합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) weightedProbabilityLocked(proxy *ProxyIP, candidates []*ProxyIP) (float64, string) {
+	n := float64(len(candidates))
+	weights := p.cachedWeights(candidates)
+	total := weights.total()
+	i := slices.Index(weights.proxies, proxy)
+	if total <= 0 || i < 0 {
+		return 1 / n, fmt.Sprintf("all weights are zero, so uniform choice among %d candidates", len(candidates))
+	}
+	weight := weights.prefix[i]
+	if i > 0 {
+		weight -= weights.prefix[i-1]
+	}
+	explore := p.config.ExplorationRate
+	probability := explore/n + (1-explore)*weight/total
+	return probability, fmt.Sprintf("weight %.2f of %.2f total across %d candidates, explorationRate %.2f",
+		weight, total, len(candidates), explore)
+}
+
+// nextRoundRobinLocked는 라운드로빈 커서를 되돌려 놓은 채로 다음에 선택될 프록시가 proxy인지 확인합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) nextRoundRobinLocked(proxy *ProxyIP, candidates []*ProxyIP, why string) (float64, string) {
+	index := p.index
+	next := p.selectRoundRobin(candidates)
+	p.index = index
+	if next == proxy {
+		return 1, why + ": this proxy is next"
+	}
+	return 0, why + ": next is " + next.ID
+}
+
+// exclusionReasonLocked는 프록시가 선택 후보에서 빠진 이유를 설명합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) exclusionReasonLocked(proxy *ProxyIP) string {
+	switch {
+	case proxy.Removed:
+		return "not a candidate: removed"
+	case !proxy.Enabled:
+		return "not a candidate: disabled (" + proxy.DisabledReason + ")"
+	case proxy.Draining:
+		return "not a candidate: draining"
+	case proxy.expired(time.Now()):
+		return "not a candidate: expired"
+	case proxy.outsideActiveHours(time.Now()):
+		return "not a candidate: outside active hours"
+	case proxy.HealthStatus == "unhealthy":
+		return "not a candidate: unhealthy"
+	case proxy.HealthStatus == HealthStatusDegraded:
+		return "not a candidate: degraded (healthy proxies in the same tier are preferred)"
+	case p.quotaExhausted(proxy):
+		return "not a candidate: daily quota exhausted"
+	case proxy.overRateLimit(time.Now()):
+		return "not a candidate: per-minute request limit reached"
+	default:
+		return "not a candidate: filtered out by failure backoff, eliteOnly, a higher priority tier, ASN anti-affinity or country targets"
+	}
+}
+
+// preferredCountryProxies는 country(대소문자 무시)에 있는 프록시만 반환합니다. country가 비어 있으면 nil입니다.
+func preferredCountryProxies(proxies []*ProxyIP, country string) []*ProxyIP {
+	if country == "" {
+		return nil
+	}
+	var matching []*ProxyIP
+	for _, proxy := range proxies {
+		if strings.EqualFold(proxy.Country, country) {
+			matching = append(matching, proxy)
+		}
+	}
+	return matching
+}
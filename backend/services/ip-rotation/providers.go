@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// unassignedProvider는 Provider가 비어 있는 프록시를 묶는 그룹 이름입니다.
+const unassignedProvider = "unassigned"
+
+// ProviderStats는 한 프록시 공급자(provider)에 속한 프록시들의 집계 통계입니다.
+type ProviderStats struct {
+	Provider     string `json:"provider"`
+	Total        int    `json:"total"`
+	Enabled      int    `json:"enabled"`
+	Healthy      int    `json:"healthy"`
+	Degraded     int    `json:"degraded"`
+	Unhealthy    int    `json:"unhealthy"`
+	TotalUsage   int64  `json:"totalUsage"`
+	TotalSuccess int64  `json:"totalSuccess"`
+	TotalFail    int64  `json:"totalFail"`
+	TotalCaptcha int64  `json:"totalCaptcha"`
+	SuccessRate  string `json:"successRate"`
+	CaptchaRate  string `json:"captchaRate"`
+	AvgLatencyMs int64  `json:"avgLatencyMs"` // weighted by each proxy's recorded results
+
+	EstimatedSpend float64 `json:"estimatedSpend"` // sum of usageCount × costPerRequest
+}
+
+// GetProviderStats는 soft-removed가 아닌 프록시를 Provider별로 묶어 집계한 통계를 이름순으로 반환합니다.
+func (p *IPPool) GetProviderStats() []ProviderStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	groups := make(map[string]*ProviderStats)
+	latencyWeight := make(map[string]int64)
+	latencySum := make(map[string]int64)
+	for _, proxy := range p.proxies {
+		if proxy.Removed {
+			continue
+		}
+		name := proxy.Provider
+		if name == "" {
+			name = unassignedProvider
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &ProviderStats{Provider: name}
+			groups[name] = g
+		}
+
+		g.Total++
+		if proxy.Enabled {
+			g.Enabled++
+		}
+		switch proxy.HealthStatus {
+		case "healthy":
+			g.Healthy++
+		case HealthStatusDegraded:
+			g.Degraded++
+		case "unhealthy":
+			g.Unhealthy++
+		}
+		success, fail := proxy.SuccessCount.Load(), proxy.FailCount.Load()
+		g.TotalUsage += proxy.UsageCount.Load()
+		g.TotalSuccess += success
+		g.TotalFail += fail
+		g.TotalCaptcha += proxy.CaptchaCount.Load()
+		g.EstimatedSpend += proxy.CostPerRequest * float64(proxy.UsageCount.Load())
+		latencyWeight[name] += success + fail
+		latencySum[name] += proxy.AvgLatencyMs.Load() * (success + fail)
+	}
+
+	stats := make([]ProviderStats, 0, len(groups))
+	for name, g := range groups {
+		successRate := float64(0)
+		if g.TotalSuccess+g.TotalFail > 0 {
+			successRate = float64(g.TotalSuccess) / float64(g.TotalSuccess+g.TotalFail) * 100
+		}
+		captchaRate := float64(0)
+		if g.TotalUsage > 0 {
+			captchaRate = float64(g.TotalCaptcha) / float64(g.TotalUsage) * 100
+		}
+		g.SuccessRate = fmt.Sprintf("%.2f%%", successRate)
+		g.CaptchaRate = fmt.Sprintf("%.2f%%", captchaRate)
+		if latencyWeight[name] > 0 {
+			g.AvgLatencyMs = latencySum[name] / latencyWeight[name]
+		}
+		stats = append(stats, *g)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Provider < stats[j].Provider })
+	return stats
+}
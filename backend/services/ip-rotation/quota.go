@@ -0,0 +1,145 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// 비활성화 사유(DisabledReason) 값입니다.
+const (
+	DisabledReasonMaxFailures = "max_failures"
+	DisabledReasonQuota       = "quota_exhausted"
+	DisabledReasonAdmin       = "admin"
+	DisabledReasonQuarantine  = "quarantine"
+	DisabledReasonRemoved     = "removed"
+)
+
+// usageCap은 프록시에 적용되는 일일 사용량 한도를 반환합니다. 0이면 무제한입니다.
+// 프록시별 MaxUsageCount가 설정에 우선합니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) usageCap(proxy *ProxyIP) int64 {
+	if proxy.MaxUsageCount > 0 {
+		return proxy.MaxUsageCount
+	}
+	return p.config.DefaultMaxUsage
+}
+
+// quotaExhausted는 프록시가 일일 사용량 한도에 도달했는지 반환합니다. 호출자는 p.mu 잠금을 보유해야 합니다.
+func (p *IPPool) quotaExhausted(proxy *ProxyIP) bool {
+	limit := p.usageCap(proxy)
+	return limit > 0 && proxy.DailyUsage >= limit
+}
+
+// refreshQuota는 프록시의 RemainingQuota 필드를 현재 한도와 사용량으로 갱신합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) refreshQuota(proxy *ProxyIP) {
+	limit := p.usageCap(proxy)
+	if limit <= 0 {
+		proxy.RemainingQuota = nil
+		return
+	}
+	remaining := limit - proxy.DailyUsage
+	if remaining < 0 {
+		remaining = 0
+	}
+	proxy.RemainingQuota = &remaining
+}
+
+// filterUnderQuota는 일일 사용량 한도에 도달하지 않은 프록시만 반환합니다. 호출자는 p.mu 잠금을 보유해야 합니다.
+func (p *IPPool) filterUnderQuota(proxies []*ProxyIP) []*ProxyIP {
+	available := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !p.quotaExhausted(proxy) {
+			available = append(available, proxy)
+		}
+	}
+	return available
+}
+
+// consumeQuota는 선택된 프록시의 일일 사용량을 증가시키고, 한도 도달 시 설정에 따라 일일 리셋까지 비활성화합니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) consumeQuota(proxy *ProxyIP) {
+	proxy.DailyUsage++
+	p.refreshQuota(proxy)
+	if p.quotaExhausted(proxy) {
+		// The proxy drops out of the selectable set
+		p.invalidateWeights()
+	}
+	if p.config.DisableOnQuota && p.quotaExhausted(proxy) {
+		proxy.Enabled = false
+		proxy.DisabledAt = time.Now()
+		proxy.DisabledReason = DisabledReasonQuota
+		p.recordEvent(proxy.ID, EventDisabled, "usage quota exhausted", 0)
+		log.Printf("[IP-ROTATION] Proxy disabled until daily reset: id=%s daily_usage=%d", proxy.ID, proxy.DailyUsage)
+	}
+}
+
+// ResetDailyUsage는 모든 프록시의 일일 사용량을 초기화하고, 한도 초과로 비활성화된 프록시를 재활성화합니다.
+func (p *IPPool) ResetDailyUsage() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reenabled := 0
+	for id, proxy := range p.proxies {
+		proxy.DailyUsage = 0
+		if !proxy.Enabled && proxy.DisabledReason == DisabledReasonQuota {
+			proxy.Enabled = true
+			proxy.DisabledAt = time.Time{}
+			proxy.DisabledReason = ""
+			p.recordEvent(id, EventEnabled, "daily usage reset", 0)
+			reenabled++
+		}
+		p.refreshQuota(proxy)
+	}
+	p.invalidateWeights()
+
+	log.Printf("[IP-ROTATION] Daily usage reset (re-enabled %d proxies)", reenabled)
+	p.autoSave()
+}
+
+// nextDailyReset은 now 이후 가장 가까운 UTC 기준 hour시 정각을 반환합니다.
+func nextDailyReset(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// StartDailyResetScheduler는 매일 DailyResetHourUTC 시각에 ResetDailyUsage를 실행하는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartDailyResetScheduler() {
+	p.mu.Lock()
+	if p.dailyResetRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.dailyResetRunning = true
+	hour := p.config.DailyResetHourUTC
+	stop := p.stopDailyReset
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Daily usage reset scheduler started (hour=%02d:00 UTC)", hour)
+		for {
+			timer := time.NewTimer(time.Until(nextDailyReset(time.Now(), hour)))
+			select {
+			case <-timer.C:
+				p.ResetDailyUsage()
+			case <-stop:
+				timer.Stop()
+				log.Printf("[IP-ROTATION] Daily usage reset scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopDailyResetScheduler는 일일 사용량 리셋 루틴을 중지합니다.
+func (p *IPPool) StopDailyResetScheduler() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dailyResetRunning {
+		close(p.stopDailyReset)
+		p.dailyResetRunning = false
+		p.stopDailyReset = make(chan struct{})
+	}
+}
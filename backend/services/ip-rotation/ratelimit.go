@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// rateLimitWindow은 MaxRequestsPerMinute가 세는 구간입니다.
+const rateLimitWindow = time.Minute
+
+// ErrRateLimited는 모든 후보 프록시가 분당 요청 한도(MaxRequestsPerMinute)에 도달했을 때 반환됩니다.
+// 가장 오래된 선택이 구간을 벗어나면 다시 선택할 수 있으므로 selectionWaitTimeout 대기 대상입니다.
+var ErrRateLimited = errors.New("all enabled proxies are at their per-minute request limit")
+
+// requestWindow는 프록시의 최근 선택 시각을 보관하는 슬라이딩 윈도입니다. 조회 경로는 p.mu 읽기 잠금만
+// 보유할 수 있으므로 자체 뮤텍스로 보호합니다.
+type requestWindow struct {
+	mu    sync.Mutex
+	times []time.Time // oldest first
+}
+
+// add는 선택 시각을 추가합니다. limit을 넘는 오래된 시각은 한도 판단에 쓰이지 않으므로 버립니다.
+func (w *requestWindow) add(at time.Time, limit int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if limit > 0 && int64(len(w.times)) >= limit {
+		w.times = w.times[int64(len(w.times))-limit+1:]
+	}
+	w.times = append(w.times, at)
+}
+
+// countSince는 since 이후의 선택 수를 반환하며, 그보다 오래된 시각은 정리합니다.
+func (w *requestWindow) countSince(since time.Time) int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := 0
+	for i < len(w.times) && w.times[i].Before(since) {
+		i++
+	}
+	w.times = w.times[i:]
+	return int64(len(w.times))
+}
+
+// overRateLimit은 프록시가 최근 1분 동안 MaxRequestsPerMinute만큼 선택되었는지 반환합니다. 한도가 0이면 항상 false입니다.
+func (p *ProxyIP) overRateLimit(now time.Time) bool {
+	if p.MaxRequestsPerMinute <= 0 {
+		return false
+	}
+	return p.recentRequests.countSince(now.Add(-rateLimitWindow)) >= p.MaxRequestsPerMinute
+}
+
+// noteRequest는 한도가 있는 프록시의 선택 시각을 기록합니다.
+func (p *ProxyIP) noteRequest(now time.Time) {
+	if p.MaxRequestsPerMinute > 0 {
+		p.recentRequests.add(now, p.MaxRequestsPerMinute)
+	}
+}
+
+// filterUnderRateLimit은 분당 요청 한도에 도달한 프록시를 후보에서 제외합니다. 백오프와 달리 모든 후보가 한도에
+// 걸려도 되돌리지 않아, 라운드로빈 부하가 몰려도 한 IP가 차단을 부르는 속도를 넘지 않습니다.
+func filterUnderRateLimit(proxies []*ProxyIP) []*ProxyIP {
+	now := time.Now()
+	available := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !proxy.overRateLimit(now) {
+			available = append(available, proxy)
+		}
+	}
+	return available
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"log"
+	"slices"
+	"time"
+)
+
+// StartOrderRebalancer는 RebalanceOrderMinutes마다 라운드로빈 순서를 재배치하는 백그라운드 루틴을 시작합니다.
+func (p *IPPool) StartOrderRebalancer() {
+	p.mu.Lock()
+	if p.rebalanceRunning {
+		p.mu.Unlock()
+		return
+	}
+	p.rebalanceRunning = true
+	minutes := p.config.RebalanceOrderMinutes
+	stop := p.stopRebalance
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Round-robin order rebalancer started (interval=%dm)", minutes)
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.rebalanceOrder()
+			case <-stop:
+				log.Printf("[IP-ROTATION] Round-robin order rebalancer stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopOrderRebalancer는 라운드로빈 순서 재배치 루틴을 중지합니다.
+func (p *IPPool) StopOrderRebalancer() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rebalanceRunning {
+		close(p.stopRebalance)
+		p.rebalanceRunning = false
+		p.stopRebalance = make(chan struct{})
+	}
+}
+
+// rebalanceOrder는 건강한 프록시 사이에 새로 추가된(아직 점검 전) 프록시를, 다시 그 사이에 unhealthy/비활성 프록시를
+// 고르게 섞어 라운드로빈이 나쁜 프록시를 연달아 만나지 않게 합니다. 각 그룹 안의 상대 순서는 유지하고,
+// 마지막으로 제공된 프록시 다음부터 이어가므로 순환의 공정성이 유지됩니다. 순서가 바뀌었으면 true를 반환합니다.
+func (p *IPPool) rebalanceOrder() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var healthy, fresh, weak []string
+	for _, id := range p.order {
+		proxy := p.proxies[id]
+		switch {
+		case !proxy.Enabled || proxy.Removed || proxy.Draining || proxy.HealthStatus == "unhealthy":
+			weak = append(weak, id)
+		case proxy.HealthStatus == "healthy":
+			healthy = append(healthy, id)
+		default:
+			// Unchecked and degraded proxies
+			fresh = append(fresh, id)
+		}
+	}
+	order := interleave(interleave(healthy, fresh), weak)
+	if slices.Equal(order, p.order) {
+		return false
+	}
+
+	lastServed := p.lastServedLocked()
+	p.order = order
+	p.index = p.resumeIndexLocked(lastServed, p.index)
+	p.autoSave()
+	log.Printf("[IP-ROTATION] Round-robin order rebalanced: healthy=%d new=%d weak=%d",
+		len(healthy), len(fresh), len(weak))
+	return true
+}
+
+// interleave는 extra 항목을 base 사이에 가능한 한 고르게 끼워 넣은 새 목록을 반환합니다. 두 목록의 순서는 유지됩니다.
+func interleave(base, extra []string) []string {
+	total := len(base) + len(extra)
+	merged := make([]string, 0, total)
+	b, e := 0, 0
+	for i := 0; i < total; i++ {
+		// Take from extra when it has fallen behind its even share of positions
+		if e < len(extra) && (b == len(base) || (2*e+1)*total <= (2*i+1)*len(extra)) {
+			merged = append(merged, extra[e])
+			e++
+		} else {
+			merged = append(merged, base[b])
+			b++
+		}
+	}
+	return merged
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// longestFailureStreak는 라운드로빈으로 순서를 한 바퀴 돌 때(끝에서 처음으로 이어짐) unhealthy 프록시를
+// 연달아 만나는 최대 횟수를 반환합니다.
+func longestFailureStreak(pool *IPPool) int {
+	n := len(pool.order)
+	longest, run := 0, 0
+	for i := 0; i < 2*n; i++ {
+		if pool.proxies[pool.order[i%n]].HealthStatus == "unhealthy" {
+			run++
+			longest = max(longest, min(run, n))
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+func TestRebalanceOrderReducesFailureStreaks(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 12)
+	pool.mu.Lock()
+	for i, id := range pool.order {
+		// The last four proxies went bad together, e.g. one provider's subnet
+		if i >= 8 {
+			pool.proxies[id].HealthStatus = "unhealthy"
+		} else {
+			pool.proxies[id].HealthStatus = "healthy"
+		}
+	}
+	before := longestFailureStreak(pool)
+	members := slices.Clone(pool.order)
+	pool.mu.Unlock()
+
+	if !pool.rebalanceOrder() {
+		t.Fatal("rebalanceOrder reported no change for a clustered order")
+	}
+
+	pool.mu.RLock()
+	after := longestFailureStreak(pool)
+	got := slices.Clone(pool.order)
+	pool.mu.RUnlock()
+	if before != 4 || after != 1 {
+		t.Errorf("longest failure streak: before=%d after=%d, want 4 then 1", before, after)
+	}
+	// Rebalancing only reorders; every proxy keeps exactly one slot
+	slices.Sort(got)
+	slices.Sort(members)
+	if !slices.Equal(got, members) {
+		t.Errorf("order members changed: %v, want %v", got, members)
+	}
+
+	if pool.rebalanceOrder() {
+		t.Error("a second rebalance changed an already balanced order")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import "log"
+
+// maxRecordBatch는 /proxy/record-batch 요청 하나에 담을 수 있는 최대 결과 수입니다.
+const maxRecordBatch = 1000
+
+// 일괄 기록 결과 항목의 상태입니다.
+const (
+	RecordStatusRecorded  = "recorded"
+	RecordStatusDuplicate = "duplicate" // requestId already seen within recordDedupWindow
+	RecordStatusInvalid   = "invalid"
+	RecordStatusNotFound  = "not_found"
+)
+
+// RecordResult는 크롤러가 보고하는 프록시 사용 결과 한 건입니다.
+type RecordResult struct {
+	ProxyID   string `json:"proxyId"`
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latencyMs"`
+	Reason    string `json:"reason"`
+	RequestID string `json:"requestId"` // optional idempotency key for client retries
+	Fallback  bool   `json:"fallback"`  // proxy came from /proxy/next fallbacks; this result also counts as its use
+	PrimaryID string `json:"primaryId"` // with fallback: the unused primary whose lease this result closes
+}
+
+// RecordAck는 일괄 기록에서 결과 한 건이 어떻게 처리되었는지 나타냅니다. Index는 요청 배열에서의 위치입니다.
+type RecordAck struct {
+	Index     int    `json:"index"`
+	ProxyID   string `json:"proxyId,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RecordBatch는 여러 결과를 순서대로 기록하고 항목별 처리 결과를 반환합니다. 각 결과는 /proxy/record와 같이
+// 원자 카운터로 기록되므로 풀 전체 쓰기 잠금을 잡지 않습니다. 한 항목의 오류는 나머지 항목에 영향을 주지 않습니다.
+func (p *IPPool) RecordBatch(results []RecordResult) []RecordAck {
+	acks := make([]RecordAck, len(results))
+	recorded := 0
+	for i, result := range results {
+		ack := RecordAck{Index: i, ProxyID: result.ProxyID, RequestID: result.RequestID}
+		switch {
+		case result.ProxyID == "":
+			ack.Status = RecordStatusInvalid
+			ack.Error = "proxyId is required"
+		case !p.hasProxy(result.ProxyID):
+			ack.Status = RecordStatusNotFound
+			ack.Error = ErrProxyNotFound.Error()
+		case p.IsDuplicateRecord(result.RequestID):
+			ack.Status = RecordStatusDuplicate
+		default:
+			if result.Fallback {
+				p.ConfirmFallback(result.ProxyID, result.PrimaryID)
+			}
+			if result.Success {
+				p.RecordSuccess(result.ProxyID, result.LatencyMs)
+			} else {
+				p.RecordFailure(result.ProxyID, result.Reason)
+			}
+			ack.Status = RecordStatusRecorded
+			recorded++
+		}
+		acks[i] = ack
+	}
+	log.Printf("[IP-ROTATION] Batch results recorded: total=%d recorded=%d", len(results), recorded)
+	return acks
+}
+
+// hasProxy는 id의 프록시가 풀에 있는지 반환합니다.
+func (p *IPPool) hasProxy(id string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.proxies[id]
+	return ok
+}
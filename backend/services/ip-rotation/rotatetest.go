@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RotationPreview는 로테이션 테스트의 한 회차 결과입니다.
+type RotationPreview struct {
+	Iteration    int    `json:"iteration"`
+	ProxyID      string `json:"proxyId,omitempty"`
+	Address      string `json:"address,omitempty"`
+	Protocol     string `json:"protocol,omitempty"`
+	Country      string `json:"country,omitempty"`
+	UsageCount   int64  `json:"usageCount"`
+	SuccessRate  string `json:"successRate,omitempty"`
+	HealthStatus string `json:"healthStatus,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// SimulateRotation은 count회의 선택을 실제 선택과 같은 후보 필터/전략으로 수행하되, 완료 후 사용량과
+// 라운드로빈 커서를 원래대로 되돌려 운영 통계(사용량, 일일 할당량, 선택 메트릭, 가중치)를 오염시키지 않습니다.
+// 회차 사이에는 사용량이 누적되므로 least_used나 할당량 동작도 실제와 같이 재현됩니다.
+// 전체가 한 번의 잠금 안에서 실행되어 동시 선택과 섞이지 않습니다.
+func (p *IPPool) SimulateRotation(opts SelectOptions, count int) []RotationPreview {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	strategy := p.strategyForLocked(opts)
+
+	type usageSnapshot struct {
+		usage int64
+		daily int64
+	}
+	savedIndex := p.index
+	saved := make(map[*ProxyIP]usageSnapshot)
+	defer func() {
+		for proxy, s := range saved {
+			proxy.UsageCount.Store(s.usage)
+			proxy.DailyUsage = s.daily
+		}
+		p.index = savedIndex
+		p.invalidateWeights()
+	}()
+
+	previews := make([]RotationPreview, 0, count)
+	for i := 0; i < count; i++ {
+		preview := RotationPreview{Iteration: i + 1}
+		proxy, err := p.pickProxyLocked(strategy, opts)
+		if err != nil {
+			preview.Error = err.Error()
+			previews = append(previews, preview)
+			continue
+		}
+		if _, ok := saved[proxy]; !ok {
+			saved[proxy] = usageSnapshot{usage: proxy.UsageCount.Load(), daily: proxy.DailyUsage}
+		}
+		// Simulated usage lets later iterations see earlier picks; rolled back by the deferred restore
+		proxy.UsageCount.Add(1)
+		proxy.DailyUsage++
+		p.invalidateWeights()
+
+		preview.ProxyID = proxy.ID
+		preview.Address = proxy.Address
+		preview.Protocol = proxy.Protocol
+		preview.Country = proxy.Country
+		preview.UsageCount = proxy.UsageCount.Load()
+		preview.SuccessRate = fmt.Sprintf("%.2f%%", calculateSuccessRate(proxy))
+		preview.HealthStatus = proxy.HealthStatus
+		previews = append(previews, preview)
+	}
+	return previews
+}
+
+// rotateTestLimiter는 로테이션 테스트 API의 호출 간격을 제한합니다.
+type rotateTestLimiter struct {
+	mu      sync.Mutex
+	last    time.Time
+	minWait time.Duration
+}
+
+// Allow는 마지막 허용 이후 minWait가 지났으면 true를 반환하고 시각을 갱신합니다.
+func (l *rotateTestLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if !l.last.IsZero() && now.Sub(l.last) < l.minWait {
+		return false
+	}
+	l.last = now
+	return true
+}
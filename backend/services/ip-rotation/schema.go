@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// currentSchemaVersion은 SaveToFile이 기록하는 상태 파일 스키마 버전입니다. ProxyIP/IPPoolState의 저장 형식이
+// 바뀌면(필드 이름 변경, 의미 변경 등) 버전을 올리고 stateMigrations에 이전 버전을 변환하는 함수를 추가합니다.
+const currentSchemaVersion = 1
+
+// stateMigration은 한 버전의 상태 문서를 바로 다음 버전 형식으로 바꿉니다. 문서는 최상위 필드별 원본 JSON입니다.
+type stateMigration func(doc map[string]json.RawMessage) error
+
+// stateMigrations[v]는 버전 v의 상태 문서를 v+1로 올립니다.
+var stateMigrations = map[int]stateMigration{
+	0: migrateStateV0,
+}
+
+// migrateStateV0은 schemaVersion이 도입되기 전에 저장된 파일을 변환합니다. 저장 형식은 버전 1과 같으므로
+// 버전만 올라갑니다.
+func migrateStateV0(doc map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateState는 상태 파일을 currentSchemaVersion 형식으로 변환합니다.
+// 더 새로운 버전은 allowNewer가 false면 ErrUnsupportedSchema로 거부하고, true면 알 수 없는 필드를 버린 채 그대로 읽습니다.
+func migrateState(data []byte, allowNewer bool) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	version := 0
+	if raw, ok := doc["schemaVersion"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+			return nil, fmt.Errorf("invalid schemaVersion: %s", raw)
+		}
+	}
+
+	switch {
+	case version == currentSchemaVersion:
+		return data, nil
+	case version > currentSchemaVersion:
+		if !allowNewer {
+			return nil, fmt.Errorf("%w: file version %d, supported up to %d",
+				ErrUnsupportedSchema, version, currentSchemaVersion)
+		}
+		log.Printf("[IP-ROTATION] Loading state schema %d with a build that supports %d; unknown fields will be dropped on the next save",
+			version, currentSchemaVersion)
+		return data, nil
+	}
+
+	for v := version; v < currentSchemaVersion; v++ {
+		migrate, ok := stateMigrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from state schema %d", v)
+		}
+		if err := migrate(doc); err != nil {
+			return nil, fmt.Errorf("state schema migration %d->%d failed: %w", v, v+1, err)
+		}
+	}
+	doc["schemaVersion"] = json.RawMessage(fmt.Sprint(currentSchemaVersion))
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[IP-ROTATION] State file migrated from schema %d to %d", version, currentSchemaVersion)
+	return migrated, nil
+}
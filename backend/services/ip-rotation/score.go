@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 점수 계산의 기본값입니다. ScoreWeights의 항목이 모두 0이면 기본 가중치를 사용합니다.
+const (
+	defaultScoreSuccessWeight = 0.6
+	defaultScoreLatencyWeight = 0.15
+	defaultScoreCaptchaWeight = 0.15
+	defaultScoreRecencyWeight = 0.1
+
+	scoreLatencyCeilingMs = 5000             // average latency at (or above) which the latency component is 0
+	scoreRecencyHalfLife  = 30 * time.Minute // time after a failure for the recency component to recover halfway
+)
+
+// ScoreWeights는 프록시 점수(ProxyIP.Score)를 이루는 네 요소의 가중치입니다. 상대 비율만 의미가 있으며,
+// 모두 0이면 기본값(0.6/0.15/0.15/0.1)을 씁니다.
+//
+// 각 요소는 0..1 값이고, 점수는 가중 평균에 100을 곱한 값(0..100)입니다.
+//   - success: 성공/(성공+실패). 결과가 없으면 newProxyWeight/100
+//   - latency: 1 - 평균 지연시간/5000ms(0 이상). 지연시간 기록이 없으면 1
+//   - captcha: 1 - CAPTCHA 수/(사용 수+1)(0 이상)
+//   - recency: 1 - 0.5^(마지막 실패 후 경과/30분). 실패한 적이 없으면 1
+type ScoreWeights struct {
+	Success float64 `json:"success"`
+	Latency float64 `json:"latency"`
+	Captcha float64 `json:"captcha"`
+	Recency float64 `json:"recency"`
+}
+
+// orDefault는 가중치가 모두 0이면 기본 가중치를 반환합니다.
+func (w ScoreWeights) orDefault() ScoreWeights {
+	if w == (ScoreWeights{}) {
+		return ScoreWeights{
+			Success: defaultScoreSuccessWeight,
+			Latency: defaultScoreLatencyWeight,
+			Captcha: defaultScoreCaptchaWeight,
+			Recency: defaultScoreRecencyWeight,
+		}
+	}
+	return w
+}
+
+// validate는 가중치가 음수가 아닌지 검사합니다.
+func (w ScoreWeights) validate() error {
+	for _, item := range []struct {
+		name  string
+		value float64
+	}{
+		{"success", w.Success},
+		{"latency", w.Latency},
+		{"captcha", w.Captcha},
+		{"recency", w.Recency},
+	} {
+		if item.value < 0 || math.IsNaN(item.value) || math.IsInf(item.value, 0) {
+			return fmt.Errorf("invalid scoreWeights.%s: %g, must be non-negative", item.name, item.value)
+		}
+	}
+	return nil
+}
+
+// parseScoreWeights는 환경 변수 name을 "success:0.6,latency:0.15,captcha:0.15,recency:0.1" 형식의 점수 가중치로 파싱합니다.
+// 빠진 요소는 0입니다. 형식이 잘못되었거나 알 수 없는 요소의 항목은 건너뛰고 env에 기록합니다. 음수는 Validate에서 걸러집니다.
+func parseScoreWeights(env *envConfig, name string) ScoreWeights {
+	var w ScoreWeights
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			env.invalidEntry(name, entry, "want component:weight")
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			env.invalidEntry(name, entry, "weight is not a number")
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(component)) {
+		case "success":
+			w.Success = f
+		case "latency":
+			w.Latency = f
+		case "captcha":
+			w.Captcha = f
+		case "recency":
+			w.Recency = f
+		default:
+			env.invalidEntry(name, entry, "unknown component, must be success, latency, captcha or recency")
+		}
+	}
+	return w
+}
+
+// computeScore는 ScoreWeights 문서의 공식으로 프록시 점수(0..100)를 계산합니다.
+// newWeight는 결과가 없는 프록시의 성공 요소(0..100)입니다.
+func computeScore(proxy *ProxyIP, weights ScoreWeights, newWeight float64, now time.Time) float64 {
+	weights = weights.orDefault()
+
+	success := math.Min(newWeight/100, 1)
+	successes := proxy.SuccessCount.Load()
+	if total := successes + proxy.FailCount.Load(); total > 0 {
+		success = float64(successes) / float64(total)
+	}
+
+	latency := 1.0
+	if avg := proxy.AvgLatencyMs.Load(); avg > 0 {
+		latency = math.Max(0, 1-float64(avg)/scoreLatencyCeilingMs)
+	}
+
+	captcha := math.Max(0, 1-float64(proxy.CaptchaCount.Load())/float64(proxy.UsageCount.Load()+1))
+
+	recency := 1.0
+	if last := proxy.LastFailure.Load(); !last.IsZero() {
+		elapsed := max(now.Sub(last), 0)
+		recency = 1 - math.Exp2(-float64(elapsed)/float64(scoreRecencyHalfLife))
+	}
+
+	sum := weights.Success + weights.Latency + weights.Captcha + weights.Recency
+	score := (weights.Success*success + weights.Latency*latency + weights.Captcha*captcha + weights.Recency*recency) / sum
+	return score * 100
+}
+
+// updateScore는 프록시의 캐시된 점수를 다시 계산합니다. 결과 기록, 통계 초기화, 설정 변경 후, 조회 시와
+// 가중치 캐시를 다시 만들 때(recency가 시간에 따라 회복되므로) 호출됩니다. 점수가 바뀌면 가중치 캐시를 무효화해
+// 목록의 점수와 weighted 선택의 가중치가 어긋나지 않게 합니다. 원자적으로 기록하므로 읽기 잠금만으로 호출할 수 있습니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) updateScore(proxy *ProxyIP, now time.Time) {
+	newWeight, _ := p.selectionWeights()
+	score := computeScore(proxy, p.config.ScoreWeights, newWeight, now)
+	if proxy.Score.Swap(score) != score {
+		p.invalidateWeights()
+	}
+}
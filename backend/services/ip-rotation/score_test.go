@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestParseScoreWeightsReportsInvalidEntries(t *testing.T) {
+	t.Setenv("SCORE_WEIGHTS", "sucess:0.9, latency:0.2,captcha,recency:high,success:0.5,")
+	var env envConfig
+	got := parseScoreWeights(&env, "SCORE_WEIGHTS")
+	if want := (ScoreWeights{Success: 0.5, Latency: 0.2}); got != want {
+		t.Errorf("weights = %+v, want %+v", got, want)
+	}
+	if len(env.problems) != 3 {
+		t.Errorf("problems = %q, want the misspelled, malformed and non-numeric entries", env.problems)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProxySearch는 GET /admin/proxy-pool/search의 조건입니다. 비어 있는(nil) 조건은 적용하지 않으며,
+// 문자열 비교는 대소문자를 무시합니다.
+type ProxySearch struct {
+	ProxyFilter
+	Enabled         *bool
+	HealthStatus    string
+	AddressContains string
+	MinSuccessRate  *float64 // percent, inclusive
+	MaxSuccessRate  *float64 // percent, inclusive
+	Sort            string   // "id" (default) or "score" (best first)
+}
+
+// ProxySearchResult는 검색에 걸린 프록시와 조회 시 계산한 지표입니다.
+type ProxySearchResult struct {
+	ProxyView
+	SuccessRate float64 `json:"successRate"` // percent; 100 until results are recorded
+	Samples     int64   `json:"samples"`     // recorded successes + failures behind successRate
+}
+
+// parseProxySearch는 쿼리 문자열에서 검색 조건을 읽습니다.
+// country, protocol, provider, tag, enabled, healthStatus, address(부분 문자열), minSuccessRate, maxSuccessRate,
+// sort(id, score)를 받습니다.
+func parseProxySearch(query url.Values) (ProxySearch, error) {
+	search := ProxySearch{
+		ProxyFilter: ProxyFilter{
+			Country:  query.Get("country"),
+			Tag:      query.Get("tag"),
+			Protocol: query.Get("protocol"),
+			Provider: query.Get("provider"),
+		},
+		HealthStatus:    query.Get("healthStatus"),
+		AddressContains: query.Get("address"),
+		Sort:            strings.ToLower(query.Get("sort")),
+	}
+	if search.Sort != "" && search.Sort != "id" && search.Sort != "score" {
+		return search, errors.New("sort must be id or score")
+	}
+	if v := query.Get("enabled"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return search, errors.New("enabled must be true or false")
+		}
+		search.Enabled = &enabled
+	}
+	for _, bound := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"minSuccessRate", &search.MinSuccessRate},
+		{"maxSuccessRate", &search.MaxSuccessRate},
+	} {
+		v := query.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || rate > 100 {
+			return search, fmt.Errorf("%s must be a number between 0 and 100", bound.name)
+		}
+		*bound.dst = &rate
+	}
+	if search.MinSuccessRate != nil && search.MaxSuccessRate != nil && *search.MinSuccessRate > *search.MaxSuccessRate {
+		return search, errors.New("minSuccessRate must not exceed maxSuccessRate")
+	}
+	return search, nil
+}
+
+// Matches는 프록시가 모든 조건을 만족하는지 반환합니다. successRate는 calculateSuccessRate 값입니다.
+func (s ProxySearch) Matches(proxy *ProxyIP, successRate float64) bool {
+	if !s.ProxyFilter.Matches(proxy) {
+		return false
+	}
+	if s.Enabled != nil && proxy.Enabled != *s.Enabled {
+		return false
+	}
+	if s.HealthStatus != "" && !strings.EqualFold(proxy.HealthStatus, s.HealthStatus) {
+		return false
+	}
+	if s.AddressContains != "" && !strings.Contains(strings.ToLower(proxy.Address), strings.ToLower(s.AddressContains)) {
+		return false
+	}
+	if s.MinSuccessRate != nil && successRate < *s.MinSuccessRate {
+		return false
+	}
+	if s.MaxSuccessRate != nil && successRate > *s.MaxSuccessRate {
+		return false
+	}
+	return true
+}
+
+// SearchProxies는 GetAllProxies와 같이 파생 필드를 갱신한 프록시 중 search에 맞는 것을 ID 순으로 반환합니다.
+// sort=score이면 점수가 높은 순(같으면 ID 순)으로 정렬해 순위 목록으로 쓸 수 있습니다.
+// 읽기 전용이며 사용 통계는 바꾸지 않습니다.
+func (p *IPPool) SearchProxies(search ProxySearch) []ProxySearchResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := time.Now()
+	results := make([]ProxySearchResult, 0)
+	for _, proxy := range p.proxies {
+		rate := calculateSuccessRate(proxy)
+		if !search.Matches(proxy, rate) {
+			continue
+		}
+		results = append(results, ProxySearchResult{
+			ProxyView:   p.viewLocked(proxy, now),
+			SuccessRate: rate,
+			Samples:     proxy.SuccessCount.Load() + proxy.FailCount.Load(),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if search.Sort == "score" {
+			if a, b := results[i].Score.Load(), results[j].Score.Load(); a != b {
+				return a > b
+			}
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -20,21 +27,108 @@ func writeJSON(w http.ResponseWriter, status int, data any) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeErr는 에러를 JSON 형태로 응답합니다.
+// writeErr는 에러를 JSON 형태로 응답합니다. 본문 한도를 넘어 난 오류는 status와 관계없이 413으로 응답합니다.
 func writeErr(w http.ResponseWriter, status int, err error) {
+	if bodyTooLarge(err) {
+		status = http.StatusRequestEntityTooLarge
+	}
 	writeJSON(w, status, map[string]string{"error": err.Error()})
 }
 
+// writeValidationErr는 ValidationError를 422와 필드별 오류 맵으로 응답합니다.
+func writeValidationErr(w http.ResponseWriter, verr *ValidationError) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"error":  verr.Error(),
+		"fields": verr.Fields,
+	})
+}
+
+// writePoolErr는 풀 연산 오류를 errorStatus에 따른 상태 코드로 응답합니다. ValidationError는 필드별 정보와 함께 응답합니다.
+func writePoolErr(w http.ResponseWriter, err error, fallback int) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		writeValidationErr(w, verr)
+		return
+	}
+	writeErr(w, errorStatus(err, fallback), err)
+}
+
+// writeDecodeErr는 JSON 디코딩 오류를 응답합니다. 타입 불일치는 필드 정보와 함께 422로 응답합니다.
+func writeDecodeErr(w http.ResponseWriter, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		verr := &ValidationError{}
+		verr.Add(typeErr.Field, fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value))
+		writeValidationErr(w, verr)
+		return
+	}
+	writeErr(w, http.StatusBadRequest, err)
+}
+
 // handleHealth는 서비스 헬스체크 및 현재 프록시 풀 통계를 반환합니다.
 func handleHealth(w http.ResponseWriter, r *http.Request) {
 	stats := globalIPPool.GetPoolStats()
+	// Still 200 so liveness probes don't restart the pod over a read-only volume
+	status := "ok"
+	healthy := globalIPPool.PersistenceHealthy()
+	if !healthy {
+		status = "degraded"
+	}
+	paused := globalIPPool.Paused()
+	if paused {
+		status = "paused"
+	}
 	writeJSON(w, http.StatusOK, map[string]any{
-		"status":  "ok",
-		"service": "ip-rotation",
-		"stats":   stats,
+		"status":             status,
+		"service":            "ip-rotation",
+		"paused":             paused,
+		"persistenceHealthy": healthy,
+		"stats":              stats,
 	})
 }
 
+// handleReady는 준비 상태(readiness)를 반환합니다. 선택 가능한 프록시가 하나도 없으면 503을 반환해
+// 트래픽이 쓸모없는 인스턴스로 라우팅되지 않게 합니다. 일시 중지 중에도 프록시를 내주지 않으므로 준비되지 않은 것으로 봅니다.
+// /health는 프로세스 생존(liveness)만 나타냅니다.
+func handleReady(w http.ResponseWriter, r *http.Request) {
+	if globalIPPool.Paused() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"status": "not_ready",
+			"paused": true,
+			"reason": ErrServicePaused.Error(),
+		})
+		return
+	}
+	usable := globalIPPool.UsableProxyCount()
+	if usable == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"status":        "not_ready",
+			"usableProxies": 0,
+			"reason":        "no enabled, healthy proxy available",
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":        "ready",
+		"usableProxies": usable,
+	})
+}
+
+// handleMetrics는 Prometheus 텍스트 형식의 메트릭을 반환합니다.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+		w.Header().Set("Content-Type", openMetricsContentType)
+		globalIPPool.WriteMetrics(w, true)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	globalIPPool.WriteMetrics(w, false)
+}
+
 // handleProxyPool은 프록시 풀 전체 조회/추가(관리자용)를 처리합니다.
 func handleProxyPool(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -48,14 +142,16 @@ func handleProxyPool(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		var proxy ProxyIP
 		if err := json.NewDecoder(r.Body).Decode(&proxy); err != nil {
-			writeErr(w, http.StatusBadRequest, err)
+			writeDecodeErr(w, err)
 			return
 		}
-		if err := globalIPPool.AddProxy(&proxy); err != nil {
-			writeErr(w, http.StatusBadRequest, err)
+		added, err := globalIPPool.AddProxy(&proxy)
+		if err != nil {
+			writePoolErr(w, err, http.StatusBadRequest)
 			return
 		}
-		writeJSON(w, http.StatusCreated, proxy)
+		audit(r, "proxy.add", added.ID, nil, globalIPPool.auditProxy(added.ID))
+		writeJSON(w, http.StatusCreated, added)
 	default:
 		writeErr(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 	}
@@ -68,6 +164,18 @@ func handleProxyPoolByID(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, errors.New("missing proxy id"))
 		return
 	}
+	if strings.HasSuffix(id, "/history") {
+		handleProxyHistory(w, r, strings.TrimSuffix(id, "/history"))
+		return
+	}
+	if strings.HasSuffix(id, "/drain") {
+		handleProxyDrain(w, r, strings.TrimSuffix(id, "/drain"))
+		return
+	}
+	if strings.HasSuffix(id, "/probability") {
+		handleProxyProbability(w, r, strings.TrimSuffix(id, "/probability"))
+		return
+	}
 
 	switch r.Method {
 	case http.MethodGet:
@@ -75,22 +183,34 @@ func handleProxyPoolByID(w http.ResponseWriter, r *http.Request) {
 		proxy, ok := globalIPPool.proxies[id]
 		globalIPPool.mu.RUnlock()
 		if !ok {
-			writeErr(w, http.StatusNotFound, errors.New("proxy not found"))
+			writeErr(w, http.StatusNotFound, ErrProxyNotFound)
 			return
 		}
-		writeJSON(w, http.StatusOK, proxy)
+		writeProxy(w, proxy)
 	case http.MethodDelete:
+		// soft=true keeps the entry (and its stats) but removes it from selection
+		before := globalIPPool.auditProxy(id)
+		if r.URL.Query().Get("soft") == "true" {
+			if err := globalIPPool.SoftRemoveProxy(id); err != nil {
+				writePoolErr(w, err, http.StatusInternalServerError)
+				return
+			}
+			audit(r, "proxy.soft_delete", id, before, globalIPPool.auditProxy(id))
+			writeJSON(w, http.StatusOK, map[string]string{"softDeleted": id})
+			return
+		}
 		if err := globalIPPool.RemoveProxy(id); err != nil {
-			writeErr(w, http.StatusNotFound, err)
+			writePoolErr(w, err, http.StatusInternalServerError)
 			return
 		}
+		audit(r, "proxy.delete", id, before, nil)
 		writeJSON(w, http.StatusOK, map[string]string{"deleted": id})
 	case http.MethodPatch:
 		globalIPPool.mu.Lock()
 		proxy, ok := globalIPPool.proxies[id]
 		if !ok {
 			globalIPPool.mu.Unlock()
-			writeErr(w, http.StatusNotFound, errors.New("proxy not found"))
+			writeErr(w, http.StatusNotFound, ErrProxyNotFound)
 			return
 		}
 		var patch map[string]any
@@ -99,16 +219,147 @@ func handleProxyPoolByID(w http.ResponseWriter, r *http.Request) {
 			writeErr(w, http.StatusBadRequest, err)
 			return
 		}
-		if v, ok := patch["enabled"].(bool); ok {
+		before := auditProxyLocked(proxy)
+		// Validate before applying anything so a rejected patch leaves the proxy untouched
+		var metadata map[string]string
+		if v, ok := patch["metadata"].(map[string]any); ok {
+			metadata = make(map[string]string, len(v))
+			for key, value := range v {
+				if s, ok := value.(string); ok {
+					metadata[key] = s
+				}
+			}
+			if msg := validateMetadata(metadata); msg != "" {
+				globalIPPool.mu.Unlock()
+				verr := &ValidationError{}
+				verr.Add("metadata", msg)
+				writeValidationErr(w, verr)
+				return
+			}
+		}
+		var userAgents []string
+		if v, ok := patch["userAgents"].([]any); ok {
+			userAgents = make([]string, 0, len(v))
+			for _, ua := range v {
+				if s, ok := ua.(string); ok {
+					userAgents = append(userAgents, s)
+				}
+			}
+			if msg := validateUserAgents(userAgents); msg != "" {
+				globalIPPool.mu.Unlock()
+				verr := &ValidationError{}
+				verr.Add("userAgents", msg)
+				writeValidationErr(w, verr)
+				return
+			}
+		}
+		var expiresAt time.Time
+		_, expirySet := patch["expiresAt"]
+		if v, ok := patch["expiresAt"].(string); ok && v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				globalIPPool.mu.Unlock()
+				verr := &ValidationError{}
+				verr.Add("expiresAt", "must be an RFC 3339 timestamp, or empty/null to clear")
+				writeValidationErr(w, verr)
+				return
+			}
+			expiresAt = t
+		}
+		var windows []MaintenanceWindow
+		_, windowsSet := patch["maintenanceWindows"]
+		if windowsSet {
+			raw, _ := json.Marshal(patch["maintenanceWindows"])
+			msg := "must be a list of {start, end, days, timezone}"
+			if err := json.Unmarshal(raw, &windows); err == nil {
+				msg = validateMaintenanceWindows(windows)
+			}
+			if msg != "" {
+				globalIPPool.mu.Unlock()
+				verr := &ValidationError{}
+				verr.Add("maintenanceWindows", msg)
+				writeValidationErr(w, verr)
+				return
+			}
+		}
+		var activeHours *ActiveHours
+		_, activeHoursSet := patch["activeHours"]
+		if v := patch["activeHours"]; v != nil {
+			raw, _ := json.Marshal(v)
+			msg := "must be {start, end, days, timezone}, or null to clear"
+			if err := json.Unmarshal(raw, &activeHours); err == nil && activeHours != nil {
+				msg = activeHours.validate()
+			}
+			if msg != "" {
+				globalIPPool.mu.Unlock()
+				verr := &ValidationError{}
+				verr.Add("activeHours", msg)
+				writeValidationErr(w, verr)
+				return
+			}
+		}
+		// Headers are checked against the protocol the proxy will have after this patch, as POST does
+		headers, protocol := proxy.Headers, proxy.Protocol
+		headersValue, headersSet := patch["headers"]
+		headersSet = headersSet && headersValue != nil
+		headersMsg := ""
+		if headersSet {
+			raw, ok := headersValue.(map[string]any)
+			if !ok {
+				headersMsg = "must be an object of string header values"
+			}
+			headers = make(map[string]string, len(raw))
+			for name, value := range raw {
+				s, ok := value.(string)
+				if !ok {
+					headersMsg = "header value must be a string: " + name
+					break
+				}
+				headers[name] = s
+			}
+		}
+		protocolSet := false
+		if v, ok := patch["protocol"].(string); ok && v != "" {
+			protocol, protocolSet = v, true
+		}
+		if headersMsg == "" && (headersSet || protocolSet) {
+			headersMsg = validateProxyHeaders(headers, protocol)
+		}
+		if headersMsg != "" {
+			globalIPPool.mu.Unlock()
+			verr := &ValidationError{}
+			verr.Add("headers", headersMsg)
+			writeValidationErr(w, verr)
+			return
+		}
+		if headersSet {
+			proxy.Headers = headers
+		}
+		if v, ok := patch["enabled"].(bool); ok && v != proxy.Enabled {
 			proxy.Enabled = v
 			if v {
 				proxy.DisabledAt = time.Time{}
+				proxy.DisabledReason = ""
+				globalIPPool.metrics.manualEnables.Add(1)
+				globalIPPool.recordEvent(id, EventEnabled, "admin patch", 0)
 			} else {
 				proxy.DisabledAt = time.Now()
+				proxy.DisabledReason = DisabledReasonAdmin
+				globalIPPool.metrics.manualDisables.Add(1)
+				globalIPPool.recordEvent(id, EventDisabled, "admin patch", 0)
+			}
+		}
+		if v, ok := patch["healthCheckEnabled"]; ok {
+			switch v := v.(type) {
+			case bool:
+				globalIPPool.setHealthCheckEnabledLocked(proxy, &v)
+			case nil:
+				globalIPPool.setHealthCheckEnabledLocked(proxy, nil)
 			}
 		}
-		if v, ok := patch["address"].(string); ok && v != "" {
+		if v, ok := patch["address"].(string); ok && v != "" && v != proxy.Address {
 			proxy.Address = v
+			globalIPPool.addressChangedLocked(proxy)
 		}
 		if v, ok := patch["country"].(string); ok {
 			proxy.Country = v
@@ -116,46 +367,507 @@ func handleProxyPoolByID(w http.ResponseWriter, r *http.Request) {
 		if v, ok := patch["city"].(string); ok {
 			proxy.City = v
 		}
+		// Unparseable values are ignored; an empty string clears the field
+		if v, ok := patch["asn"].(string); ok && (v == "" || parseASN(v) != "") {
+			proxy.ASN = parseASN(v)
+		}
+		if v, ok := patch["subnet"].(string); ok {
+			if v == "" {
+				proxy.Subnet = ""
+			} else if _, network, err := net.ParseCIDR(strings.TrimSpace(v)); err == nil {
+				proxy.Subnet = network.String()
+			}
+		}
+		if v, ok := patch["provider"].(string); ok {
+			proxy.Provider = v
+		}
+		if v, ok := patch["notes"].(string); ok {
+			proxy.Notes = v
+		}
+		if v, ok := patch["tlsProfile"].(string); ok {
+			proxy.TLSProfile = strings.TrimSpace(v)
+		}
+		if metadata != nil {
+			proxy.Metadata = metadata
+		}
+		if userAgents != nil {
+			proxy.UserAgents = userAgents
+		}
 		if v, ok := patch["protocol"].(string); ok && v != "" {
 			proxy.Protocol = v
 		}
+		username, password := proxy.Username, proxy.Password
 		if v, ok := patch["username"].(string); ok {
-			proxy.Username = v
+			username = v
 		}
 		if v, ok := patch["password"].(string); ok {
-			proxy.Password = v
+			password = v
+		}
+		globalIPPool.setCredentialsLocked(proxy, username, password, "patch")
+		if v, ok := patch["priority"].(float64); ok {
+			proxy.Priority = int(v)
+		}
+		if v, ok := patch["tags"].([]any); ok {
+			tags := make([]string, 0, len(v))
+			for _, t := range v {
+				if s, ok := t.(string); ok && s != "" {
+					tags = append(tags, s)
+				}
+			}
+			proxy.Tags = tags
+		}
+		lat, latOK := patch["latitude"].(float64)
+		lon, lonOK := patch["longitude"].(float64)
+		if latOK && lonOK && validCoordinates(lat, lon) {
+			proxy.Latitude, proxy.Longitude = &lat, &lon
+		}
+		if v, ok := patch["supportsUdp"].(bool); ok {
+			proxy.SupportsUDP = v
+			if v {
+				proxy.UDPStatus = "unknown"
+			} else {
+				proxy.UDPStatus = ""
+			}
+		}
+		if v, ok := patch["maxUsageCount"].(float64); ok && v >= 0 {
+			proxy.MaxUsageCount = int64(v)
+			globalIPPool.refreshQuota(proxy)
+		}
+		if v, ok := patch["maxRequestsPerMinute"].(float64); ok && v >= 0 {
+			proxy.MaxRequestsPerMinute = int64(v)
 		}
-		// Handle success/failure recording
+		if v, ok := patch["timeoutMs"].(float64); ok && v >= 0 {
+			proxy.TimeoutMs = int64(v)
+		}
+		if v, ok := patch["costPerRequest"].(float64); ok && v >= 0 {
+			proxy.CostPerRequest = v
+		}
+		// Handle success/failure recording. Like /proxy/record, a result closes the proxy's oldest in-flight lease,
+		// which may complete a pending drain.
+		resultRecorded := false
 		if success, ok := patch["success"].(bool); ok && success {
 			latency := int64(0)
 			if v, ok := patch["latency_ms"].(float64); ok {
 				latency = int64(v)
 			}
-			proxy.SuccessCount++
-			total := proxy.SuccessCount + proxy.FailCount
-			if total > 0 {
-				proxy.AvgLatencyMs = (proxy.AvgLatencyMs*(total-1) + latency) / total
-			}
+			proxy.SuccessCount.Add(1)
+			recordLatency(proxy, latency)
+			globalIPPool.updateWarmup(proxy)
+			globalIPPool.updateScore(proxy, time.Now())
+			globalIPPool.recordEvent(id, EventSuccess, "admin patch", latency)
+			releaseActive(proxy)
+			resultRecorded = true
 		}
 		if failure, ok := patch["failure"].(bool); ok && failure {
-			proxy.FailCount++
-			if globalIPPool.config.MaxFailures > 0 && proxy.FailCount >= int64(globalIPPool.config.MaxFailures) {
+			fails := proxy.FailCount.Add(1)
+			globalIPPool.updateWarmup(proxy)
+			globalIPPool.updateScore(proxy, time.Now())
+			globalIPPool.recordEvent(id, EventFailure, "admin patch", 0)
+			releaseActive(proxy)
+			resultRecorded = true
+			maxed := globalIPPool.config.MaxFailures > 0 && fails >= int64(globalIPPool.config.MaxFailures)
+			lowSuccess := proxy.Enabled && globalIPPool.belowSuccessFloor(proxy)
+			if (maxed || lowSuccess) && proxy.Enabled && !globalIPPool.autoDisableAllowedLocked(proxy) {
+				maxed, lowSuccess = false, false
+			}
+			if maxed {
 				proxy.Enabled = false
 				proxy.DisabledAt = time.Now()
+				proxy.DisabledReason = DisabledReasonMaxFailures
+				globalIPPool.metrics.autoDisables.Add(1)
+				globalIPPool.recordEvent(id, EventDisabled, "max failures reached", 0)
+			} else if lowSuccess {
+				globalIPPool.disableLowSuccessLocked(proxy)
 			}
 		}
+		if resultRecorded {
+			globalIPPool.finishDrainLocked(proxy)
+		}
+		if windowsSet {
+			// Take effect now rather than on the next scheduler tick
+			proxy.MaintenanceWindows = windows
+			globalIPPool.applyMaintenanceLocked(proxy, time.Now())
+		}
+		if activeHoursSet {
+			proxy.ActiveHours = activeHours
+		}
+		if expirySet {
+			proxy.ExpiresAt = expiresAt
+			globalIPPool.applyExpiryLocked(proxy, time.Now())
+		}
+		globalIPPool.invalidateWeights()
+		after := auditProxyLocked(proxy)
 		globalIPPool.mu.Unlock()
 		log.Printf("[IP-ROTATION] Proxy updated: id=%s enabled=%v", id, proxy.Enabled)
+		audit(r, "proxy.update", id, before, after)
 
 		// Auto-save
 		globalIPPool.autoSave()
 
-		writeJSON(w, http.StatusOK, proxy)
+		writeProxy(w, proxy)
 	default:
 		writeErr(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 	}
 }
 
+// handleProxyHistory는 특정 프록시의 최근 이벤트 이력을 반환합니다(관리자용).
+func handleProxyHistory(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &limit); err != nil || limit < 0 {
+			writeErr(w, http.StatusBadRequest, errors.New("limit must be a non-negative integer"))
+			return
+		}
+	}
+
+	events, ok := globalIPPool.GetProxyHistory(id, limit)
+	if !ok {
+		writeErr(w, http.StatusNotFound, ErrProxyNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"proxyId": id,
+		"events":  events,
+	})
+}
+
+// handleProxyProbability는 지금 선택했을 때 해당 프록시가 고를 확률과 근거를 반환합니다(관리자용).
+func handleProxyProbability(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	probability, err := globalIPPool.SelectionProbability(id)
+	if err != nil {
+		writePoolErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, probability)
+}
+
+// handleProxyDrain은 프록시의 drain 상태를 설정(POST)하거나 해제(DELETE)합니다(관리자용).
+func handleProxyDrain(w http.ResponseWriter, r *http.Request, id string) {
+	var (
+		proxy  *ProxyIP
+		err    error
+		action string
+	)
+	before := globalIPPool.auditProxy(id)
+	switch r.Method {
+	case http.MethodPost:
+		proxy, err = globalIPPool.DrainProxy(id)
+		action = "proxy.drain"
+	case http.MethodDelete:
+		proxy, err = globalIPPool.UndrainProxy(id)
+		action = "proxy.undrain"
+	default:
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST or DELETE"))
+		return
+	}
+	if err != nil {
+		writePoolErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	audit(r, action, id, before, globalIPPool.auditProxy(id))
+	writeProxy(w, proxy)
+}
+
+// writeProxy는 프록시 하나를 조회 시점의 파생 필드(ProxyView)와 함께 200으로 응답합니다.
+func writeProxy(w http.ResponseWriter, proxy *ProxyIP) {
+	data, err := globalIPPool.proxyJSON(proxy)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// proxyJSON은 p.mu 읽기 잠금 안에서 프록시의 응답 표현(ProxyView)을 직렬화합니다. 응답은 잠금을 놓은 뒤에 써야
+// 느린 클라이언트가 읽기 잠금을 붙잡아 쓰기 잠금과 그 뒤의 선택을 막지 않습니다.
+func (p *IPPool) proxyJSON(proxy *ProxyIP) (json.RawMessage, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return json.Marshal(p.viewLocked(proxy, time.Now()))
+}
+
+// handleProxyBulkAction은 필터에 맞는 프록시들을 일괄 활성화/비활성화/격리합니다(관리자용).
+func handleProxyBulkAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var req struct {
+		Filter ProxyFilter `json:"filter"`
+		Action string      `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	affected, err := globalIPPool.BulkAction(req.Filter, req.Action)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	audit(r, "proxy.bulk_action", "", req.Filter, map[string]any{"action": req.Action, "affected": affected})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"action":   req.Action,
+		"affected": affected,
+		"count":    len(affected),
+	})
+}
+
+// handleProxyPoolDiff는 제안된 프록시 목록과 현재 풀의 차이(added/removed/modified)를 반환합니다(관리자용).
+// 본문의 proxies는 배열 또는 상태 파일과 같은 id → 프록시 맵일 수 있으며, apply=true 쿼리로 차이를 한 번에 반영합니다.
+func handleProxyPoolDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var req struct {
+		Proxies json.RawMessage `json:"proxies"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeErr(w, err)
+		return
+	}
+	var proposed []*ProxyIP
+	if trimmed := bytes.TrimSpace(req.Proxies); len(trimmed) > 0 && trimmed[0] == '{' {
+		var byID map[string]*ProxyIP
+		if err := json.Unmarshal(trimmed, &byID); err != nil {
+			writeDecodeErr(w, err)
+			return
+		}
+		for _, id := range sortedKeys(byID) {
+			proxy := byID[id]
+			if proxy != nil && proxy.ID == "" {
+				proxy.ID = id
+			}
+			proposed = append(proposed, proxy)
+		}
+	} else if len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &proposed); err != nil {
+			writeDecodeErr(w, err)
+			return
+		}
+	}
+
+	apply := r.URL.Query().Get("apply") == "true"
+	diff, err := globalIPPool.DiffPool(proposed, apply)
+	if err != nil {
+		writePoolErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	if apply {
+		audit(r, "proxy.diff_apply", "", nil, diff)
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// handleProxyExport는 풀의 모든 프록시를 내보냅니다(관리자용). format=jsonl이면 한 줄에 프록시 하나씩
+// 스트리밍하며(application/x-ndjson), 기본값 json은 프록시 배열 하나로 응답합니다.
+func handleProxyExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		writeJSON(w, http.StatusOK, globalIPPool.GetAllProxies())
+	case "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flush := func() {}
+		if f, ok := w.(http.Flusher); ok {
+			flush = f.Flush
+		}
+		// Headers are already sent, so a failure can only be logged
+		if n, err := globalIPPool.ExportJSONL(w, flush); err != nil {
+			log.Printf("[IP-ROTATION] Proxy export aborted after %d proxies: %v", n, err)
+		}
+	default:
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid format: %s, must be one of: json, jsonl", format))
+	}
+}
+
+// handleProxyImport는 프록시 목록을 가져와 풀에 추가합니다(관리자용). format=jsonl 또는
+// Content-Type: application/x-ndjson이면 본문을 한 줄씩 읽고, 그 외에는 프록시 JSON 배열로 읽습니다.
+func handleProxyImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var report ImportReport
+	if isJSONLImport(r) {
+		var err error
+		report, err = globalIPPool.ImportJSONL(r.Body)
+		if err != nil {
+			status := http.StatusBadRequest
+			if bodyTooLarge(err) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeJSON(w, status, map[string]any{"error": err.Error(), "report": report})
+			return
+		}
+	} else {
+		var proxies []*ProxyIP
+		if err := json.NewDecoder(r.Body).Decode(&proxies); err != nil {
+			writeDecodeErr(w, err)
+			return
+		}
+		report.Errors = []ImportLineError{}
+		for i, proxy := range proxies {
+			if proxy == nil {
+				continue
+			}
+			if _, err := globalIPPool.AddProxy(proxy); err != nil {
+				report.Failed++
+				if len(report.Errors) < maxImportErrorsKept {
+					report.Errors = append(report.Errors, ImportLineError{Line: i + 1, Error: err.Error()})
+				}
+				continue
+			}
+			report.Imported++
+		}
+	}
+	audit(r, "proxy.import", "", nil, map[string]int{"imported": report.Imported, "failed": report.Failed})
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handlePause는 프록시 선택을 일시 중지합니다(관리자용). 중지 중에도 결과 기록과 통계는 동작합니다.
+func handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	if globalIPPool.Pause() {
+		audit(r, "service.pause", "", nil, nil)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"paused": true})
+}
+
+// handleResume은 일시 중지된 프록시 선택을 재개합니다(관리자용).
+func handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	if globalIPPool.Resume() {
+		audit(r, "service.resume", "", nil, nil)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"paused": false})
+}
+
+// handleProxyPurge는 soft-removed 상태인 프록시들을 영구 삭제합니다(관리자용).
+func handleProxyPurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	purged := globalIPPool.PurgeRemoved()
+	audit(r, "proxy.purge", "", map[string]any{"purged": purged}, nil)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"purged": purged,
+		"count":  len(purged),
+	})
+}
+
+// handleProviders는 공급자별 집계 통계를 반환합니다(관리자용).
+func handleProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	providers := globalIPPool.GetProviderStats()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"providers": providers,
+		"count":     len(providers),
+	})
+}
+
+// handleAvailableProxies는 지금 사용 가능한 프록시 목록을 반환합니다(직접 로테이션하는 클라이언트용).
+// country, tag, protocol, provider 쿼리로 범위를 좁힐 수 있습니다.
+func handleAvailableProxies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	query := r.URL.Query()
+	filter := ProxyFilter{
+		Country:  query.Get("country"),
+		Tag:      query.Get("tag"),
+		Protocol: query.Get("protocol"),
+		Provider: query.Get("provider"),
+	}
+	proxies, err := globalIPPool.GetAvailableProxies(filter)
+	if err != nil {
+		writePoolErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{
+		"proxies": proxies,
+		"count":   len(proxies),
+	}
+	if upstream := globalIPPool.UpstreamProxy(); upstream != "" {
+		resp["upstreamProxy"] = upstream
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleProxySearch는 조건에 맞는 프록시만 계산된 지표(successRate, samples)와 함께 반환합니다(관리자용).
+// 큰 풀을 통째로 받아 클라이언트에서 거르지 않도록 서버에서 필터링합니다.
+func handleProxySearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	search, err := parseProxySearch(r.URL.Query())
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	proxies := globalIPPool.SearchProxies(search)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"proxies": proxies,
+		"count":   len(proxies),
+	})
+}
+
+// handleSnapshot은 풀 상태, 설정, 점수/가중치와 현재 후보를 한 JSON 문서로 반환합니다(관리자용).
+// 장애 시점의 라우팅 결정을 버그 리포트에 첨부하거나 테스트에서 재현할 때 씁니다.
+// 프록시 인증 정보는 가려지며, includeCredentials=true일 때만 그대로 포함됩니다.
+func handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	// Credentials are redacted unless explicitly requested, e.g. to restore the snapshot as a state file
+	includeCredentials := r.URL.Query().Get("includeCredentials") == "true"
+	if includeCredentials {
+		log.Printf("[IP-ROTATION] Snapshot with credentials requested: remote=%s", r.RemoteAddr)
+	}
+	data, err := globalIPPool.Snapshot(includeCredentials)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`inline; filename="ip-pool-snapshot-%s.json"`, time.Now().UTC().Format("20060102T150405Z")))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
 // handleProxyPoolConfig는 풀 설정 조회/수정(관리자용)을 처리합니다.
 func handleProxyPoolConfig(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -170,25 +882,45 @@ func handleProxyPoolConfig(w http.ResponseWriter, r *http.Request) {
 			writeErr(w, http.StatusBadRequest, err)
 			return
 		}
+		globalIPPool.mu.RLock()
+		before := globalIPPool.config
+		globalIPPool.mu.RUnlock()
 		if err := globalIPPool.UpdateConfig(cfg); err != nil {
-			writeErr(w, http.StatusBadRequest, err)
+			writePoolErr(w, err, http.StatusBadRequest)
 			return
 		}
+		audit(r, "config.update", "", before, cfg)
 		writeJSON(w, http.StatusOK, cfg)
 	default:
 		writeErr(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
 	}
 }
 
+// 로테이션 테스트 API 제한값입니다. main에서 ROTATE_TEST_MAX_COUNT / ROTATE_TEST_MIN_INTERVAL로 덮어씁니다.
+var (
+	rotateTestMaxCount = 100
+	rotateTestLimit    = &rotateTestLimiter{minWait: time.Second}
+)
+
 // handleProxyRotateTest는 N회 로테이션을 수행해 선택 결과를 점검할 수 있는 테스트 API입니다.
+// 기본은 운영 통계를 바꾸지 않는 시뮬레이션이며, "dryRun": false일 때만 실제 선택(사용량 기록)을 수행합니다.
+// 실제 선택도 프록시를 쓰지 않으므로 임대(진행 중 요청)는 바로 반납합니다.
 func handleProxyRotateTest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
 		return
 	}
+	if !rotateTestLimit.Allow() {
+		writeErr(w, http.StatusTooManyRequests, fmt.Errorf("rotation test is limited to one run every %s", rotateTestLimit.minWait))
+		return
+	}
 
 	var req struct {
-		Count int `json:"count"`
+		Count    int              `json:"count"`
+		Strategy RotationStrategy `json:"strategy"`
+		Country  string           `json:"country"` // preview a countryStrategies override
+		Tag      string           `json:"tag"`     // preview a tagStrategies override
+		DryRun   *bool            `json:"dryRun"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		req.Count = 5 // default
@@ -196,39 +928,49 @@ func handleProxyRotateTest(w http.ResponseWriter, r *http.Request) {
 	if req.Count <= 0 {
 		req.Count = 5
 	}
-	if req.Count > 100 {
-		req.Count = 100
+	if req.Count > rotateTestMaxCount {
+		req.Count = rotateTestMaxCount
 	}
+	if req.Strategy != "" && !validStrategy(req.Strategy) {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid strategy: %s, must be one of: %s", req.Strategy, strategyNames()))
+		return
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+	opts := SelectOptions{Strategy: req.Strategy, Country: req.Country, Tag: req.Tag}
 
-	results := make([]map[string]any, 0, req.Count)
-
-	for i := 0; i < req.Count; i++ {
-		proxy, err := globalIPPool.GetNextProxy()
-		if err != nil {
-			results = append(results, map[string]any{
-				"iteration": i + 1,
-				"error":     err.Error(),
+	var results []RotationPreview
+	if dryRun {
+		results = globalIPPool.SimulateRotation(opts, req.Count)
+	} else {
+		results = make([]RotationPreview, 0, req.Count)
+		for i := 0; i < req.Count; i++ {
+			proxy, err := globalIPPool.GetNextProxyWithOptions(r.Context(), opts)
+			if err != nil {
+				results = append(results, RotationPreview{Iteration: i + 1, Error: err.Error()})
+				continue
+			}
+			// Nothing is sent through the proxy, so no result will ever close this selection's lease
+			globalIPPool.ReturnProxy(proxy)
+			results = append(results, RotationPreview{
+				Iteration:    i + 1,
+				ProxyID:      proxy.ID,
+				Address:      proxy.Address,
+				Protocol:     proxy.Protocol,
+				Country:      proxy.Country,
+				UsageCount:   proxy.UsageCount.Load(),
+				SuccessRate:  fmt.Sprintf("%.2f%%", calculateSuccessRate(proxy)),
+				HealthStatus: proxy.HealthStatus,
 			})
-			continue
-		}
-		results = append(results, map[string]any{
-			"iteration":    i + 1,
-			"proxyId":      proxy.ID,
-			"address":      proxy.Address,
-			"protocol":     proxy.Protocol,
-			"country":      proxy.Country,
-			"usageCount":   proxy.UsageCount,
-			"successRate":  fmt.Sprintf("%.2f%%", calculateSuccessRate(proxy)),
-			"healthStatus": proxy.HealthStatus,
-		})
+		}
 	}
 
 	stats := globalIPPool.GetPoolStats()
 
-	log.Printf("[IP-ROTATION] Rotation test completed: count=%d", req.Count)
+	log.Printf("[IP-ROTATION] Rotation test completed: count=%d dry_run=%v", req.Count, dryRun)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"rotations": results,
+		"dryRun":    dryRun,
 		"stats":     stats,
 	})
 }
@@ -247,6 +989,35 @@ func handleProxyHealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleProxyValidate는 모든 프록시를 동기적으로 점검하고 프록시별 결과와 요약을 반환합니다(관리자용).
+// timeout(초, 기본 60)으로 전체 소요 시간을, concurrency로 동시 점검 수를 제한합니다.
+func handleProxyValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	query := r.URL.Query()
+	timeout := 60
+	if v := query.Get("timeout"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &timeout); err != nil || timeout <= 0 {
+			writeErr(w, http.StatusBadRequest, errors.New("timeout must be a positive integer (seconds)"))
+			return
+		}
+	}
+	concurrency := defaultValidateConcurrency
+	if v := query.Get("concurrency"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &concurrency); err != nil || concurrency <= 0 {
+			writeErr(w, http.StatusBadRequest, errors.New("concurrency must be a positive integer"))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Duration(timeout)*time.Second)
+	defer cancel()
+	writeJSON(w, http.StatusOK, globalIPPool.ValidateProxies(ctx, concurrency))
+}
+
 // handleProxyResetStats는 전체 또는 특정 프록시의 통계를 초기화합니다.
 func handleProxyResetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -260,6 +1031,7 @@ func handleProxyResetStats(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ProxyID == "" {
 		// Reset all
 		globalIPPool.ResetStats()
+		audit(r, "stats.reset", "", nil, nil)
 		writeJSON(w, http.StatusOK, map[string]string{
 			"status":  "success",
 			"message": "All proxy statistics reset",
@@ -267,10 +1039,12 @@ func handleProxyResetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	before := globalIPPool.auditProxy(req.ProxyID)
 	if err := globalIPPool.ResetProxyStats(req.ProxyID); err != nil {
-		writeErr(w, http.StatusNotFound, err)
+		writePoolErr(w, err, http.StatusInternalServerError)
 		return
 	}
+	audit(r, "stats.reset", req.ProxyID, before, globalIPPool.auditProxy(req.ProxyID))
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status":  "success",
 		"message": fmt.Sprintf("Statistics reset for proxy: %s", req.ProxyID),
@@ -339,9 +1113,10 @@ func handleProxyLoad(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := globalIPPool.LoadFromFile(path); err != nil {
-		writeErr(w, http.StatusInternalServerError, err)
+		writePoolErr(w, err, http.StatusInternalServerError)
 		return
 	}
+	audit(r, "state.load", "", nil, map[string]string{"path": path})
 
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status":  "success",
@@ -350,27 +1125,168 @@ func handleProxyLoad(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleGetNextProxy는 다음 프록시를 반환합니다(클라이언트/크롤러용).
+// Accept: application/msgpack 요청에는 MessagePack으로 응답합니다.
 func handleGetNextProxy(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet && r.Method != http.MethodPost {
 		writeErr(w, http.StatusMethodNotAllowed, errors.New("use GET or POST"))
 		return
 	}
 
-	proxy, err := globalIPPool.GetNextProxy()
+	// Optional per-request strategy override (A/B testing); does not change the pool config
+	strategy := RotationStrategy(r.URL.Query().Get("strategy"))
+	if strategy != "" && !validStrategy(strategy) {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid strategy: %s, must be one of: %s", strategy, strategyNames()))
+		return
+	}
+
+	// key routes consistently to the same proxy under the consistent_hash strategy
+	opts := SelectOptions{Strategy: strategy, Key: r.URL.Query().Get("key")}
+
+	// highVolume=true de-prioritizes proxies that can't reuse connections
+	opts.HighVolume = r.URL.Query().Get("highVolume") == "true"
+
+	// websocket=true restricts the choice to proxies verified to pass WebSocket upgrades
+	opts.WebSocket = r.URL.Query().Get("websocket") == "true"
+
+	// country/tag narrow the candidates and select any tagStrategies/countryStrategies override
+	opts.Country = r.URL.Query().Get("country")
+	opts.Tag = r.URL.Query().Get("tag")
+
+	// ipVersion=4|6 only considers proxies exiting over that IP family
+	ipVersion, err := parseIPVersion(r.URL.Query().Get("ipVersion"))
 	if err != nil {
-		writeErr(w, http.StatusServiceUnavailable, err)
+		writeErr(w, http.StatusBadRequest, err)
 		return
 	}
+	opts.IPVersion = ipVersion
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"proxyId":      proxy.ID,
-		"address":      proxy.Address,
-		"protocol":     proxy.Protocol,
-		"username":     proxy.Username,
-		"password":     proxy.Password,
-		"country":      proxy.Country,
-		"healthStatus": proxy.HealthStatus,
-	})
+	// format=url returns only the ready-to-use proxy URL (credentials percent-encoded) as text/plain
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "url" {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid format: %s, must be one of: json, url", format))
+		return
+	}
+
+	// fallbacks=N adds up to N distinct backup proxies; they count as used only once recorded with "fallback": true,
+	// and "primaryId" on that record closes the lease of the primary they replaced
+	fallbacks := 0
+	if v := query.Get("fallbacks"); v != "" {
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err != nil || n < 0 || n > maxFallbacks {
+			writeErr(w, http.StatusBadRequest, fmt.Errorf("fallbacks must be between 0 and %d", maxFallbacks))
+			return
+		}
+		if n > 0 && format == "url" {
+			writeErr(w, http.StatusBadRequest, errors.New("fallbacks are only returned in the JSON format"))
+			return
+		}
+		fallbacks = n
+	}
+
+	// Optional target point for the geographic strategy (nearest proxy wins)
+	if query.Has("targetLat") || query.Has("targetLon") {
+		var lat, lon float64
+		if _, err := fmt.Sscanf(query.Get("targetLat"), "%g", &lat); err != nil {
+			writeErr(w, http.StatusBadRequest, errors.New("targetLat must be a number"))
+			return
+		}
+		if _, err := fmt.Sscanf(query.Get("targetLon"), "%g", &lon); err != nil {
+			writeErr(w, http.StatusBadRequest, errors.New("targetLon must be a number"))
+			return
+		}
+		if !validCoordinates(lat, lon) {
+			writeErr(w, http.StatusBadRequest, errors.New("targetLat must be within [-90, 90] and targetLon within [-180, 180]"))
+			return
+		}
+		opts.TargetLat, opts.TargetLon = &lat, &lon
+	}
+
+	// Use the request context so a client disconnect aborts any wait for a usable proxy
+	proxy, err := globalIPPool.GetNextProxyWithOptions(r.Context(), opts)
+	if err != nil {
+		// Fall back to a peer region's pool when nothing is usable locally
+		if isSelectionUnavailable(err) && relayFederatedNext(w, r) {
+			return
+		}
+		writePoolErr(w, err, http.StatusServiceUnavailable)
+		return
+	}
+	globalIPPool.metrics.servedLocal.Add(1)
+
+	proxyURL, err := proxy.GetProxyURL()
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, fmt.Errorf("invalid proxy address: %w", err))
+		return
+	}
+
+	// Pair a fresh identity with the fresh IP
+	userAgent := globalIPPool.UserAgentFor(proxy)
+
+	if format == "url" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Proxy-Id", proxy.ID)
+		if userAgent != "" {
+			w.Header().Set("X-User-Agent", userAgent)
+		}
+		if proxy.TLSProfile != "" {
+			w.Header().Set("X-TLS-Profile", proxy.TLSProfile)
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, proxyURL.String())
+		return
+	}
+
+	resp := nextProxyEntry(proxy, proxyURL, userAgent)
+	// Clients must chain through the same upstream proxy the pool uses to reach its proxies
+	if upstream := globalIPPool.UpstreamProxy(); upstream != "" {
+		resp["upstreamProxy"] = upstream
+	}
+	if fallbacks > 0 {
+		entries := []map[string]any{}
+		for _, fallback := range globalIPPool.FallbacksFor(proxy, opts, fallbacks) {
+			fallbackURL, err := fallback.GetProxyURL()
+			if err != nil {
+				continue
+			}
+			entries = append(entries, nextProxyEntry(fallback, fallbackURL, globalIPPool.UserAgentFor(fallback)))
+		}
+		resp["fallbacks"] = entries
+	}
+	writeNegotiated(w, r, http.StatusOK, resp)
+}
+
+// nextProxyEntry는 /proxy/next 응답에서 프록시 하나를 나타내는 필드를 만듭니다.
+func nextProxyEntry(proxy *ProxyIP, proxyURL *url.URL, userAgent string) map[string]any {
+	entry := map[string]any{
+		"proxyId":        proxy.ID,
+		"proxyUrl":       proxyURL.String(),
+		"address":        proxy.Address,
+		"protocol":       proxy.Protocol,
+		"country":        proxy.Country,
+		"healthStatus":   proxy.HealthStatus,
+		"priority":       proxy.Priority,
+		"resolvedIps":    proxy.ResolvedIPs,
+		"remainingQuota": proxy.RemainingQuota,
+		"headers":        proxy.Headers,
+		"timeoutMs":      proxy.TimeoutMs,
+		// Clients holding credentials from an older response can compare versions to detect a rotation
+		"credentialVersion": proxy.CredentialVersion,
+	}
+	// Omit unset credentials: some clients turn empty strings into "Proxy-Authorization: Basic Og=="
+	if proxy.Username != "" {
+		entry["username"] = proxy.Username
+	}
+	if proxy.Password != "" {
+		entry["password"] = proxy.Password
+	}
+	if userAgent != "" {
+		entry["userAgent"] = userAgent
+	}
+	if proxy.TLSProfile != "" {
+		entry["tlsProfile"] = proxy.TLSProfile
+	}
+	return entry
 }
 
 // handleRecordResult는 프록시의 성공/실패 결과를 기록합니다(클라이언트/크롤러용).
@@ -380,12 +1296,7 @@ func handleRecordResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		ProxyID   string `json:"proxyId"`
-		Success   bool   `json:"success"`
-		LatencyMs int64  `json:"latencyMs"`
-		Reason    string `json:"reason"`
-	}
+	var req RecordResult
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeErr(w, http.StatusBadRequest, err)
 		return
@@ -396,6 +1307,20 @@ func handleRecordResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ignore retried submissions of the same result so stats aren't double-counted
+	if globalIPPool.IsDuplicateRecord(req.RequestID) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "duplicate",
+		})
+		return
+	}
+
+	if req.Fallback {
+		if err := globalIPPool.ConfirmFallback(req.ProxyID, req.PrimaryID); err != nil {
+			writePoolErr(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
 	if req.Success {
 		globalIPPool.RecordSuccess(req.ProxyID, req.LatencyMs)
 	} else {
@@ -407,6 +1332,36 @@ func handleRecordResult(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleRecordBatch는 여러 성공/실패 결과를 한 번에 기록하고 항목별 처리 결과를 반환합니다(클라이언트/크롤러용).
+// 요청 본문은 /proxy/record 결과 객체의 배열입니다.
+func handleRecordBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var results []RecordResult
+	if err := json.NewDecoder(r.Body).Decode(&results); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(results) > maxRecordBatch {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("too many results: %d, at most %d per batch", len(results), maxRecordBatch))
+		return
+	}
+
+	acks := globalIPPool.RecordBatch(results)
+	counts := make(map[string]int)
+	for _, ack := range acks {
+		counts[ack.Status]++
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results": acks,
+		"counts":  counts,
+		"total":   len(acks),
+	})
+}
+
 // handleRecordCaptcha는 프록시의 CAPTCHA 발생을 기록합니다(클라이언트/크롤러용).
 func handleRecordCaptcha(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -435,6 +1390,77 @@ func handleRecordCaptcha(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleReportBlock은 프록시가 대상 사이트에서 차단(403, CAPTCHA 벽 등)되었음을 보고받아 즉시 비활성화합니다(클라이언트/크롤러용).
+// 일시적 오류는 /proxy/record로, 차단("이 IP는 사용 불가")은 이 엔드포인트로 구분해 보고합니다.
+func handleReportBlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var req struct {
+		ProxyID string `json:"proxyId"`
+		Reason  string `json:"reason"` // e.g. "403", "captcha_wall"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.ProxyID == "" {
+		writeErr(w, http.StatusBadRequest, errors.New("proxyId is required"))
+		return
+	}
+
+	globalIPPool.RecordBlock(req.ProxyID, req.Reason)
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "recorded",
+	})
+}
+
+// handleProxySimulate는 프록시에 합성 실패/CAPTCHA/차단을 주입하거나 unhealthy로 만듭니다(TEST_MODE 전용, 관리자용).
+// 클라이언트 통합 테스트가 풀 소진과 자동 비활성화를 네트워크 없이 재현할 수 있게 합니다.
+func handleProxySimulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErr(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+
+	var req struct {
+		ProxyID string `json:"proxyId"`
+		Action  string `json:"action"` // failure, captcha, block, unhealthy
+		Count   int    `json:"count"`  // events to inject; 0 = 1 (ignored for unhealthy)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ProxyID == "" {
+		writeErr(w, http.StatusBadRequest, errors.New("proxyId is required"))
+		return
+	}
+	if !validSimulateActions[req.Action] {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("invalid action: %q, must be one of: failure, captcha, block, unhealthy", req.Action))
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 0 || req.Count > maxSimulateCount {
+		writeErr(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", maxSimulateCount))
+		return
+	}
+
+	result, err := globalIPPool.Simulate(req.ProxyID, req.Action, req.Count)
+	if err != nil {
+		writePoolErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	audit(r, "proxy.simulate", req.ProxyID, nil, result)
+	writeJSON(w, http.StatusOK, result)
+}
+
 // corsMiddleware는 CORS 헤더를 추가하고 OPTIONS 프리플라이트 요청을 처리합니다.
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -451,10 +1477,22 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// envSeconds는 name을 0 이상의 초 단위 정수로 읽어 time.Duration으로 반환합니다.
+// 설정되지 않았거나 잘못된 값이면 def를 사용하며, 잘못된 값은 다른 설정과 같이 env에 기록됩니다.
+func envSeconds(env *envConfig, name string, def int) time.Duration {
+	seconds := env.Int(name, def)
+	if seconds < 0 {
+		env.invalid(name, os.Getenv(name), def)
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 // main은 환경 변수 기반으로 IP 풀을 초기화하고 HTTP 서버를 시작합니다.
 func main() {
 	// Initialize the IP pool
 	initIPPool()
+	reloadConfigFromEnvFile()
 
 	// Get port from environment
 	port := os.Getenv("PORT")
@@ -464,27 +1502,150 @@ func main() {
 
 	// Register routes
 	http.HandleFunc("/health", corsMiddleware(handleHealth))
+	http.HandleFunc("/ready", corsMiddleware(handleReady))
+	http.HandleFunc("/metrics", handleMetrics)
 
-	// Admin endpoints
-	http.HandleFunc("/admin/proxy-pool", corsMiddleware(handleProxyPool))
-	http.HandleFunc("/admin/proxy-pool/", corsMiddleware(handleProxyPoolByID))
-	http.HandleFunc("/admin/proxy-pool-config", corsMiddleware(handleProxyPoolConfig))
-	http.HandleFunc("/admin/proxy-rotate-test", corsMiddleware(handleProxyRotateTest))
-	http.HandleFunc("/admin/proxy-health-check", corsMiddleware(handleProxyHealthCheck))
-	http.HandleFunc("/admin/proxy-reset-stats", corsMiddleware(handleProxyResetStats))
-	http.HandleFunc("/admin/proxy-save", corsMiddleware(handleProxySave))
-	http.HandleFunc("/admin/proxy-load", corsMiddleware(handleProxyLoad))
+	// Admin endpoints (responses are gzip-compressed for remote dashboards)
+	http.HandleFunc("/admin/proxy-pool", corsMiddleware(gzipMiddleware(handleProxyPool)))
+	http.HandleFunc("/admin/proxy-pool/", corsMiddleware(gzipMiddleware(handleProxyPoolByID)))
+	http.HandleFunc("/admin/proxy-pool/bulk-action", corsMiddleware(gzipMiddleware(handleProxyBulkAction)))
+	http.HandleFunc("/admin/proxy-pool/purge", corsMiddleware(gzipMiddleware(handleProxyPurge)))
+	http.HandleFunc("/admin/proxy-pool/search", corsMiddleware(gzipMiddleware(handleProxySearch)))
+	http.HandleFunc("/admin/proxy-pool/diff", corsMiddleware(gzipMiddleware(handleProxyPoolDiff)))
+	http.HandleFunc("/admin/proxy-pool/export", corsMiddleware(gzipMiddleware(handleProxyExport)))
+	http.HandleFunc("/admin/proxy-pool/import", corsMiddleware(gzipMiddleware(handleProxyImport)))
+	http.HandleFunc("/admin/proxy-pool-config", corsMiddleware(gzipMiddleware(handleProxyPoolConfig)))
+	http.HandleFunc("/admin/snapshot", corsMiddleware(gzipMiddleware(handleSnapshot)))
+	http.HandleFunc("/admin/proxy-rotate-test", corsMiddleware(gzipMiddleware(handleProxyRotateTest)))
+	http.HandleFunc("/admin/proxy-health-check", corsMiddleware(gzipMiddleware(handleProxyHealthCheck)))
+	http.HandleFunc("/admin/proxy-validate", corsMiddleware(gzipMiddleware(handleProxyValidate)))
+	http.HandleFunc("/admin/proxy-reset-stats", corsMiddleware(gzipMiddleware(handleProxyResetStats)))
+	http.HandleFunc("/admin/proxy-save", corsMiddleware(gzipMiddleware(handleProxySave)))
+	http.HandleFunc("/admin/proxy-load", corsMiddleware(gzipMiddleware(handleProxyLoad)))
+	http.HandleFunc("/admin/audit", corsMiddleware(gzipMiddleware(handleAudit)))
+	http.HandleFunc("/admin/pause", corsMiddleware(handlePause))
+	http.HandleFunc("/admin/resume", corsMiddleware(handleResume))
+	http.HandleFunc("/admin/providers", corsMiddleware(gzipMiddleware(handleProviders)))
 
 	// Client endpoints (for crawlers to use)
 	http.HandleFunc("/proxy/next", corsMiddleware(handleGetNextProxy))
+	http.HandleFunc("/proxy/available", corsMiddleware(gzipMiddleware(handleAvailableProxies)))
 	http.HandleFunc("/proxy/record", corsMiddleware(handleRecordResult))
+	http.HandleFunc("/proxy/record-batch", corsMiddleware(gzipMiddleware(handleRecordBatch)))
 	http.HandleFunc("/proxy/captcha", corsMiddleware(handleRecordCaptcha))
+	http.HandleFunc("/proxy/report-block", corsMiddleware(handleReportBlock))
+
+	// Fault injection for client integration tests; never registered unless TEST_MODE=true
+	if os.Getenv("TEST_MODE") == "true" {
+		http.HandleFunc("/admin/proxy-simulate", corsMiddleware(handleProxySimulate))
+		log.Printf("[IP-ROTATION] TEST_MODE enabled: /admin/proxy-simulate accepts synthetic failures")
+	}
 
 	log.Printf("[IP-ROTATION] Server starting on port %s", port)
 	log.Printf("[IP-ROTATION] Config: strategy=%s maxFailures=%d cooldown=%dm",
 		globalIPPool.config.Strategy, globalIPPool.config.MaxFailures, globalIPPool.config.CooldownMinutes)
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	// Server settings are read like the pool's: malformed values are logged, and STRICT_CONFIG refuses them
+	var env envConfig
+
+	// Rotation test limits (count per run, minimum interval between runs)
+	rotateTestMaxCount = env.Int("ROTATE_TEST_MAX_COUNT", 100)
+	if rotateTestMaxCount <= 0 {
+		env.invalid("ROTATE_TEST_MAX_COUNT", os.Getenv("ROTATE_TEST_MAX_COUNT"), 100)
+		rotateTestMaxCount = 100
+	}
+	rotateTestLimit.minWait = envSeconds(&env, "ROTATE_TEST_MIN_INTERVAL", 1)
+
+	// Admin mutations are appended to a JSONL audit trail when AUDIT_LOG_PATH is set
+	if path := os.Getenv("AUDIT_LOG_PATH"); path != "" {
+		logger, err := newAuditLog(path)
+		if err != nil {
+			log.Fatalf("[IP-ROTATION] Audit log setup failed: %v", err)
+		}
+		auditLogger = logger
+		log.Printf("[IP-ROTATION] Audit log enabled: %s", path)
+	}
+
+	// Cap request bodies so an oversized POST/PATCH can't exhaust memory; overflows get 413
+	bodyLimit, bulkBodyLimit := bodyLimits(&env)
+
+	// Bound every phase of a connection so slow or idle clients can't exhaust the server
+	readHeaderTimeout := envSeconds(&env, "SERVER_READ_HEADER_TIMEOUT", 10)
+	readTimeout := envSeconds(&env, "SERVER_READ_TIMEOUT", 30)
+	writeTimeout := envSeconds(&env, "SERVER_WRITE_TIMEOUT", 60)
+	idleTimeout := envSeconds(&env, "SERVER_IDLE_TIMEOUT", 120)
+	env.enforceStrict()
+
+	var handler http.Handler = maxBodyMiddleware(http.DefaultServeMux, bodyLimit, bulkBodyLimit)
+
+	// Access logging is on by default; ACCESS_LOG=false turns it off for high-QPS deployments
+	if os.Getenv("ACCESS_LOG") != "false" {
+		handler = accessLogMiddleware(handler)
+	}
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+
+	// Serve HTTPS when a certificate is configured so admin credentials never cross the network in plaintext
+	certs, err := tlsReloaderFromEnv(os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE"))
+	if err != nil {
+		log.Fatalf("[IP-ROTATION] TLS setup failed: %v", err)
+	}
+	if certs != nil {
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+
+	// Flush pending state and stop background routines on SIGINT/SIGTERM
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigCh
+		log.Printf("[IP-ROTATION] Received %s, shutting down", sig)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("[IP-ROTATION] Server shutdown error: %v", err)
+		}
+	}()
+
+	// Re-read CONFIG_FILE (and the TLS certificate, for rotation) on SIGHUP without a restart
+	go func() {
+		hupCh := make(chan os.Signal, 1)
+		signal.Notify(hupCh, syscall.SIGHUP)
+		for range hupCh {
+			log.Printf("[IP-ROTATION] Received SIGHUP, reloading")
+			reloadConfigFromEnvFile()
+			if certs != nil {
+				if err := certs.Reload(); err != nil {
+					log.Printf("[IP-ROTATION] TLS certificate reload failed, keeping current certificate: %v", err)
+				}
+			}
+		}
+	}()
+
+	if certs != nil {
+		log.Printf("[IP-ROTATION] Serving HTTPS")
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("[IP-ROTATION] Server failed: %v", err)
 	}
+	<-shutdownDone
+
+	globalIPPool.Shutdown()
+	log.Printf("[IP-ROTATION] Server stopped")
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetNextProxyOmitsUnsetCredentials(t *testing.T) {
+	tests := []struct {
+		name         string
+		username     string
+		password     string
+		wantUsername bool
+		wantPassword bool
+	}{
+		{"no credentials", "", "", false, false},
+		{"username and password", "user", "pass", true, true},
+		{"username only", "user", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := NewIPPool(IPPoolConfig{})
+			previous := globalIPPool
+			globalIPPool = pool
+			t.Cleanup(func() { globalIPPool = previous })
+			if _, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "http://1.2.3.4:8080", Username: tt.username, Password: tt.password}); err != nil {
+				t.Fatalf("add proxy: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			handleGetNextProxy(rec, httptest.NewRequest(http.MethodGet, "/proxy/next", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+			}
+			var resp map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if _, ok := resp["username"]; ok != tt.wantUsername {
+				t.Errorf("username present = %v, want %v (%v)", ok, tt.wantUsername, resp["username"])
+			}
+			if _, ok := resp["password"]; ok != tt.wantPassword {
+				t.Errorf("password present = %v, want %v (%v)", ok, tt.wantPassword, resp["password"])
+			}
+		})
+	}
+}
+
+func TestPatchAddressClearsResolvedIPs(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "http://old.example:8080"})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+	pool.mu.Lock()
+	proxy.ResolvedIPs = []string{"1.2.3.4"}
+	proxy.ResolvedAt = time.Now()
+	proxy.ExitIP = "1.2.3.4"
+	pool.refreshIPVersion(proxy)
+	pool.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	handleProxyPoolByID(rec, httptest.NewRequest(http.MethodPatch, "/admin/proxy-pool/p",
+		strings.NewReader(`{"address":"http://[2001:db8::1]:9000"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if len(proxy.ResolvedIPs) != 0 || !proxy.ResolvedAt.IsZero() || proxy.ExitIP != "" {
+		t.Errorf("resolvedIps=%v resolvedAt=%v exitIp=%q, want the old host's results cleared",
+			proxy.ResolvedIPs, proxy.ResolvedAt, proxy.ExitIP)
+	}
+	if proxy.IPVersion != 6 {
+		t.Errorf("ipVersion = %d, want 6 from the new address", proxy.IPVersion)
+	}
+}
+
+func TestEnvSecondsReportsInvalidValues(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		invalid bool
+	}{
+		{"", 30 * time.Second, false},
+		{"45", 45 * time.Second, false},
+		{"0", 0, false},
+		{"30s", 30 * time.Second, true}, // fmt.Sscanf used to read this as 30
+		{"-5", 30 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("SERVER_READ_TIMEOUT", tt.value)
+			var env envConfig
+			if got := envSeconds(&env, "SERVER_READ_TIMEOUT", 30); got != tt.want {
+				t.Errorf("envSeconds = %v, want %v", got, tt.want)
+			}
+			if invalid := len(env.problems) > 0; invalid != tt.invalid {
+				t.Errorf("problems = %q, want invalid=%v", env.problems, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestPatchResponseComputesDerivedFields(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{CooldownMinutes: 5})
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+	proxy, err := pool.AddProxy(&ProxyIP{ID: "p", Address: "http://1.2.3.4:8080"})
+	if err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+	pool.mu.Lock()
+	proxy.Enabled = false
+	proxy.DisabledAt = time.Now().Add(-time.Minute)
+	pool.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	handleProxyPoolByID(rec, httptest.NewRequest(http.MethodPatch, "/admin/proxy-pool/p",
+		strings.NewReader(`{"metadata":{"site":"a"}}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if got, _ := resp["cooldownRemainingSeconds"].(float64); got < 230 || got > 240 {
+		t.Errorf("cooldownRemainingSeconds = %v, want about 240", resp["cooldownRemainingSeconds"])
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := pool.SaveToFile(path); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read state: %v", err)
+	}
+	for _, key := range []string{"cooldownRemainingSeconds", "expired", "outsideActiveHours"} {
+		if strings.Contains(string(data), `"`+key+`"`) {
+			t.Errorf("state file contains derived field %q", key)
+		}
+	}
+}
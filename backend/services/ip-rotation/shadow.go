@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// shadowStats는 섀도 전략이 실제 선택과 같은 프록시를 골랐는지에 대한 누적 집계입니다.
+// 섀도 전략이 바뀌면 비교를 새로 시작하도록 초기화됩니다.
+type shadowStats struct {
+	Selections Counter // live selections that also ran the shadow strategy
+	Matches    Counter // shadow picked the same proxy as the live strategy
+	Errors     Counter // shadow strategy found no proxy although the live one did
+}
+
+// reset은 집계를 0으로 되돌립니다.
+func (s *shadowStats) reset() {
+	s.Selections.Store(0)
+	s.Matches.Store(0)
+	s.Errors.Store(0)
+}
+
+// shadowSelectLocked는 설정된 ShadowStrategy로 같은 후보에서 한 번 더 선택해, 실제로 선택된 live와
+// 비교한 결과를 로그와 집계에 남깁니다. 섀도 선택은 사용량, 할당량, 선택 메트릭을 갱신하지 않으며
+// 라운드로빈 커서도 별도(shadowIndex)로 사용하므로 실제 라우팅에 영향을 주지 않습니다.
+// 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) shadowSelectLocked(live *ProxyIP, liveStrategy RotationStrategy, opts SelectOptions) {
+	shadow := p.config.ShadowStrategy
+	if shadow == "" || shadow == liveStrategy || live == nil {
+		return
+	}
+
+	p.index, p.shadowIndex = p.shadowIndex, p.index
+	picked, err := p.pickProxyLocked(shadow, opts)
+	p.index, p.shadowIndex = p.shadowIndex, p.index
+
+	p.shadow.Selections.Add(1)
+	if err != nil || picked == nil {
+		p.shadow.Errors.Add(1)
+		log.Printf("[IP-ROTATION] Shadow selection: strategy=%s live=%s shadow=none err=%v", shadow, live.ID, err)
+		return
+	}
+	match := picked == live
+	if match {
+		p.shadow.Matches.Add(1)
+	}
+	log.Printf("[IP-ROTATION] Shadow selection: strategy=%s live=%s shadow=%s match=%t", shadow, live.ID, picked.ID, match)
+}
+
+// shadowSummaryLocked는 GetStats에 노출할 섀도 비교 요약을 반환합니다. 섀도 전략이 꺼져 있으면 nil입니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) shadowSummaryLocked() map[string]any {
+	if p.config.ShadowStrategy == "" {
+		return nil
+	}
+	selections := p.shadow.Selections.Load()
+	matches := p.shadow.Matches.Load()
+	matchRate := float64(0)
+	if selections > 0 {
+		matchRate = float64(matches) / float64(selections) * 100
+	}
+	return map[string]any{
+		"strategy":   p.config.ShadowStrategy,
+		"selections": selections,
+		"matches":    matches,
+		"errors":     p.shadow.Errors.Load(),
+		"matchRate":  fmt.Sprintf("%.2f%%", matchRate),
+	}
+}
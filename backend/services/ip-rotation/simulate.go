@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// 장애 시뮬레이션 동작입니다(POST /admin/proxy-simulate, TEST_MODE 전용).
+const (
+	SimulateFailure   = "failure"   // record synthetic failures (may trigger maxFailures auto-disable)
+	SimulateCaptcha   = "captcha"   // record synthetic captchas
+	SimulateBlock     = "block"     // report a hard block (disables immediately)
+	SimulateUnhealthy = "unhealthy" // force HealthStatus to unhealthy without a network check
+)
+
+// maxSimulateCount는 한 번의 시뮬레이션 요청으로 주입할 수 있는 최대 이벤트 수입니다.
+const maxSimulateCount = 1000
+
+// validSimulateActions는 시뮬레이션 동작 검증에 사용되는 허용 목록입니다.
+var validSimulateActions = map[string]bool{
+	SimulateFailure:   true,
+	SimulateCaptcha:   true,
+	SimulateBlock:     true,
+	SimulateUnhealthy: true,
+}
+
+// SimulationResult는 시뮬레이션을 적용한 뒤의 프록시 상태입니다.
+type SimulationResult struct {
+	ProxyID        string `json:"proxyId"`
+	Action         string `json:"action"`
+	Count          int    `json:"count"`
+	Enabled        bool   `json:"enabled"`
+	DisabledReason string `json:"disabledReason,omitempty"`
+	HealthStatus   string `json:"healthStatus,omitempty"`
+	FailCount      int64  `json:"failCount"`
+	CaptchaCount   int64  `json:"captchaCount"`
+	BlockCount     int64  `json:"blockCount"`
+}
+
+// Simulate는 실제 네트워크 호출 없이 프록시에 합성 실패/CAPTCHA/차단을 기록하거나 unhealthy로 만듭니다.
+// 결과 기록은 실제 /proxy/record와 같은 경로를 타므로 자동 비활성화, 이벤트, 가중치가 그대로 반영됩니다.
+// action과 count(1..maxSimulateCount)는 호출자가 검증해야 합니다.
+func (p *IPPool) Simulate(id, action string, count int) (*SimulationResult, error) {
+	p.mu.RLock()
+	proxy, ok := p.proxies[id]
+	removed := ok && proxy.Removed
+	p.mu.RUnlock()
+	if !ok || removed {
+		return nil, ErrProxyNotFound
+	}
+
+	const reason = "simulated"
+	for i := 0; i < count; i++ {
+		switch action {
+		case SimulateFailure:
+			p.RecordFailure(id, reason)
+		case SimulateCaptcha:
+			p.RecordCaptcha(id, reason)
+		case SimulateBlock:
+			p.RecordBlock(id, reason)
+		}
+	}
+	if action == SimulateUnhealthy {
+		p.forceUnhealthy(proxy)
+	}
+	log.Printf("[IP-ROTATION] Simulated %s: id=%s count=%d", action, id, count)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return &SimulationResult{
+		ProxyID:        id,
+		Action:         action,
+		Count:          count,
+		Enabled:        proxy.Enabled,
+		DisabledReason: proxy.DisabledReason,
+		HealthStatus:   proxy.HealthStatus,
+		FailCount:      proxy.FailCount.Load(),
+		CaptchaCount:   proxy.CaptchaCount.Load(),
+		BlockCount:     proxy.BlockCount.Load(),
+	}, nil
+}
+
+// forceUnhealthy는 unhealthyThreshold만큼 실패한 헬스체크 결과를 적용해 프록시를 unhealthy로 전환합니다.
+func (p *IPPool) forceUnhealthy(proxy *ProxyIP) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	threshold := max(p.config.UnhealthyThreshold, 1)
+	proxy.LastHealthCheck = time.Now()
+	for i := 0; i < threshold; i++ {
+		p.applyHealthResult(proxy, false, 0)
+	}
+	p.autoSave()
+}
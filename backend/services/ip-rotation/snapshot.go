@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PoolSnapshot은 GET /admin/snapshot의 응답으로, 특정 시점의 라우팅 결정을 재현하는 데 필요한 상태 전체입니다.
+// State는 SaveToFile과 같은 형식이라 그대로 상태 파일로 저장해 다시 불러올 수 있고, 나머지는 저장되지 않는
+// 파생 값(점수, 가중치, 현재 후보)입니다. 기본적으로 프록시 인증 정보(사용자 이름, 비밀번호, 헤더 값)는 가려지므로
+// 그대로 다시 불러오면 인증 정보가 복원되지 않습니다.
+type PoolSnapshot struct {
+	TakenAt           time.Time        `json:"takenAt"`
+	Strategy          RotationStrategy `json:"strategy"`                  // strategy a /proxy/next without options would use
+	Candidates        []string         `json:"candidates"`                // proxies that strategy chooses among right now, after all filters
+	CandidatesError   string           `json:"candidatesError,omitempty"` // why no proxy can be selected, if so
+	Weights           []SnapshotWeight `json:"weights"`                   // every proxy, sorted by ID
+	WeightsGeneration uint64           `json:"weightsGeneration"`         // bumps on every change that invalidates weights
+	State             IPPoolState      `json:"state"`
+}
+
+// SnapshotWeight는 스냅숏 시점의 프록시별 파생 값입니다.
+type SnapshotWeight struct {
+	ProxyID      string  `json:"proxyId"`
+	Score        float64 `json:"score"`
+	Weight       float64 `json:"weight"`             // weighted-strategy weight (score + minWeight, scaled by warm-up)
+	WarmupFactor float64 `json:"warmupFactor"`       // multiplier applied to the weight while warming up
+	Candidate    bool    `json:"candidate"`          // in Candidates
+	Share        float64 `json:"share"`              // weight / sum of candidate weights; 0 for non-candidates
+	Excluded     string  `json:"excluded,omitempty"` // why a non-candidate was filtered out
+}
+
+// redactedSnapshot은 State.Proxies 대신 인증 정보를 가린 프록시를 직렬화하는 PoolSnapshot입니다.
+type redactedSnapshot struct {
+	PoolSnapshot
+	State redactedState `json:"state"`
+}
+
+// redactedState는 Proxies를 가린 사본으로 바꾼 IPPoolState입니다.
+type redactedState struct {
+	IPPoolState
+	Proxies map[string]map[string]any `json:"proxies"`
+}
+
+// Snapshot은 풀 상태, 설정, 파생 값을 하나의 JSON 문서로 만듭니다. 인코딩까지 잠금 안에서 하므로 문서 안의 값은
+// 모두 같은 시점의 것입니다. 라운드로빈 커서와 사용 통계는 바꾸지 않습니다.
+// includeCredentials가 false이면 프록시의 사용자 이름, 비밀번호, 헤더 값을 가립니다.
+func (p *IPPool) Snapshot(includeCredentials bool) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for _, proxy := range p.proxies {
+		p.updateScore(proxy, now)
+	}
+
+	strategy := p.strategyForLocked(SelectOptions{})
+	snapshot := PoolSnapshot{
+		TakenAt:           now,
+		Strategy:          strategy,
+		Candidates:        []string{},
+		Weights:           make([]SnapshotWeight, 0, len(p.proxies)),
+		WeightsGeneration: p.weightsGen.Load(),
+		State: IPPoolState{
+			SchemaVersion: currentSchemaVersion,
+			Proxies:       p.proxies,
+			Order:         p.order,
+			Index:         p.index,
+			Config:        p.config,
+			SavedAt:       now,
+			LastServed:    p.lastServedLocked(),
+		},
+	}
+
+	candidates, err := p.candidatesLocked(strategy, SelectOptions{})
+	if err != nil {
+		snapshot.CandidatesError = err.Error()
+	}
+	isCandidate := make(map[*ProxyIP]bool, len(candidates))
+	for _, proxy := range candidates {
+		isCandidate[proxy] = true
+		snapshot.Candidates = append(snapshot.Candidates, proxy.ID)
+	}
+
+	_, minWeight := p.selectionWeights()
+	total := 0.0
+	for _, proxy := range candidates {
+		total += proxyWeight(proxy, minWeight)
+	}
+	for _, proxy := range p.proxies {
+		entry := SnapshotWeight{
+			ProxyID:      proxy.ID,
+			Score:        proxy.Score.Load(),
+			Weight:       proxyWeight(proxy, minWeight),
+			WarmupFactor: warmupFactor(proxy),
+			Candidate:    isCandidate[proxy],
+		}
+		if entry.Candidate && total > 0 {
+			entry.Share = entry.Weight / total
+		} else if !entry.Candidate {
+			entry.Excluded = p.exclusionReasonLocked(proxy)
+		}
+		snapshot.Weights = append(snapshot.Weights, entry)
+	}
+	sort.Slice(snapshot.Weights, func(i, j int) bool { return snapshot.Weights[i].ProxyID < snapshot.Weights[j].ProxyID })
+
+	var doc any = snapshot
+	if !includeCredentials {
+		proxies, err := redactedProxies(p.proxies)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal pool snapshot: %w", err)
+		}
+		doc = redactedSnapshot{
+			PoolSnapshot: snapshot,
+			State:        redactedState{IPPoolState: snapshot.State, Proxies: proxies},
+		}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pool snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// redactedProxies는 proxies를 JSON으로 풀어 사용자 이름, 비밀번호, 헤더 값을 가린 사본을 만듭니다.
+func redactedProxies(proxies map[string]*ProxyIP) (map[string]map[string]any, error) {
+	redacted := make(map[string]map[string]any, len(proxies))
+	for id, proxy := range proxies {
+		data, err := json.Marshal(proxy)
+		if err != nil {
+			return nil, err
+		}
+		var entry map[string]any
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, err
+		}
+		if _, ok := entry["username"]; ok {
+			entry["username"] = auditRedacted
+		}
+		redactSecrets(entry)
+		redacted[id] = entry
+	}
+	return redacted, nil
+}
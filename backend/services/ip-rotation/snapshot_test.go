@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSnapshotRedactsCredentialsUnlessRequested(t *testing.T) {
+	pool := NewIPPool(IPPoolConfig{})
+	if _, err := pool.AddProxy(&ProxyIP{
+		ID:       "p0",
+		Address:  "http://10.0.0.1:8080",
+		Username: "alice",
+		Password: "s3cret",
+		Headers:  map[string]string{"X-Api-Key": "token"},
+	}); err != nil {
+		t.Fatalf("add proxy: %v", err)
+	}
+	previous := globalIPPool
+	globalIPPool = pool
+	t.Cleanup(func() { globalIPPool = previous })
+
+	tests := []struct {
+		target                  string
+		username, password, hdr string
+	}{
+		{"/admin/snapshot", auditRedacted, auditRedacted, auditRedacted},
+		{"/admin/snapshot?includeCredentials=true", "alice", "s3cret", "token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handleSnapshot(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+			}
+			var snapshot struct {
+				Weights []SnapshotWeight `json:"weights"`
+				State   struct {
+					Proxies map[string]struct {
+						Address  string            `json:"address"`
+						Username string            `json:"username"`
+						Password string            `json:"password"`
+						Headers  map[string]string `json:"headers"`
+					} `json:"proxies"`
+				} `json:"state"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			proxy := snapshot.State.Proxies["p0"]
+			if proxy.Username != tt.username || proxy.Password != tt.password || proxy.Headers["X-Api-Key"] != tt.hdr {
+				t.Errorf("credentials = %q/%q/%q, want %q/%q/%q",
+					proxy.Username, proxy.Password, proxy.Headers["X-Api-Key"], tt.username, tt.password, tt.hdr)
+			}
+			if proxy.Address != "http://10.0.0.1:8080" || len(snapshot.Weights) != 1 {
+				t.Errorf("snapshot lost non-secret fields: address=%q weights=%d", proxy.Address, len(snapshot.Weights))
+			}
+		})
+	}
+	if pool.proxies["p0"].Password != "s3cret" {
+		t.Error("redacting the snapshot changed the live proxy")
+	}
+}
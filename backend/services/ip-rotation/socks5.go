@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// defaultUDPCheckTarget은 UDP 릴레이 점검 시 DNS 질의를 보낼 기본 대상입니다.
+const defaultUDPCheckTarget = "8.8.8.8:53"
+
+// SOCKS5 프로토콜 상수 (RFC 1928, RFC 1929)
+const (
+	socks5Version          = 0x05
+	socks5AuthNone         = 0x00
+	socks5AuthUserPass     = 0x02
+	socks5AuthNoAcceptable = 0xFF
+	socks5CmdUDPAssociate  = 0x03
+	socks5AtypIPv4         = 0x01
+	socks5AtypDomain       = 0x03
+	socks5AtypIPv6         = 0x04
+)
+
+// checkSOCKS5UDP는 SOCKS5 UDP ASSOCIATE 핸드셰이크를 수행하고, target이 설정되어 있으면
+// 릴레이를 통해 DNS 질의를 보내 실제로 UDP가 중계되는지 확인합니다. 전체 점검은 ctx 데드라인으로 제한됩니다.
+func checkSOCKS5UDP(ctx context.Context, dial dialFunc, username, password, dialAddr, target string) error {
+	conn, err := dial(ctx, "tcp", dialAddr)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	// The TCP control connection must stay open for the lifetime of the association
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := socks5Negotiate(conn, username, password); err != nil {
+		return err
+	}
+
+	// UDP ASSOCIATE with an unspecified client address
+	if _, err := conn.Write([]byte{socks5Version, socks5CmdUDPAssociate, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("udp associate: %w", err)
+	}
+	relayHost, relayPort, err := socks5ReadReply(conn)
+	if err != nil {
+		return fmt.Errorf("udp associate: %w", err)
+	}
+
+	// Many proxies answer with 0.0.0.0, meaning "same host as the TCP endpoint"
+	if ip := net.ParseIP(relayHost); ip == nil || ip.IsUnspecified() {
+		if h, _, err := net.SplitHostPort(dialAddr); err == nil {
+			relayHost = h
+		}
+	}
+	if target == "" {
+		return nil
+	}
+
+	return socks5UDPRoundTrip(ctx, net.JoinHostPort(relayHost, strconv.Itoa(relayPort)), target)
+}
+
+// socks5Negotiate는 인증 방식 협상 및 (필요 시) 사용자/비밀번호 인증을 수행합니다.
+func socks5Negotiate(conn net.Conn, username, password string) error {
+	methods := []byte{socks5AuthNone}
+	if username != "" {
+		methods = append(methods, socks5AuthUserPass)
+	}
+	greeting := append([]byte{socks5Version, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+
+	resp := make([]byte, 2)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if resp[0] != socks5Version {
+		return fmt.Errorf("unexpected SOCKS version: %d", resp[0])
+	}
+
+	switch resp[1] {
+	case socks5AuthNone:
+		return nil
+	case socks5AuthUserPass:
+		if len(username) > 255 || len(password) > 255 {
+			return errors.New("credentials too long for SOCKS5")
+		}
+		req := []byte{0x01, byte(len(username))}
+		req = append(req, username...)
+		req = append(req, byte(len(password)))
+		req = append(req, password...)
+		if _, err := conn.Write(req); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+		if _, err := io.ReadFull(conn, resp); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+		if resp[1] != 0x00 {
+			return errors.New("auth: credentials rejected")
+		}
+		return nil
+	case socks5AuthNoAcceptable:
+		return errors.New("no acceptable authentication method")
+	default:
+		return fmt.Errorf("unsupported authentication method: %d", resp[1])
+	}
+}
+
+// socks5ReadReply는 SOCKS5 요청 응답을 읽고 BND.ADDR/BND.PORT를 반환합니다.
+func socks5ReadReply(conn net.Conn) (string, int, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", 0, err
+	}
+	if header[0] != socks5Version {
+		return "", 0, fmt.Errorf("unexpected SOCKS version: %d", header[0])
+	}
+	if header[1] != 0x00 {
+		return "", 0, fmt.Errorf("request rejected (reply code %d)", header[1])
+	}
+
+	var host string
+	switch header[3] {
+	case socks5AtypIPv4, socks5AtypIPv6:
+		size := net.IPv4len
+		if header[3] == socks5AtypIPv6 {
+			size = net.IPv6len
+		}
+		addr := make([]byte, size)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return "", 0, err
+		}
+		host = net.IP(addr).String()
+	case socks5AtypDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", 0, err
+		}
+		name := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", 0, err
+		}
+		host = string(name)
+	default:
+		return "", 0, fmt.Errorf("unknown address type: %d", header[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", 0, err
+	}
+	return host, int(binary.BigEndian.Uint16(port)), nil
+}
+
+// socks5UDPRoundTrip은 UDP 릴레이를 통해 target으로 DNS 질의를 보내고 응답이 돌아오는지 확인합니다.
+func socks5UDPRoundTrip(ctx context.Context, relayAddr, target string) error {
+	targetAddr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return fmt.Errorf("udp target: %w", err)
+	}
+	ip4 := targetAddr.IP.To4()
+	if ip4 == nil {
+		return fmt.Errorf("udp target must be an IPv4 address: %s", target)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", relayAddr)
+	if err != nil {
+		return fmt.Errorf("udp relay: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	// SOCKS5 UDP header: RSV(2) FRAG(1) ATYP(1) DST.ADDR DST.PORT DATA
+	packet := []byte{0, 0, 0, socks5AtypIPv4}
+	packet = append(packet, ip4...)
+	packet = binary.BigEndian.AppendUint16(packet, uint16(targetAddr.Port))
+	packet = append(packet, dnsProbeQuery()...)
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("udp relay write: %w", err)
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return fmt.Errorf("udp relay read: %w", err)
+	}
+	// Header (10 bytes for IPv4) + at least a DNS header (12 bytes)
+	if n < 22 || buf[2] != 0 {
+		return errors.New("udp relay returned a malformed datagram")
+	}
+	return nil
+}
+
+// dnsProbeQuery는 UDP 점검용 최소 DNS 질의(example.com A 레코드)를 생성합니다.
+func dnsProbeQuery() []byte {
+	query := []byte{
+		0x13, 0x37, // ID
+		0x01, 0x00, // standard query, recursion desired
+		0x00, 0x01, // QDCOUNT
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+	for _, label := range []string{"example", "com"} {
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+	return append(query, 0x00, 0x00, 0x01, 0x00, 0x01) // root, QTYPE=A, QCLASS=IN
+}
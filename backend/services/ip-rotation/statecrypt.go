@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedStateMagic은 암호화된 상태 파일의 앞머리입니다. 평문 상태 파일은 JSON이라 '{'로 시작하므로
+// 이 값으로 두 형식을 구분합니다. 인증 데이터(AAD)로도 쓰여 앞머리가 바뀐 파일은 복호화되지 않습니다.
+var encryptedStateMagic = []byte("IPRSTATE-AESGCM1\n")
+
+// stateCipher는 상태 파일을 AES-GCM으로 암호화/복호화합니다. 파일 형식은 magic | nonce | ciphertext입니다.
+type stateCipher struct {
+	aead cipher.AEAD
+}
+
+// persistenceKeyFromEnv는 PERSISTENCE_KEY(base64로 인코딩한 16/24/32바이트 키, 예: openssl rand -base64 32)를 읽어
+// stateCipher를 만듭니다. 값이 비어 있으면 nil을 반환하며 상태 파일은 평문으로 저장됩니다.
+func persistenceKeyFromEnv(value string) (*stateCipher, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("PERSISTENCE_KEY must be base64-encoded")
+	}
+	return newStateCipher(key)
+}
+
+// newStateCipher는 key(16, 24 또는 32바이트)로 AES-GCM stateCipher를 만듭니다.
+func newStateCipher(key []byte) (*stateCipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("PERSISTENCE_KEY must decode to 16, 24 or 32 bytes, got %d", len(key))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &stateCipher{aead: aead}, nil
+}
+
+// isEncryptedState는 data가 암호화된 상태 파일인지 반환합니다.
+func isEncryptedState(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedStateMagic)
+}
+
+// seal은 평문 상태를 암호화합니다. 저장할 때마다 새 nonce를 씁니다.
+func (c *stateCipher) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(encryptedStateMagic)+len(nonce)+len(plain)+c.aead.Overhead())
+	out = append(out, encryptedStateMagic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plain, encryptedStateMagic), nil
+}
+
+// open은 암호화된 상태 파일을 복호화합니다. 키가 다르거나 파일이 손상되었으면 오류를 반환합니다.
+func (c *stateCipher) open(data []byte) ([]byte, error) {
+	body := data[len(encryptedStateMagic):]
+	if len(body) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: encrypted state file is truncated", ErrStateEncryption)
+	}
+	nonce, ciphertext := body[:c.aead.NonceSize()], body[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, ciphertext, encryptedStateMagic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decrypt state file (wrong PERSISTENCE_KEY or corrupted file)", ErrStateEncryption)
+	}
+	return plain, nil
+}
+
+// SetStateCipher는 상태 파일 암호화에 쓸 stateCipher를 설정합니다. nil이면 평문으로 저장합니다.
+// allowPlaintext가 true면 키가 있어도 평문 상태 파일을 읽어, 다음 저장부터 암호화되도록 옮겨 갈 수 있습니다.
+func (p *IPPool) SetStateCipher(c *stateCipher, allowPlaintext bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stateCipher = c
+	p.allowPlaintextState = allowPlaintext
+}
+
+// decodeStateLocked는 읽은 상태 파일을 설정된 키에 맞게 복호화합니다. 키 설정과 파일 형식이 맞지 않으면
+// 쓰레기 값을 읽거나 평문 파일을 덮어쓰지 않도록 ErrStateEncryption을 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) decodeStateLocked(data []byte) ([]byte, error) {
+	encrypted := isEncryptedState(data)
+	switch {
+	case encrypted && p.stateCipher == nil:
+		return nil, fmt.Errorf("%w: state file is encrypted but PERSISTENCE_KEY is not set", ErrStateEncryption)
+	case encrypted:
+		return p.stateCipher.open(data)
+	case p.stateCipher != nil && !p.allowPlaintextState:
+		return nil, fmt.Errorf("%w: PERSISTENCE_KEY is set but the state file is not encrypted (set PERSISTENCE_ALLOW_PLAINTEXT=true once to encrypt it on the next save)", ErrStateEncryption)
+	default:
+		return data, nil
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Selector는 후보 목록에서 프록시 하나를 고르는 선택 전략입니다. 후보 목록은 활성/할당량/티어 필터를 거친
+// 비어 있지 않은 목록이며, 호출 중에는 p.mu 쓰기 잠금이 잡혀 있습니다.
+type Selector interface {
+	Select(enabled []*ProxyIP) *ProxyIP
+}
+
+// SelectorFunc는 일반 함수를 Selector로 사용할 수 있게 합니다.
+type SelectorFunc func(enabled []*ProxyIP) *ProxyIP
+
+// Select는 f(enabled)를 호출합니다.
+func (f SelectorFunc) Select(enabled []*ProxyIP) *ProxyIP {
+	return f(enabled)
+}
+
+// SelectorFactory는 풀과 요청별 선택 옵션(대상 좌표, 해시 키 등)에 묶인 Selector를 만듭니다.
+type SelectorFactory func(p *IPPool, opts SelectOptions) Selector
+
+// strategyRegistry는 전략 이름별 Selector 생성기입니다. 기본 제공 전략 외에는 RegisterStrategy로 추가합니다.
+var strategyRegistry = map[RotationStrategy]SelectorFactory{
+	StrategyRoundRobin: func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectRoundRobin) },
+	StrategyRandom:     func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectRandom) },
+	StrategyLeastUsed:  func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectLeastUsed) },
+	StrategyWeighted:   func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectWeighted) },
+	StrategyGeographic: func(p *IPPool, opts SelectOptions) Selector {
+		return SelectorFunc(func(enabled []*ProxyIP) *ProxyIP { return p.selectGeographic(enabled, opts) })
+	},
+
+	StrategyConsistentHash: func(p *IPPool, opts SelectOptions) Selector {
+		return SelectorFunc(func(enabled []*ProxyIP) *ProxyIP { return p.selectConsistentHash(enabled, opts) })
+	},
+	StrategyCaptchaAware: func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectCaptchaAware) },
+	StrategyCostAware:    func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectCostAware) },
+	StrategyThompson:     func(p *IPPool, _ SelectOptions) Selector { return SelectorFunc(p.selectThompson) },
+}
+
+// RegisterStrategy는 새 선택 전략을 등록합니다. 등록된 이름은 설정, /proxy/next의 strategy 파라미터,
+// 섀도 전략에서 바로 사용할 수 있습니다. 레지스트리는 잠금 없이 읽히므로 init 시점에만 호출해야 하며,
+// 이름이 비었거나 이미 등록된 경우 panic합니다.
+func RegisterStrategy(name RotationStrategy, factory SelectorFactory) {
+	if name == "" || factory == nil {
+		panic("ip-rotation: RegisterStrategy requires a name and a factory")
+	}
+	if _, dup := strategyRegistry[name]; dup {
+		panic("ip-rotation: strategy already registered: " + string(name))
+	}
+	strategyRegistry[name] = factory
+}
+
+// validStrategy는 strategy가 등록된 전략인지 반환합니다.
+func validStrategy(strategy RotationStrategy) bool {
+	_, ok := strategyRegistry[strategy]
+	return ok
+}
+
+// strategyNames는 등록된 전략 이름을 정렬하여 쉼표로 이은 문자열입니다(오류 메시지용).
+func strategyNames() string {
+	names := make([]string, 0, len(strategyRegistry))
+	for name := range strategyRegistry {
+		names = append(names, string(name))
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// selectorFor는 strategy의 Selector를 만듭니다. 등록되지 않은 전략은 라운드로빈으로 대체합니다.
+func (p *IPPool) selectorFor(strategy RotationStrategy, opts SelectOptions) Selector {
+	factory, ok := strategyRegistry[strategy]
+	if !ok {
+		factory = strategyRegistry[StrategyRoundRobin]
+	}
+	return factory(p, opts)
+}
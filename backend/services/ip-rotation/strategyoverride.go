@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StrategyOverrides는 태그 또는 국가 → 전략 매핑입니다(IPPoolConfig.TagStrategies, CountryStrategies).
+type StrategyOverrides map[string]RotationStrategy
+
+// strategyForLocked는 이번 선택에 쓸 전략을 정합니다. 요청에 지정된 전략이 가장 우선이고, 그다음 요청 태그의
+// TagStrategies, 요청 국가의 CountryStrategies 순이며, 맞는 것이 없으면 전역 Strategy를 씁니다.
+// 태그와 국가는 대소문자를 구분하지 않습니다. 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) strategyForLocked(opts SelectOptions) RotationStrategy {
+	if opts.Strategy != "" {
+		return opts.Strategy
+	}
+	if strategy, ok := lookupStrategy(p.config.TagStrategies, opts.Tag); ok {
+		return strategy
+	}
+	if strategy, ok := lookupStrategy(p.config.CountryStrategies, opts.Country); ok {
+		return strategy
+	}
+	return p.config.Strategy
+}
+
+// lookupStrategy는 key(대소문자 무시)에 지정된 전략을 찾습니다. key가 비어 있으면 찾지 않습니다.
+func lookupStrategy(overrides StrategyOverrides, key string) (RotationStrategy, bool) {
+	if key == "" {
+		return "", false
+	}
+	for k, strategy := range overrides {
+		if strings.EqualFold(k, key) {
+			return strategy, true
+		}
+	}
+	return "", false
+}
+
+// validateStrategyOverrides는 태그/국가별 전략 매핑을 검사합니다. name은 오류 메시지에 쓸 설정 이름입니다.
+func validateStrategyOverrides(name string, overrides StrategyOverrides) error {
+	for key, strategy := range overrides {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid %s: empty key", name)
+		}
+		if !validStrategy(strategy) {
+			return fmt.Errorf("invalid %s entry %q: %s, must be one of: %s", name, key, strategy, strategyNames())
+		}
+	}
+	return nil
+}
+
+// parseStrategyOverrides는 환경 변수 name을 "residential:weighted,datacenter:round_robin" 형식의 태그/국가별 전략으로 파싱합니다.
+// 형식이 잘못된 항목은 건너뛰고 env에 기록하며, 알 수 없는 전략은 Validate에서 걸러집니다.
+func parseStrategyOverrides(env *envConfig, name string) StrategyOverrides {
+	var overrides StrategyOverrides
+	for _, entry := range strings.Split(os.Getenv(name), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, strategy, ok := strings.Cut(entry, ":")
+		key, strategy = strings.TrimSpace(key), strings.TrimSpace(strategy)
+		if !ok || key == "" || strategy == "" {
+			env.invalidEntry(name, entry, "want key:strategy")
+			continue
+		}
+		if overrides == nil {
+			overrides = make(StrategyOverrides)
+		}
+		overrides[key] = RotationStrategy(strategy)
+	}
+	return overrides
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStrategyOverridesReportsInvalidEntries(t *testing.T) {
+	t.Setenv("TAG_STRATEGIES", "residential:weighted, datacenter,:random,mobile:,")
+	var env envConfig
+	got := parseStrategyOverrides(&env, "TAG_STRATEGIES")
+	if want := (StrategyOverrides{"residential": StrategyWeighted}); !reflect.DeepEqual(got, want) {
+		t.Errorf("overrides = %v, want %v", got, want)
+	}
+	if len(env.problems) != 3 {
+		t.Errorf("problems = %q, want the three malformed entries", env.problems)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// DisabledReasonLowSuccessRate는 누적 성공률이 MinSuccessRate 아래로 떨어져 자동 비활성화된 프록시의 사유입니다.
+// max_failures와 같이 쿨다운 후 재활성화되며, 이때 실패 수가 초기화됩니다.
+const DisabledReasonLowSuccessRate = "low_success_rate"
+
+// defaultMinSuccessSamples는 MinSuccessSamples가 0일 때 성공률 하한을 적용하기 위한 최소 결과 수입니다.
+const defaultMinSuccessSamples = 100
+
+// belowSuccessFloor는 프록시가 충분한 결과(MinSuccessSamples)를 쌓았고 성공률이 MinSuccessRate 미만인지 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) belowSuccessFloor(proxy *ProxyIP) bool {
+	if p.config.MinSuccessRate <= 0 {
+		return false
+	}
+	samples := int64(p.config.MinSuccessSamples)
+	if samples <= 0 {
+		samples = defaultMinSuccessSamples
+	}
+	success := proxy.SuccessCount.Load()
+	total := success + proxy.FailCount.Load()
+	if total < samples {
+		return false
+	}
+	return float64(success)/float64(total)*100 < p.config.MinSuccessRate
+}
+
+// disableLowSuccessLocked는 성공률 하한 미달로 프록시를 비활성화합니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) disableLowSuccessLocked(proxy *ProxyIP) {
+	proxy.Enabled = false
+	proxy.DisabledAt = time.Now()
+	proxy.DisabledReason = DisabledReasonLowSuccessRate
+	markUnhealthyStreak(proxy)
+	p.metrics.autoDisables.Add(1)
+	p.recordEvent(proxy.ID, EventDisabled, "success rate below floor", 0)
+	p.invalidateWeights()
+	log.Printf("[IP-ROTATION] Proxy auto-disabled due to low success rate: id=%s rate=%.2f%% floor=%.2f%% (will re-enable after %d minutes)",
+		proxy.ID, calculateSuccessRate(proxy), p.config.MinSuccessRate, p.config.CooldownMinutes)
+}
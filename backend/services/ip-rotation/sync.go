@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 프로바이더 동기화 관련 기본값과 한도입니다.
+const (
+	defaultProviderSyncMinutes = 60
+	defaultProviderSyncName    = "provider-sync"
+	providerSyncTimeout        = 30 * time.Second
+	maxProviderSyncBody        = 8 << 20 // provider listings beyond this are rejected
+)
+
+// ProviderSyncStatus는 마지막 프로바이더 동기화 결과입니다(풀 통계의 providerSync).
+type ProviderSyncStatus struct {
+	LastAttempt time.Time `json:"lastAttempt,omitempty"`
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+	LastDiff    *PoolDiff `json:"lastDiff,omitempty"`
+}
+
+// providerSyncAuthFromEnv는 프로바이더 API 인증 헤더를 만듭니다. headerName이 비어 있으면
+// "Authorization: Bearer <token>"을, 아니면 그 헤더에 토큰을 그대로 보냅니다(예: X-Api-Key). 토큰이 없으면 nil입니다.
+// 토큰은 설정(/admin/proxy-pool-config)에 노출되지 않도록 환경 변수로만 받습니다.
+func providerSyncAuthFromEnv(token, headerName string) http.Header {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil
+	}
+	header := http.Header{}
+	if headerName = strings.TrimSpace(headerName); headerName != "" {
+		header.Set(headerName, token)
+	} else {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return header
+}
+
+// SetProviderSyncAuth는 프로바이더 목록을 가져올 때 보낼 인증 헤더를 설정합니다.
+func (p *IPPool) SetProviderSyncAuth(header http.Header) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.providerSyncAuth = header
+}
+
+// providerSyncName은 동기화된 프록시에 기록되는 Provider 이름입니다. 동기화는 이 Provider의 프록시만 추가/삭제합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) providerSyncName() string {
+	if p.config.ProviderSyncName != "" {
+		return p.config.ProviderSyncName
+	}
+	return defaultProviderSyncName
+}
+
+// StartProviderSync는 ProviderSyncURL의 프록시 목록을 주기적으로 가져와 풀에 반영하는 백그라운드 루틴을 시작합니다.
+// 첫 동기화는 바로 실행됩니다. ProviderSyncURL이 비어 있으면 아무것도 하지 않습니다.
+func (p *IPPool) StartProviderSync() {
+	p.mu.Lock()
+	if p.providerSyncRunning || p.config.ProviderSyncURL == "" {
+		p.mu.Unlock()
+		return
+	}
+	p.providerSyncRunning = true
+	minutes := p.config.ProviderSyncMinutes
+	if minutes <= 0 {
+		minutes = defaultProviderSyncMinutes
+	}
+	stop := p.stopProviderSync
+	p.mu.Unlock()
+
+	go func() {
+		log.Printf("[IP-ROTATION] Provider sync started (interval=%dm)", minutes)
+		ticker := time.NewTicker(time.Duration(minutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), providerSyncTimeout)
+			p.SyncProvider(ctx)
+			cancel()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				log.Printf("[IP-ROTATION] Provider sync stopped")
+				return
+			}
+		}
+	}()
+}
+
+// StopProviderSync는 프로바이더 동기화 루틴을 중지합니다.
+func (p *IPPool) StopProviderSync() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.providerSyncRunning {
+		close(p.stopProviderSync)
+		p.providerSyncRunning = false
+		p.stopProviderSync = make(chan struct{})
+	}
+}
+
+// SyncProvider는 프로바이더 목록을 한 번 가져와 풀에 반영합니다. 목록의 프록시는 주소로 기존 프록시와 짝지어지고
+// Provider가 providerSyncName으로 기록됩니다. 새 프록시는 추가되고, 목록에서 빠진 같은 Provider의 프록시는 삭제되며,
+// 남은 프록시는 통계와 운영자 설정을 유지한 채 프로바이더 필드(providerSpec)만 갱신됩니다.
+// 빈 목록은 프로바이더 장애일 수 있으므로 반영하지 않습니다.
+func (p *IPPool) SyncProvider(ctx context.Context) (*PoolDiff, error) {
+	p.mu.RLock()
+	target := p.config.ProviderSyncURL
+	auth := p.providerSyncAuth.Clone()
+	name := p.providerSyncName()
+	p.mu.RUnlock()
+
+	listed, err := fetchProviderProxies(ctx, target, auth)
+	if err == nil && len(listed) == 0 {
+		err = errors.New("provider returned no proxies; keeping the current pool")
+	}
+	var diff *PoolDiff
+	if err == nil {
+		for _, proxy := range listed {
+			if proxy != nil {
+				// Provider IDs are not ours; entries are matched by address
+				proxy.ID = ""
+				proxy.Provider = name
+			}
+		}
+		diff, err = p.diffPool(listed, true, func(proxy *ProxyIP) bool { return proxy.Provider == name }, providerSpec)
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	p.providerSync.LastAttempt = now
+	if err != nil {
+		p.providerSync.LastError = err.Error()
+	} else {
+		p.providerSync.LastSuccess = now
+		p.providerSync.LastError = ""
+		p.providerSync.LastDiff = diff
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		log.Printf("[IP-ROTATION] Provider sync failed: %v", err)
+		return nil, err
+	}
+	log.Printf("[IP-ROTATION] Provider sync complete: provider=%s added=%d removed=%d modified=%d unchanged=%d",
+		name, len(diff.Added), len(diff.Removed), len(diff.Modified), diff.Unchanged)
+	return diff, nil
+}
+
+// providerSpec은 동기화가 기존 프록시에 반영할 설정입니다. 프로바이더가 정하는 필드(주소, 프로토콜, 인증 정보,
+// 국가/도시)만 목록에서 가져오고, 운영자가 PATCH 등으로 정한 나머지 필드(우선순위, 태그, 할당량, 메모 등)는 유지합니다.
+func providerSpec(live, listed proxySpec) proxySpec {
+	live.Address = listed.Address
+	live.Protocol = listed.Protocol
+	live.Username, live.Password = listed.Username, listed.Password
+	live.Country, live.City = listed.Country, listed.City
+	return live
+}
+
+// fetchProviderProxies는 프로바이더 API에서 프록시 목록을 가져옵니다. 응답은 프록시 배열이거나
+// {"proxies": [...]} 형태의 JSON이며, 각 항목은 풀의 프록시와 같은 필드를 씁니다.
+func fetchProviderProxies(ctx context.Context, target string, auth http.Header) ([]*ProxyIP, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range auth {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderSyncBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxProviderSyncBody {
+		return nil, fmt.Errorf("provider listing exceeds %d bytes", maxProviderSyncBody)
+	}
+
+	var listed []*ProxyIP
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var doc struct {
+			Proxies []*ProxyIP `json:"proxies"`
+		}
+		err = json.Unmarshal(trimmed, &doc)
+		listed = doc.Proxies
+	} else {
+		err = json.Unmarshal(trimmed, &listed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider listing: %w", err)
+	}
+	return listed, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSyncProviderKeepsOperatorFields(t *testing.T) {
+	var listing atomic.Value
+	listing.Store(`[{"address":"http://1.2.3.4:8080","country":"US","username":"u","password":"old"}]`)
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(listing.Load().(string)))
+	}))
+	defer provider.Close()
+
+	pool := NewIPPool(IPPoolConfig{ProviderSyncURL: provider.URL})
+	ctx := context.Background()
+	diff, err := pool.SyncProvider(ctx)
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	if len(diff.Added) != 1 {
+		t.Fatalf("first sync added %v, want one proxy", diff.Added)
+	}
+
+	// Operator tuning after the proxy was synced
+	pool.mu.Lock()
+	var proxy *ProxyIP
+	for _, candidate := range pool.proxies {
+		proxy = candidate
+	}
+	proxy.Priority = 5
+	proxy.Tags = []string{"premium"}
+	proxy.Notes = "keep for checkout flows"
+	proxy.MaxUsageCount = 100
+	pool.mu.Unlock()
+
+	// The provider rotates the password, moves the exit and sends its own values for operator fields
+	listing.Store(`[{"address":"http://1.2.3.4:8080","country":"DE","city":"Berlin","username":"u","password":"new","priority":9,"tags":["bulk"]}]`)
+	diff, err = pool.SyncProvider(ctx)
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if len(diff.Modified) != 1 || len(diff.Added) != 0 || len(diff.Removed) != 0 {
+		t.Fatalf("second sync diff = %+v, want one modified proxy", diff)
+	}
+
+	pool.mu.RLock()
+	if proxy.Country != "DE" || proxy.City != "Berlin" || proxy.Password != "new" {
+		t.Errorf("provider fields not updated: country=%s city=%s password=%s", proxy.Country, proxy.City, proxy.Password)
+	}
+	if proxy.Priority != 5 || !slices.Equal(proxy.Tags, []string{"premium"}) || proxy.Notes == "" || proxy.MaxUsageCount != 100 {
+		t.Errorf("operator fields overwritten: priority=%d tags=%v notes=%q maxUsage=%d",
+			proxy.Priority, proxy.Tags, proxy.Notes, proxy.MaxUsageCount)
+	}
+	pool.mu.RUnlock()
+
+	// Operator fields no longer count as drift
+	diff, err = pool.SyncProvider(ctx)
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if len(diff.Modified) != 0 || diff.Unchanged != 1 {
+		t.Errorf("third sync diff = %+v, want the proxy unchanged", diff)
+	}
+}
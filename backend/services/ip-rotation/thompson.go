@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// thompson 전략의 선택 확률을 몬테카를로로 추정할 때의 시행 횟수입니다. 잠금 안에서 실행되므로 전체 Beta 추출 수를
+// thompsonProbabilityBudget으로 묶고, 후보가 많으면 시행 횟수를 줄이되 thompsonMinProbabilitySamples 아래로는 줄이지 않습니다.
+const (
+	thompsonProbabilitySamples    = 2000
+	thompsonMinProbabilitySamples = 100
+	thompsonProbabilityBudget     = 200_000
+)
+
+// lockedRand는 시드를 고정한 난수원을 여러 고루틴이 함께 쓸 수 있게 뮤텍스로 감쌉니다. 선택과 확률 추정은 읽기 잠금만으로
+// 동시에 실행되므로 *rand.Rand를 그대로 공유할 수 없습니다. nil이면 math/rand/v2의 전역 난수원을 씁니다.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newLockedRand는 src에서 값을 뽑는 lockedRand를 만듭니다. 테스트가 시드를 고정할 때 씁니다.
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{r: rand.New(src)}
+}
+
+// NormFloat64는 표준 정규 분포에서 값을 뽑습니다.
+func (l *lockedRand) NormFloat64() float64 {
+	if l == nil {
+		return rand.NormFloat64()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.NormFloat64()
+}
+
+// Float64는 [0, 1)에서 균등하게 값을 뽑습니다.
+func (l *lockedRand) Float64() float64 {
+	if l == nil {
+		return rand.Float64()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// selectThompson은 후보마다 성공 확률을 Beta(성공+1, 실패+1)에서 하나씩 뽑아 가장 큰 값의 프록시를 선택합니다
+// (Beta-Bernoulli 톰슨 샘플링). 결과가 적은 프록시는 분포가 넓어 가끔 높은 값이 나오므로 탐색되고,
+// 결과가 쌓일수록 성공률이 높은 프록시로 선택이 모입니다. 별도의 탐색 비율 설정이 필요 없습니다.
+func (p *IPPool) selectThompson(proxies []*ProxyIP) *ProxyIP {
+	var best *ProxyIP
+	bestSample := -1.0
+	for _, proxy := range proxies {
+		if sample := thompsonSample(p.thompsonRand, proxy); sample > bestSample {
+			best, bestSample = proxy, sample
+		}
+	}
+	return best
+}
+
+// thompsonSample은 프록시의 성공/실패 카운트로 만든 Beta 사후 분포에서 성공 확률 하나를 뽑습니다.
+func thompsonSample(rng *lockedRand, proxy *ProxyIP) float64 {
+	return sampleBeta(rng, float64(proxy.SuccessCount.Load())+1, float64(proxy.FailCount.Load())+1)
+}
+
+// thompsonProbabilityLocked는 thompson 전략에서 proxy가 선택될 확률을 표본 추출로 추정합니다.
+// 시행마다 proxy의 값을 먼저 뽑고 이를 넘는 후보가 나오면 바로 다음 시행으로 넘어가므로, 선택되지 않는 시행은 대개 짧게 끝납니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) thompsonProbabilityLocked(proxy *ProxyIP, candidates []*ProxyIP) (float64, string) {
+	samples := min(thompsonProbabilitySamples, max(thompsonMinProbabilitySamples, thompsonProbabilityBudget/len(candidates)))
+
+	// Posterior parameters are read once instead of loading the counters on every draw
+	type posterior struct{ a, b float64 }
+	own := posterior{float64(proxy.SuccessCount.Load()) + 1, float64(proxy.FailCount.Load()) + 1}
+	others := make([]posterior, 0, len(candidates)-1)
+	for _, candidate := range candidates {
+		if candidate != proxy {
+			others = append(others, posterior{float64(candidate.SuccessCount.Load()) + 1, float64(candidate.FailCount.Load()) + 1})
+		}
+	}
+
+	wins := 0
+	for i := 0; i < samples; i++ {
+		sample := sampleBeta(p.thompsonRand, own.a, own.b)
+		won := true
+		for _, other := range others {
+			// selectThompson keeps the earlier candidate on ties; a continuous sample makes those negligible
+			if sampleBeta(p.thompsonRand, other.a, other.b) > sample {
+				won = false
+				break
+			}
+		}
+		if won {
+			wins++
+		}
+	}
+	return float64(wins) / float64(samples), fmt.Sprintf(
+		"estimated from %d Thompson draws over %d candidates (Beta(successes+1, failures+1) per proxy)",
+		samples, len(candidates))
+}
+
+// sampleBeta는 Beta(a, b) 분포에서 값을 뽑습니다. a, b는 1 이상이어야 하며, rng가 nil이면 전역 난수원을 씁니다.
+func sampleBeta(rng *lockedRand, a, b float64) float64 {
+	x := sampleGamma(rng, a)
+	y := sampleGamma(rng, b)
+	return x / (x + y)
+}
+
+// sampleGamma는 Marsaglia-Tsang 방법으로 Gamma(shape, 1) 분포에서 값을 뽑습니다. shape는 1 이상이어야 합니다.
+func sampleGamma(rng *lockedRand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// thompsonShare는 candidates에 대해 톰슨 선택을 n번 실행해 첫 후보가 선택된 비율을 반환합니다.
+func thompsonShare(pool *IPPool, candidates []*ProxyIP, n int) float64 {
+	best := candidates[0]
+	wins := 0
+	for i := 0; i < n; i++ {
+		if pool.selectThompson(candidates) == best {
+			wins++
+		}
+	}
+	return float64(wins) / float64(n)
+}
+
+func TestSelectThompsonConvergesOnBestProxy(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{Strategy: StrategyThompson}, 3)
+	pool.thompsonRand = newLockedRand(rand.NewPCG(1, 2))
+	candidates := []*ProxyIP{pool.proxies["p0"], pool.proxies["p1"], pool.proxies["p2"]}
+
+	// Same 90% / 50% / 20% success rates, first with few results and then with ten times as many
+	recordResults(pool, "p0", 9, 1)
+	recordResults(pool, "p1", 5, 5)
+	recordResults(pool, "p2", 2, 8)
+	early := thompsonShare(pool, candidates, 5000)
+
+	recordResults(pool, "p0", 81, 9)
+	recordResults(pool, "p1", 45, 45)
+	recordResults(pool, "p2", 18, 72)
+	late := thompsonShare(pool, candidates, 5000)
+
+	if late <= early {
+		t.Errorf("best proxy share went from %.3f to %.3f, want it to grow as results accumulate", early, late)
+	}
+	if late < 0.98 {
+		t.Errorf("best proxy share = %.3f after 100 results each, want it to converge above 0.98", late)
+	}
+
+	probability, err := pool.SelectionProbability("p0")
+	if err != nil {
+		t.Fatalf("selection probability: %v", err)
+	}
+	if diff := probability.Probability - late; diff > 0.02 || diff < -0.02 {
+		t.Errorf("estimated probability %.3f disagrees with the observed share %.3f", probability.Probability, late)
+	}
+}
+
+func TestThompsonSeededSourceConcurrentUse(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{Strategy: StrategyThompson}, 3)
+	pool.thompsonRand = newLockedRand(rand.NewPCG(1, 2))
+	recordResults(pool, "p0", 9, 1)
+
+	// Probability queries run under the read lock, so they draw from the source concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.SelectionProbability("p0"); err != nil {
+				t.Errorf("selection probability: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// certReloader는 TLS 인증서/키 파일을 보관하고, 재시작 없이 교체(로테이션)할 수 있도록 다시 읽습니다.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+}
+
+// newCertReloader는 인증서/키 파일을 읽어 certReloader를 생성합니다.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload는 인증서/키 파일을 다시 읽습니다. 실패하면 기존 인증서를 계속 사용합니다.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	log.Printf("[IP-ROTATION] TLS certificate loaded from %s", r.certFile)
+	return nil
+}
+
+// GetCertificate는 tls.Config.GetCertificate로 사용되며, 현재 로드된 인증서를 반환합니다.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// tlsReloaderFromEnv는 TLS_CERT_FILE/TLS_KEY_FILE이 모두 설정되어 있으면 certReloader를 반환합니다.
+// 둘 다 없으면 nil(평문 HTTP)이고, 하나만 설정된 경우는 설정 오류입니다.
+func tlsReloaderFromEnv(certFile, keyFile string) (*certReloader, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	return newCertReloader(certFile, keyFile)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// healthIdleConnTimeout은 헬스체크용 캐시 Transport가 유휴 연결을 유지하는 시간입니다.
+// 일반적인 헬스체크 주기보다 길어야 다음 점검에서 연결을 재사용할 수 있습니다.
+const healthIdleConnTimeout = 5 * time.Minute
+
+// transportCache는 프록시별 헬스체크용 http.Transport를 보관해 점검 간 연결을 재사용합니다.
+// 프록시 ID로 찾으며, 프록시 URL, 헤더 또는 상위 프록시가 바뀌면 Transport를 새로 만듭니다.
+type transportCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedTransport
+}
+
+// cachedTransport는 Transport와 그것을 만들 때 쓴 설정입니다.
+type cachedTransport struct {
+	transport *http.Transport
+	proxyURL  string
+	header    http.Header
+	upstream  string
+}
+
+func newTransportCache() *transportCache {
+	return &transportCache{entries: make(map[string]*cachedTransport)}
+}
+
+// get은 id 프록시의 캐시된 Transport를 반환하고, 없거나 설정이 달라졌으면 새로 만듭니다.
+// 유휴 연결은 프록시당 하나로 제한됩니다.
+func (c *transportCache) get(id string, proxyURL *url.URL, header http.Header, upstream string) *http.Transport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[id]; ok {
+		if entry.proxyURL == proxyURL.String() && entry.upstream == upstream && maps.EqualFunc(entry.header, header, slices.Equal[[]string]) {
+			return entry.transport
+		}
+		entry.transport.CloseIdleConnections()
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyURL(proxyURL),
+		ProxyConnectHeader:  header,
+		DialContext:         newDialFunc(upstream),
+		MaxIdleConns:        1,
+		MaxIdleConnsPerHost: 1,
+		IdleConnTimeout:     healthIdleConnTimeout,
+	}
+	c.entries[id] = &cachedTransport{
+		transport: transport,
+		proxyURL:  proxyURL.String(),
+		header:    header,
+		upstream:  upstream,
+	}
+	return transport
+}
+
+// drop은 id 프록시의 Transport를 캐시에서 빼고 유휴 연결을 닫습니다.
+func (c *transportCache) drop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[id]; ok {
+		entry.transport.CloseIdleConnections()
+		delete(c.entries, id)
+	}
+}
+
+// closeAll은 캐시된 모든 Transport의 유휴 연결을 닫고 캐시를 비웁니다.
+func (c *transportCache) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, entry := range c.entries {
+		entry.transport.CloseIdleConnections()
+		delete(c.entries, id)
+	}
+}
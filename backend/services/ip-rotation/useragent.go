@@ -0,0 +1,48 @@
+package main
+
+import "strings"
+
+// parseUserAgents는 "|"로 구분된 User-Agent 목록을 파싱합니다. User-Agent 문자열에는 쉼표가 흔하므로 "|"를 씁니다.
+func parseUserAgents(v string) []string {
+	var agents []string
+	for _, ua := range strings.Split(v, "|") {
+		if ua = strings.TrimSpace(ua); ua != "" {
+			agents = append(agents, ua)
+		}
+	}
+	return agents
+}
+
+// validateUserAgents는 User-Agent 목록이 헤더 값으로 보낼 수 있는지 검사하고, 문제가 있으면 설명을 반환합니다.
+func validateUserAgents(agents []string) string {
+	for _, ua := range agents {
+		if strings.TrimSpace(ua) == "" {
+			return "user agents must not be empty"
+		}
+		if strings.ContainsAny(ua, "\r\n") {
+			return "user agent must not contain line breaks: " + ua
+		}
+	}
+	return ""
+}
+
+// pickUserAgent는 프록시와 함께 쓸 User-Agent를 무작위로 하나 고릅니다. 프록시에 목록이 없으면
+// 풀 기본 목록(DefaultUserAgents)을 쓰고, 둘 다 비어 있으면 빈 문자열을 반환합니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) pickUserAgent(proxy *ProxyIP) string {
+	agents := proxy.UserAgents
+	if len(agents) == 0 {
+		agents = p.config.DefaultUserAgents
+	}
+	if len(agents) == 0 {
+		return ""
+	}
+	return agents[secureRandomInt(len(agents))]
+}
+
+// UserAgentFor는 pickUserAgent를 읽기 잠금 아래에서 호출합니다.
+func (p *IPPool) UserAgentFor(proxy *ProxyIP) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pickUserAgent(proxy)
+}
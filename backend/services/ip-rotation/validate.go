@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 동기 검증(ValidateProxies)의 동시 실행 수 기본값/상한입니다.
+const (
+	defaultValidateConcurrency = 10
+	maxValidateConcurrency     = 100
+)
+
+// ProxyValidationResult는 단일 프록시의 동기 검증 결과입니다.
+type ProxyValidationResult struct {
+	ProxyID   string `json:"proxyId"`
+	Address   string `json:"address"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs int64  `json:"latencyMs"`
+	ExitIP    string `json:"exitIp,omitempty"` // egress IP reported by exitIpCheckUrl
+	Error     string `json:"error,omitempty"`
+}
+
+// ValidationReport는 ValidateProxies의 전체 결과 요약입니다.
+type ValidationReport struct {
+	Total      int                     `json:"total"`
+	Healthy    int                     `json:"healthy"`
+	Unhealthy  int                     `json:"unhealthy"`
+	DurationMs int64                   `json:"durationMs"`
+	Results    []ProxyValidationResult `json:"results"`
+}
+
+// ValidateProxies는 soft-removed가 아닌 모든 프록시를 최대 concurrency개씩 동시에 점검하고,
+// 결과를 HealthStatus에 반영한 뒤 프록시별 결과 보고서를 반환합니다.
+// ctx가 끝나면 아직 점검하지 못한 프록시는 unhealthy(timed out)로 보고됩니다.
+func (p *IPPool) ValidateProxies(ctx context.Context, concurrency int) ValidationReport {
+	if concurrency <= 0 {
+		concurrency = defaultValidateConcurrency
+	}
+	if concurrency > maxValidateConcurrency {
+		concurrency = maxValidateConcurrency
+	}
+
+	p.mu.RLock()
+	proxies := make([]*ProxyIP, 0, len(p.proxies))
+	timeouts := make(map[*ProxyIP]time.Duration, len(p.proxies))
+	// PATCH may rewrite these while the checks run outside the lock
+	addresses := make(map[*ProxyIP]string, len(p.proxies))
+	protocols := make(map[*ProxyIP]string, len(p.proxies))
+	for _, proxy := range p.proxies {
+		if !proxy.Removed {
+			proxies = append(proxies, proxy)
+			timeouts[proxy] = p.healthCheckTimeout(proxy)
+			addresses[proxy] = proxy.Address
+			protocols[proxy] = proxy.Protocol
+		}
+	}
+	checkURL := p.config.HealthCheckURL
+	exitIPURL := p.config.ExitIPCheckURL
+	p.mu.RUnlock()
+
+	sort.Slice(proxies, func(i, j int) bool { return proxies[i].ID < proxies[j].ID })
+
+	start := time.Now()
+	results := make([]ProxyValidationResult, len(proxies))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, proxy := range proxies {
+		results[i] = ProxyValidationResult{ProxyID: proxy.ID, Address: addresses[proxy]}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Error = "timed out before the check started"
+			continue
+		}
+		wg.Add(1)
+		go func(res *ProxyValidationResult, px *ProxyIP) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeouts[px])
+			defer cancel()
+
+			began := time.Now()
+			err := p.probeProxyHealth(checkCtx, px, checkURL)
+			res.LatencyMs = time.Since(began).Milliseconds()
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Healthy = true
+				if exitIPURL != "" && protocols[px] != "socks4" {
+					res.ExitIP = p.lookupExitIP(checkCtx, px, exitIPURL)
+				}
+			}
+
+			p.mu.Lock()
+			px.LastHealthCheck = time.Now()
+			if res.ExitIP != "" {
+				p.noteExitIPLocked(px, res.ExitIP)
+			}
+			// Reported either way, but opted-out proxies keep their status
+			if px.healthCheckEnabled() {
+				p.applyHealthResult(px, res.Healthy, time.Duration(res.LatencyMs)*time.Millisecond)
+			}
+			p.mu.Unlock()
+		}(&results[i], proxy)
+	}
+	wg.Wait()
+
+	report := ValidationReport{
+		Total:      len(results),
+		DurationMs: time.Since(start).Milliseconds(),
+		Results:    results,
+	}
+	for _, res := range results {
+		if res.Healthy {
+			report.Healthy++
+		} else {
+			report.Unhealthy++
+		}
+	}
+	return report
+}
+
+// lookupExitIP는 프록시를 통해 exitIPURL(IP를 본문으로 반환하는 서비스)을 조회하여 외부에서 보이는 IP를 반환합니다.
+// 조회에 실패하거나 본문이 IP가 아니면 빈 문자열을 반환합니다.
+func (p *IPPool) lookupExitIP(ctx context.Context, proxy *ProxyIP, exitIPURL string) string {
+	proxyURL, header, err := p.proxyEndpoint(proxy)
+	if err != nil {
+		return ""
+	}
+	body, err := fetchThroughProxy(ctx, p.upstreamDial(), proxyURL, header, exitIPURL)
+	if err != nil {
+		return ""
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
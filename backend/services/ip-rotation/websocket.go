@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrNoWebSocketProxy는 WebSocket이 필요한 선택에서 WebSocket 지원이 확인된 프록시가 하나도 없을 때 반환됩니다.
+var ErrNoWebSocketProxy = errors.New("no enabled proxies verified to support WebSocket")
+
+// websocketGUID는 Sec-WebSocket-Accept 계산에 쓰는 RFC 6455 고정 값입니다.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// websocketProbe는 에코 서버로 보내는 점검 메시지입니다.
+var websocketProbe = []byte("ip-rotation websocket probe")
+
+// checkWebSocket은 프록시를 통해 target(ws:// 또는 wss:// 에코 엔드포인트)으로 WebSocket 업그레이드를 시도하고,
+// 핸드셰이크 후 텍스트 프레임 하나가 그대로 되돌아오는지 확인합니다. 업그레이드가 거부되거나 데이터가 흐르지 않으면
+// false를 반환하며, 점검 자체를 수행할 수 없으면 오류를 반환합니다. 점검은 ctx 데드라인으로 제한됩니다.
+func checkWebSocket(ctx context.Context, dial dialFunc, proxyURL *url.URL, proxyHeader http.Header, target string) (bool, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return false, fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+
+	transport := &http.Transport{
+		Proxy:              http.ProxyURL(proxyURL),
+		ProxyConnectHeader: proxyHeader,
+		DialContext:        dial,
+		DisableKeepAlives:  true,
+	}
+	defer transport.CloseIdleConnections()
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return false, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	if u.Scheme == "http" {
+		for name, values := range proxyHeader {
+			req.Header[name] = values
+		}
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return false, nil
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") || resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return false, nil
+	}
+	// Reads on the upgraded connection don't observe ctx by themselves
+	stop := context.AfterFunc(ctx, func() { resp.Body.Close() })
+	defer stop()
+
+	// A 101 response body is the raw upgraded connection
+	conn, ok := resp.Body.(io.ReadWriter)
+	if !ok {
+		return false, errors.New("upgraded connection is not writable")
+	}
+
+	if _, err := conn.Write(maskedTextFrame(websocketProbe)); err != nil {
+		return false, nil
+	}
+	echo, err := readFramePayload(conn, len(websocketProbe))
+	if err != nil {
+		return false, nil
+	}
+	return bytes.Equal(echo, websocketProbe), nil
+}
+
+// websocketAccept는 Sec-WebSocket-Key에 대해 서버가 돌려줘야 하는 Sec-WebSocket-Accept 값을 계산합니다.
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// maskedTextFrame은 클라이언트가 보내는 마스킹된 단일 텍스트 프레임을 만듭니다(페이로드 125바이트 이하).
+func maskedTextFrame(payload []byte) []byte {
+	frame := []byte{0x81, 0x80 | byte(len(payload))}
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// readFramePayload는 서버가 보낸 마스킹되지 않은 프레임 하나를 읽어 페이로드를 반환합니다.
+// 점검용이므로 limit바이트를 넘는 프레임은 오류로 처리합니다.
+func readFramePayload(r io.Reader, limit int) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := int(header[1] & 0x7f)
+	if header[1]&0x80 != 0 || length > limit {
+		return nil, fmt.Errorf("unexpected websocket frame (len=%d)", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// filterWebSocket은 WebSocket 지원이 확인된 프록시만 반환합니다. 호출자는 p.mu 잠금을 보유해야 합니다.
+func filterWebSocket(proxies []*ProxyIP) []*ProxyIP {
+	capable := make([]*ProxyIP, 0, len(proxies))
+	for _, proxy := range proxies {
+		if proxy.SupportsWebSocket {
+			capable = append(capable, proxy)
+		}
+	}
+	return capable
+}
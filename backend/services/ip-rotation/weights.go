@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// weighted 전략의 기본 가중치입니다. IPPoolConfig의 NewProxyWeight/MinWeight가 0이면 사용됩니다.
+const (
+	defaultNewProxyWeight  = 50.0 // score assumed for a proxy with no recorded results
+	defaultMinWeight       = 10.0 // floor and exploration bonus for every proxy
+	defaultExplorationRate = 0.05 // EXPLORATION_RATE when unset: 1 in 20 weighted selections is uniform
+
+	weightCacheTTL = time.Minute // rebuild at least this often so the time-decaying recency term reaches weights
+)
+
+// explore는 weighted 전략이 이번 선택을 가중치 대신 균등 무작위로 할지(epsilon-greedy) 결정합니다.
+// 최소 가중치로 떨어진 프록시도 주기적으로 다시 시도되어 통계를 회복할 기회를 얻습니다.
+// 호출자는 p.mu 잠금(읽기 이상)을 보유해야 합니다.
+func (p *IPPool) explore() bool {
+	rate := p.config.ExplorationRate
+	if rate <= 0 {
+		return false
+	}
+	const resolution = 1_000_000
+	return float64(secureRandomInt(resolution)) < rate*resolution
+}
+
+// weightCache는 가중치 선택에 쓰는 후보 목록과 누적 가중치(prefix sum)를 풀 변경 사이에 재사용하기 위한 캐시입니다.
+// gen이 IPPool.weightsGen과 같고 후보 집합이 같으며 expires 전일 때만 유효합니다. p.mu 쓰기 잠금으로 보호됩니다.
+type weightCache struct {
+	gen     uint64
+	expires time.Time
+	members map[*ProxyIP]bool
+	proxies []*ProxyIP
+	prefix  []float64 // prefix[i] = weights[0] + ... + weights[i]
+}
+
+// invalidateWeights는 가중치 캐시를 무효화합니다. 가중치나 선택 후보 집합에 영향을 주는
+// 모든 변경(결과 기록, 활성화/비활성화, 헬스 상태, 우선순위, 할당량, 설정) 후에 호출해야 합니다.
+// 원자적으로 동작하므로 읽기 잠금만 보유한 기록 경로에서도 호출할 수 있습니다.
+func (p *IPPool) invalidateWeights() {
+	p.weightsGen.Add(1)
+}
+
+// cachedWeights는 현재 후보 목록에 대한 누적 가중치를 반환하며, 캐시가 무효하거나 weightCacheTTL이 지나면
+// 후보의 점수를 지금 시각으로 다시 계산한 뒤 누적 가중치를 다시 만듭니다. 호출자는 p.mu 쓰기 잠금을 보유해야 합니다.
+func (p *IPPool) cachedWeights(proxies []*ProxyIP) *weightCache {
+	now := time.Now()
+	c := &p.weights
+	// Candidate filters can yield different sets of the same size
+	if c.gen == p.weightsGen.Load() && now.Before(c.expires) && c.matches(proxies) {
+		return c
+	}
+
+	// Scores that changed here bump weightsGen, so the generation is read only after refreshing them
+	for _, proxy := range proxies {
+		p.updateScore(proxy, now)
+	}
+	c.gen = p.weightsGen.Load()
+	c.expires = now.Add(weightCacheTTL)
+	c.members = make(map[*ProxyIP]bool, len(proxies))
+	for _, proxy := range proxies {
+		c.members[proxy] = true
+	}
+	c.proxies = append(c.proxies[:0], proxies...)
+	c.prefix = c.prefix[:0]
+	_, minWeight := p.selectionWeights()
+	total := 0.0
+	for _, proxy := range proxies {
+		total += proxyWeight(proxy, minWeight)
+		c.prefix = append(c.prefix, total)
+	}
+	return c
+}
+
+// matches는 캐시가 주어진 후보 집합으로 계산되었는지 반환합니다.
+func (c *weightCache) matches(proxies []*ProxyIP) bool {
+	if len(c.members) != len(proxies) {
+		return false
+	}
+	for _, proxy := range proxies {
+		if !c.members[proxy] {
+			return false
+		}
+	}
+	return true
+}
+
+// pick은 [0, total) 범위의 값에 해당하는 프록시를 이진 탐색으로 찾습니다.
+func (c *weightCache) pick(randVal float64) *ProxyIP {
+	i := sort.Search(len(c.prefix), func(i int) bool { return randVal < c.prefix[i] })
+	if i == len(c.prefix) {
+		// Fallback to last proxy
+		i = len(c.prefix) - 1
+	}
+	return c.proxies[i]
+}
+
+// total은 모든 후보 가중치의 합을 반환합니다.
+func (c *weightCache) total() float64 {
+	if len(c.prefix) == 0 {
+		return 0
+	}
+	return c.prefix[len(c.prefix)-1]
+}
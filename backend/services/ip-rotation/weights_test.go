@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// recordResults는 프록시에 성공 successes회, 실패 failures회를 기록합니다.
+func recordResults(pool *IPPool, id string, successes, failures int) {
+	for i := 0; i < successes; i++ {
+		pool.RecordSuccess(id, 100)
+	}
+	for i := 0; i < failures; i++ {
+		pool.RecordFailure(id, "test")
+	}
+}
+
+func TestSelectWeightedDistributionMatchesWeights(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 3)
+	recordResults(pool, "p0", 20, 0)
+	recordResults(pool, "p1", 10, 10)
+	recordResults(pool, "p2", 0, 20)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	proxies := []*ProxyIP{pool.proxies["p0"], pool.proxies["p1"], pool.proxies["p2"]}
+
+	// Reference shares computed directly, without the cache
+	_, minWeight := pool.selectionWeights()
+	total := 0.0
+	for _, proxy := range proxies {
+		total += proxyWeight(proxy, minWeight)
+	}
+
+	const draws = 20000
+	counts := make(map[*ProxyIP]int)
+	for i := 0; i < draws; i++ {
+		counts[pool.selectWeighted(proxies)]++
+	}
+	for _, proxy := range proxies {
+		want := proxyWeight(proxy, minWeight) / total
+		got := float64(counts[proxy]) / draws
+		if math.Abs(got-want) > 0.02 {
+			t.Errorf("%s share = %.3f, want %.3f", proxy.ID, got, want)
+		}
+	}
+}
+
+func TestSelectWeightedCacheKeyedByCandidateSet(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 4)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	first := []*ProxyIP{pool.proxies["p0"], pool.proxies["p1"]}
+	second := []*ProxyIP{pool.proxies["p2"], pool.proxies["p3"]}
+
+	// Same size and same generation: only the members differ
+	pool.selectWeighted(first)
+	for i := 0; i < 100; i++ {
+		selected := pool.selectWeighted(second)
+		if selected != second[0] && selected != second[1] {
+			t.Fatalf("selected %s, which is not a candidate", selected.ID)
+		}
+	}
+}
+
+// cachedWeightOf는 가중치 캐시에 저장된 proxy의 가중치를 반환합니다. 호출자는 pool.mu 쓰기 잠금을 보유해야 합니다.
+func cachedWeightOf(pool *IPPool, proxies []*ProxyIP, proxy *ProxyIP) float64 {
+	c := pool.cachedWeights(proxies)
+	for i, member := range c.proxies {
+		if member == proxy {
+			if i == 0 {
+				return c.prefix[0]
+			}
+			return c.prefix[i] - c.prefix[i-1]
+		}
+	}
+	return 0
+}
+
+func TestCachedWeightsFollowRecencyDecay(t *testing.T) {
+	pool := newTestPool(t, IPPoolConfig{}, 2)
+	recordResults(pool, "p0", 9, 1)
+	proxy := pool.proxies["p0"]
+	proxies := []*ProxyIP{proxy, pool.proxies["p1"]}
+
+	pool.mu.Lock()
+	_, minWeight := pool.selectionWeights()
+	fresh := cachedWeightOf(pool, proxies, proxy)
+	// An hour passes since the failure; nothing is recorded in between
+	proxy.LastFailure.Store(time.Now().Add(-time.Hour))
+	if got := cachedWeightOf(pool, proxies, proxy); got != fresh {
+		t.Errorf("weight changed to %.2f before the cache expired, want %.2f", got, fresh)
+	}
+	pool.weights.expires = time.Time{}
+	recovered := cachedWeightOf(pool, proxies, proxy)
+	if recovered <= fresh {
+		t.Errorf("weight after the cache expired = %.2f, want above %.2f as the failure ages", recovered, fresh)
+	}
+	if want := proxyWeight(proxy, minWeight); recovered != want {
+		t.Errorf("cached weight %.2f disagrees with the score-based weight %.2f", recovered, want)
+	}
+	pool.mu.Unlock()
+
+	// A listing refreshes the score; the cache must pick it up instead of serving the old prefix sums
+	proxy.LastFailure.Store(time.Now().Add(-3 * time.Hour))
+	pool.GetAllProxies()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if got, want := cachedWeightOf(pool, proxies, proxy), proxyWeight(proxy, minWeight); got != want {
+		t.Errorf("cached weight %.2f disagrees with the listed score's weight %.2f", got, want)
+	}
+}